@@ -0,0 +1,106 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"cmp"
+	"maps"
+	"slices"
+)
+
+// accessCounter counts the successful lookups of each element.
+// A nil accessCounter counts nothing.
+type accessCounter struct {
+	hits map[string]uint64 // Lookups that found the element
+}
+
+// newAccessCounter creates an empty accessCounter.
+func newAccessCounter() *accessCounter {
+	return &accessCounter{
+		hits: make(map[string]uint64),
+	}
+}
+
+// clone returns an independent copy of the counts.
+func (a *accessCounter) clone() *accessCounter {
+	if a == nil {
+		return nil
+	}
+	return &accessCounter{hits: maps.Clone(a.hits)}
+}
+
+// hit counts a successful lookup of value.
+func (a *accessCounter) hit(value []byte) {
+	if a == nil {
+		return
+	}
+	a.hits[string(value)]++
+}
+
+// remove forgets the count of value.
+func (a *accessCounter) remove(value []byte) {
+	if a == nil {
+		return
+	}
+	delete(a.hits, string(value))
+}
+
+// clear forgets every count.
+func (a *accessCounter) clear() {
+	if a == nil {
+		return
+	}
+	clear(a.hits)
+}
+
+// TopK returns up to k members with the most successful Contains lookups, most hit first.
+// Members never found by Contains are not returned, ties are ordered by bytes.Compare.
+// It returns nil if the set was not created with the CountAccess option.
+func (h *HashSet) TopK(k int) [][]byte {
+	if h.access == nil || k <= 0 {
+		return nil
+	}
+
+	members := make([][]byte, 0, len(h.access.hits))
+	for value := range h.access.hits {
+		members = append(members, []byte(value))
+	}
+
+	slices.SortFunc(members, func(a, b []byte) int {
+		if c := cmp.Compare(h.access.hits[string(b)], h.access.hits[string(a)]); c != 0 {
+			return c // Most hit first
+		}
+		return bytes.Compare(a, b)
+	})
+
+	return members[:min(k, len(members))]
+}
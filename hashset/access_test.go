@@ -0,0 +1,77 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import "testing"
+
+func TestHashSet_TopK(t *testing.T) {
+	set := mustHashSet(t, Options{CountAccess: true})
+	for _, value := range []string{"a", "b", "c", "d"} {
+		set.Add([]byte(value))
+	}
+
+	for value, hits := range map[string]int{"a": 1, "b": 5, "c": 3} {
+		for i := 0; i < hits; i++ {
+			set.Contains([]byte(value))
+		}
+	}
+	set.Contains([]byte("missing"))
+
+	top := set.TopK(2)
+	if len(top) != 2 || string(top[0]) != "b" || string(top[1]) != "c" {
+		t.Errorf("Expected top 2 to be [b c], got %q", top)
+	}
+
+	// Members never looked up are not returned
+	if all := set.TopK(10); len(all) != 3 {
+		t.Errorf("Expected 3 hit members, got %q", all)
+	}
+
+	set.Remove([]byte("b"))
+	if top := set.TopK(1); len(top) != 1 || string(top[0]) != "c" {
+		t.Errorf("Expected top 1 after remove to be [c], got %q", top)
+	}
+
+	set.Clear()
+	if top := set.TopK(1); len(top) != 0 {
+		t.Errorf("Expected no hit members after clear, got %q", top)
+	}
+}
+
+func TestHashSet_TopKDisabled(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("a"))
+	set.Contains([]byte("a"))
+
+	if top := set.TopK(1); top != nil {
+		t.Errorf("Expected TopK to be nil without CountAccess, got %q", top)
+	}
+}
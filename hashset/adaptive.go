@@ -0,0 +1,104 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+const adaptiveWindow = 4096 // operations observed before the load factor is reconsidered
+const readHeavyShare = 0.9  // share of lookups above which the set is serving
+const writeHeavyShare = 0.5 // share of lookups below which the set is building
+const serveLoadFactor = 0.5 // load factor of a serving set, fewer collisions per lookup
+const buildLoadFactor = 0.9 // load factor of a building set, fewer resizes per insert
+
+// adaptiveLoad follows the read/write mix of the set, for the AdaptiveLoadFactor option.
+// A nil adaptiveLoad keeps the default load factor threshold.
+type adaptiveLoad struct {
+	factor float64 // Current load factor threshold
+	reads  int     // Lookups in the current window
+	writes int     // Mutations in the current window
+}
+
+// newAdaptiveLoad creates an adaptiveLoad starting at the default threshold.
+func newAdaptiveLoad() *adaptiveLoad {
+	return &adaptiveLoad{factor: loadFactorThreshold}
+}
+
+// clone returns an independent copy of the adaptive state.
+func (a *adaptiveLoad) clone() *adaptiveLoad {
+	if a == nil {
+		return nil
+	}
+	c := *a
+	return &c
+}
+
+// ResizeThreshold returns the load factor past which the set resizes, the default threshold of 0.7
+// unless the AdaptiveLoadFactor option moved it.
+func (h *HashSet) ResizeThreshold() float64 {
+	if h.adaptive == nil {
+		return loadFactorThreshold
+	}
+	return h.adaptive.factor
+}
+
+// adapt counts a lookup or a mutation. At the end of every window it picks the load factor of the phase
+// the mix of operations suggests, and grows the set at once if it now exceeds a lowered load factor.
+// A raised load factor only spaces out the following resizes, the set never shrinks to reach it.
+func (h *HashSet) adapt(read bool) {
+	a := h.adaptive
+	if a == nil || h.frozen {
+		return
+	}
+	if read {
+		a.reads++
+	} else {
+		a.writes++
+	}
+	if a.reads+a.writes < adaptiveWindow {
+		return
+	}
+
+	share := float64(a.reads) / float64(a.reads+a.writes)
+	a.reads, a.writes = 0, 0
+	factor := loadFactorThreshold
+	switch {
+	case share >= readHeavyShare:
+		factor = serveLoadFactor
+	case share <= writeHeavyShare:
+		factor = buildLoadFactor
+	}
+	if factor == a.factor {
+		return
+	}
+
+	a.factor = factor
+	if capacity := OptimalCapacity(h.Size, factor); capacity > h.Capacity && h.opts.BucketLimit <= 0 {
+		h.rehash(capacity)
+	}
+}
@@ -0,0 +1,80 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_AdaptiveLoadFactor(t *testing.T) {
+	set := mustHashSet(t, Options{AdaptiveLoadFactor: true})
+	if set.ResizeThreshold() != loadFactorThreshold {
+		t.Errorf("Expected the default threshold, got %v", set.ResizeThreshold())
+	}
+
+	// Building: only inserts, the threshold rises
+	for i := 0; i < 2*adaptiveWindow; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	if set.ResizeThreshold() != buildLoadFactor {
+		t.Errorf("Expected the build threshold, got %v", set.ResizeThreshold())
+	}
+	capacity := set.Capacity
+	for float64(set.Size)/float64(set.Capacity) <= 0.8 {
+		set.Add([]byte(fmt.Sprintf("test%d", set.Size)))
+	}
+	if set.Capacity != capacity {
+		t.Errorf("Expected the build phase to fill the set past the default load factor without a resize")
+	}
+
+	// Serving: only lookups, the threshold falls and the set grows at once
+	for i := 0; i < 2*adaptiveWindow; i++ {
+		if !set.Contains([]byte(fmt.Sprintf("test%d", i))) {
+			t.Fatalf("Expected test%d to be found", i)
+		}
+	}
+	if set.ResizeThreshold() != serveLoadFactor {
+		t.Errorf("Expected the serve threshold, got %v", set.ResizeThreshold())
+	}
+	if load := float64(set.Size) / float64(set.Capacity); load > serveLoadFactor {
+		t.Errorf("Expected the set to rehash to the serve load factor, got %v", load)
+	}
+	for i := 0; i < set.Size; i++ {
+		if !set.Contains([]byte(fmt.Sprintf("test%d", i))) {
+			t.Errorf("Expected test%d to survive the rehash", i)
+		}
+	}
+
+	if NewHashSet().ResizeThreshold() != loadFactorThreshold {
+		t.Errorf("Expected the default threshold without the option")
+	}
+}
@@ -0,0 +1,107 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import "unsafe"
+
+// arena holds every element of a set in two contiguous arrays, so full scans read memory sequentially.
+// It describes the set as of its generation and is ignored once the set is mutated.
+type arena struct {
+	data       []byte        // Bytes of the elements, bucket after bucket
+	items      []interface{} // Elements viewing data, bucket after bucket
+	offsets    []int         // Bucket i holds items[offsets[i]:offsets[i+1]]
+	generation uint64        // Generation of the set the arena was built at
+}
+
+// Arena compacts the elements and the bucket slices of the set into single backing arrays.
+// Lookups are unchanged while ForEach and ToSlice scan the arrays sequentially.
+// A later mutation falls back to the normal layout, calling Arena again compacts the set anew.
+func (h *HashSet) Arena() {
+	total := 0
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			total += len(item.([]byte))
+		}
+	}
+
+	a := &arena{
+		data:       make([]byte, 0, total),
+		items:      make([]interface{}, 0, h.Size),
+		offsets:    make([]int, h.Capacity+1),
+		generation: h.generation,
+	}
+	for i, bucket := range h.Buckets {
+		a.offsets[i] = len(a.items)
+		for _, item := range bucket {
+			start := len(a.data)
+			a.data = append(a.data, item.([]byte)...)
+			a.items = append(a.items, a.data[start:len(a.data):len(a.data)])
+		}
+	}
+	a.offsets[h.Capacity] = len(a.items)
+
+	// Cap every bucket at its own length so an append reallocates instead of overwriting the next bucket
+	for i, bucket := range h.Buckets {
+		if h.opts.Allocator != nil && !h.isShared(i) && !h.inArena(bucket) {
+			h.releaseBucket(bucket) // Return the bucket the arena replaces to the Allocator option
+		}
+		start, end := a.offsets[i], a.offsets[i+1]
+		h.Buckets[i] = a.items[start:end:end]
+	}
+	h.arena = a
+	h.shared = nil // No bucket is shared with a clone anymore
+}
+
+// inArena reports whether bucket views the items of the arena, even one built before the last mutation.
+// Such a bucket was not handed out by the Allocator option and is never passed to its Free.
+func (h *HashSet) inArena(bucket []interface{}) bool {
+	if h.arena == nil || cap(bucket) == 0 || cap(h.arena.items) == 0 {
+		return false
+	}
+	start := uintptr(unsafe.Pointer(unsafe.SliceData(h.arena.items)))
+	p := uintptr(unsafe.Pointer(unsafe.SliceData(bucket[:cap(bucket)])))
+	return p >= start && p < start+uintptr(cap(h.arena.items))*unsafe.Sizeof(interface{}(nil))
+}
+
+// Freeze compacts the set into an arena and makes it read-only.
+func (h *HashSet) Freeze() {
+	h.finishGrowth() // A frozen set can no longer split its buckets
+	h.Arena()
+	h.frozen = true
+}
+
+// currentArena returns the arena of the set, or nil if there is none or the set changed since it was built.
+func (h *HashSet) currentArena() *arena {
+	if h.arena == nil || h.arena.generation != h.generation {
+		return nil
+	}
+	return h.arena
+}
@@ -0,0 +1,107 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_Arena(t *testing.T) {
+	h := NewHashSet()
+	for i := 0; i < 100; i++ {
+		h.Add([]byte(fmt.Sprintf("value%d", i)))
+	}
+	before := h.ToSlice()
+
+	h.Arena()
+	if h.currentArena() == nil {
+		t.Fatalf("Expected an arena after Arena")
+	}
+
+	after := h.ToSlice()
+	if len(after) != len(before) {
+		t.Fatalf("Expected %d values from the arena, got %d", len(before), len(after))
+	}
+	for i := range before {
+		if !bytes.Equal(before[i], after[i]) {
+			t.Errorf("Expected value %d to be %s, got %s", i, before[i], after[i])
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		if !h.Contains([]byte(fmt.Sprintf("value%d", i))) {
+			t.Errorf("Expected value%d to be found after Arena", i)
+		}
+	}
+}
+
+func TestHashSet_ArenaMutation(t *testing.T) {
+	h := NewHashSetWithCapacity(4)
+	for i := 0; i < 2; i++ {
+		h.Add([]byte(fmt.Sprintf("value%d", i)))
+	}
+	h.Arena()
+
+	// Appending to a bucket must not overwrite the elements of the next one
+	for i := 2; i < 100; i++ {
+		h.Add([]byte(fmt.Sprintf("value%d", i)))
+	}
+	if h.currentArena() != nil {
+		t.Errorf("Expected the arena to be dropped after a mutation")
+	}
+	for i := 0; i < 100; i++ {
+		if !h.Contains([]byte(fmt.Sprintf("value%d", i))) {
+			t.Errorf("Expected value%d to be found", i)
+		}
+	}
+	if len(h.ToSlice()) != 100 {
+		t.Errorf("Expected 100 values, got %d", len(h.ToSlice()))
+	}
+}
+
+func TestHashSet_Freeze(t *testing.T) {
+	h := NewHashSet()
+	h.Add([]byte("a"))
+	h.Freeze()
+
+	if !h.Frozen() || h.currentArena() == nil {
+		t.Fatalf("Expected a frozen set with an arena")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected Add on a frozen set to panic")
+		}
+	}()
+	h.Add([]byte("b"))
+}
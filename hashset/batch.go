@@ -0,0 +1,216 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AddSorted inserts values sorted by bytes.Compare and returns the number of elements added.
+// Capacity is reserved once up front and adjacent duplicate inputs are skipped without scanning their bucket,
+// except under the Multiset option, which counts every occurrence.
+func (h *HashSet) AddSorted(values [][]byte) int {
+	h.checkMutable()
+
+	h.reserve(h.Size + len(values)) // Reserve for the worst case of all values being new
+
+	added := 0
+	var prev []byte
+	for i, value := range values {
+		// Sorted input places duplicates next to each other
+		if i > 0 && h.counts == nil && bytes.Equal(prev, value) {
+			continue
+		}
+		prev = value
+
+		if ok, _ := h.Add(value); ok {
+			added++
+		}
+	}
+
+	return added
+}
+
+// AddFromIter inserts the keys next returns until it reports false and returns the number of elements added,
+// bridging an iterator to the set without collecting its keys first. Keys are expected sorted by bytes.Compare,
+// like a compaction iterator yields them, so adjacent duplicates are skipped without scanning their bucket, except
+// under the Multiset option, which counts every occurrence; unsorted keys are still all inserted. The set keeps
+// the returned slices, next must not reuse them.
+func (h *HashSet) AddFromIter(next func() ([]byte, bool)) int {
+	h.checkMutable()
+
+	added := 0
+	var prev []byte
+	for first := true; ; first = false {
+		value, ok := next()
+		if !ok {
+			return added
+		}
+
+		// Sorted input places duplicates next to each other
+		if !first && h.counts == nil && bytes.Equal(prev, value) {
+			continue
+		}
+		prev = value
+
+		if ok, _ := h.Add(value); ok {
+			added++
+		}
+	}
+}
+
+// AddDeduped inserts values after dropping the duplicates within them, so each distinct value is hashed once,
+// and returns the number of elements added. Deduplicating sorts values in place and clears the entries past the
+// distinct values, the caller must not rely on its order or contents afterwards. Capacity is reserved once for
+// the distinct values. Under the Multiset option every occurrence is counted, values is sorted but not compacted.
+func (h *HashSet) AddDeduped(values [][]byte) int {
+	h.checkMutable()
+
+	slices.SortFunc(values, bytes.Compare)
+	if h.counts != nil {
+		return h.AddSorted(values) // Dropping the duplicates would lose their counts
+	}
+	return h.AddSorted(slices.CompactFunc(values, bytes.Equal))
+}
+
+// AddAll inserts values and returns the number of elements added.
+// Capacity is reserved once up front for the worst case of all values being new.
+// Values refused by Add, see ErrCapacityExceeded, are skipped.
+func (h *HashSet) AddAll(values [][]byte) int {
+	h.checkMutable()
+
+	h.reserve(h.Size + len(values))
+
+	added := 0
+	for _, value := range values {
+		if ok, _ := h.Add(value); ok {
+			added++
+		}
+	}
+	return added
+}
+
+// AddAllParallel is AddAll with the stored forms and digests of the values computed by workers goroutines
+// before the values are inserted one by one, leaving the set identical to AddAll of the same values.
+// Hashing dominates the cost of cold bulk loads, inserting does not. The Normalize option, when set,
+// is called concurrently. With the Profiling option every insertion is recorded as an Add. A workers count
+// below one is raised to one.
+func (h *HashSet) AddAllParallel(values [][]byte, workers int) int {
+	h.checkMutable()
+
+	h.reserve(h.Size + len(values))
+
+	stored := make([][]byte, len(values))
+	digests := make([]uint64, len(values))
+	workers = min(max(workers, 1), max(len(values), 1))
+
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				stored[i] = h.key(values[i])
+				digests[i] = h.digest(stored[i])
+			}
+		}(w*len(values)/workers, (w+1)*len(values)/workers)
+	}
+	wg.Wait()
+
+	added := 0
+	for i, value := range values {
+		var start time.Time
+		if h.latency != nil {
+			start = time.Now()
+		}
+		if ok, _ := h.addHashed(value, stored[i], digests[i]); ok {
+			added++
+		}
+		if h.latency != nil {
+			h.latency.Add.observe(start) // Profiled like the Add of AddAll, hashing aside
+		}
+	}
+	return added
+}
+
+// ContainsBatch checks every value and returns the results as a bitset of (len(values)+63)/64 words.
+// Bit i%64 of word i/64, counting from the least significant bit, is set when values[i] is in the set.
+// Values are hashed once each and probed in input order.
+func (h *HashSet) ContainsBatch(values [][]byte) []uint64 {
+	bits := make([]uint64, (len(values)+63)/64)
+	for i, value := range values {
+		if h.Contains(value) {
+			bits[i/64] |= 1 << (i % 64)
+		}
+	}
+	return bits
+}
+
+// ContainsSortedBatch checks every value and returns the results aligned to values. A run of equal adjacent
+// values is looked up once and its result reused, so sorting the batch, by bytes.Compare or any order keeping
+// equal values together, avoids hashing repeated queries. Unsorted input is answered correctly, only the
+// repeats that are not adjacent are looked up again. With the CountAccess option a run counts as one lookup.
+func (h *HashSet) ContainsSortedBatch(sorted [][]byte) []bool {
+	results := make([]bool, len(sorted))
+	for i, value := range sorted {
+		if i > 0 && bytes.Equal(value, sorted[i-1]) {
+			results[i] = results[i-1] // Same query as the previous one
+			continue
+		}
+		results[i] = h.Contains(value)
+	}
+	return results
+}
+
+// ContainedSubset returns the values that are in the set, in input order.
+// With NotContained it partitions a batch into present and absent values. Values are hashed once each.
+func (h *HashSet) ContainedSubset(values [][]byte) [][]byte {
+	return h.filterContained(values, true)
+}
+
+// NotContained returns the values that are not in the set, in input order. Values are hashed once each.
+func (h *HashSet) NotContained(values [][]byte) [][]byte {
+	return h.filterContained(values, false)
+}
+
+// filterContained returns the values whose membership is present, in input order.
+func (h *HashSet) filterContained(values [][]byte, present bool) [][]byte {
+	var kept [][]byte
+	for _, value := range values {
+		if h.Contains(value) == present {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
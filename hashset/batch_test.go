@@ -0,0 +1,283 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestHashSet_AddSorted(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("b"))
+
+	values := [][]byte{
+		[]byte("a"),
+		[]byte("a"),
+		[]byte("b"),
+		[]byte("c"),
+		[]byte("c"),
+		[]byte("c"),
+	}
+
+	added := set.AddSorted(values)
+	if added != 2 {
+		t.Errorf("Expected 2 values to be added, got %d", added)
+	}
+
+	if set.Size != 3 {
+		t.Errorf("Expected size to be 3, got %d", set.Size)
+	}
+
+	for _, value := range values {
+		if !set.Contains(value) {
+			t.Errorf("Expected set to contain %v", value)
+		}
+	}
+}
+
+func TestHashSet_AddSortedMultiset(t *testing.T) {
+	set := mustHashSet(t, Options{Multiset: true})
+	set.AddSorted([][]byte{[]byte("a"), []byte("a"), []byte("b"), []byte("c"), []byte("c"), []byte("c")})
+
+	for value, want := range map[string]uint64{"a": 2, "b": 1, "c": 3} {
+		if got := set.Count([]byte(value)); got != want {
+			t.Errorf("Expected %s to be counted %d times, got %d", value, want, got)
+		}
+	}
+}
+
+func TestHashSet_AddSortedReserve(t *testing.T) {
+	set := NewHashSet()
+
+	values := make([][]byte, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		values = append(values, []byte(fmt.Sprintf("test%04d", i)))
+	}
+
+	if added := set.AddSorted(values); added != 1000 {
+		t.Errorf("Expected 1000 values to be added, got %d", added)
+	}
+
+	if set.Capacity != capacityFor(1000) {
+		t.Errorf("Expected capacity to be %d, got %d", capacityFor(1000), set.Capacity)
+	}
+}
+
+func TestHashSet_AddFromIter(t *testing.T) {
+	keys := []string{"", "a", "a", "b", "c", "c", "c", "d"}
+	set := NewHashSet()
+	set.Add([]byte("d"))
+
+	i := 0
+	added := set.AddFromIter(func() ([]byte, bool) {
+		if i == len(keys) {
+			return nil, false
+		}
+		i++
+		return []byte(keys[i-1]), true
+	})
+
+	if added != 4 {
+		t.Errorf("Expected 4 elements added, got %d", added)
+	}
+	if set.Size != 5 {
+		t.Errorf("Expected size 5, got %d", set.Size)
+	}
+	for _, key := range keys {
+		if !set.Contains([]byte(key)) {
+			t.Errorf("Expected %q to be present", key)
+		}
+	}
+
+	if added := set.AddFromIter(func() ([]byte, bool) { return nil, false }); added != 0 {
+		t.Errorf("Expected an empty iterator to add nothing, got %d", added)
+	}
+}
+
+func TestHashSet_AddFromIterMultiset(t *testing.T) {
+	keys := []string{"a", "a", "b", "c", "c", "c"}
+	set := mustHashSet(t, Options{Multiset: true})
+
+	i := 0
+	set.AddFromIter(func() ([]byte, bool) {
+		if i == len(keys) {
+			return nil, false
+		}
+		i++
+		return []byte(keys[i-1]), true
+	})
+
+	for value, want := range map[string]uint64{"a": 2, "b": 1, "c": 3} {
+		if got := set.Count([]byte(value)); got != want {
+			t.Errorf("Expected %s to be counted %d times, got %d", value, want, got)
+		}
+	}
+}
+
+func TestHashSet_AddDeduped(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("b"))
+
+	values := [][]byte{[]byte("c"), []byte("a"), []byte("b"), []byte("a"), []byte("c"), []byte("a")}
+	if added := set.AddDeduped(values); added != 2 {
+		t.Errorf("Expected 2 new elements, got %d", added)
+	}
+	if set.Size != 3 || !set.Contains([]byte("a")) || !set.Contains([]byte("c")) {
+		t.Errorf("Expected a, b and c in the set, got %d elements", set.Size)
+	}
+}
+
+func TestHashSet_AddDedupedMultiset(t *testing.T) {
+	set := mustHashSet(t, Options{Multiset: true})
+	values := [][]byte{[]byte("c"), []byte("a"), []byte("c"), []byte("b"), []byte("a"), []byte("c")}
+
+	if added := set.AddDeduped(values); added != 3 {
+		t.Errorf("Expected 3 distinct values to be added, got %d", added)
+	}
+	for value, want := range map[string]uint64{"a": 2, "b": 1, "c": 3} {
+		if got := set.Count([]byte(value)); got != want {
+			t.Errorf("Expected %s to be counted %d times, got %d", value, want, got)
+		}
+	}
+}
+
+func TestHashSet_ContainsBatch(t *testing.T) {
+	set := NewHashSet()
+	values := make([][]byte, 130)
+	for i := range values {
+		values[i] = []byte(fmt.Sprintf("test%d", i))
+		if i%3 == 0 {
+			set.Add(values[i])
+		}
+	}
+
+	bits := set.ContainsBatch(values)
+	if len(bits) != 3 {
+		t.Fatalf("Expected 3 words for 130 values, got %d", len(bits))
+	}
+
+	for i := range values {
+		if got := bits[i/64]&(1<<(i%64)) != 0; got != (i%3 == 0) {
+			t.Errorf("Expected bit %d to be %v, got %v", i, i%3 == 0, got)
+		}
+	}
+
+	if bits := set.ContainsBatch(nil); len(bits) != 0 {
+		t.Errorf("Expected an empty batch to return no words, got %d", len(bits))
+	}
+}
+
+func TestHashSet_ContainsSortedBatch(t *testing.T) {
+	set := mustHashSet(t, Options{CountAccess: true})
+	set.Add([]byte("b"))
+	set.Add([]byte("d"))
+
+	queries := [][]byte{[]byte("a"), []byte("b"), []byte("b"), []byte("b"), []byte("c"), []byte("d"), []byte("d")}
+	want := []bool{false, true, true, true, false, true, true}
+	if got := set.ContainsSortedBatch(queries); !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if hits := set.access.hits["b"]; hits != 1 {
+		t.Errorf("Expected a run of equal queries to be looked up once, got %d lookups", hits)
+	}
+
+	unsorted := [][]byte{[]byte("d"), []byte("a"), []byte("d")}
+	if got := set.ContainsSortedBatch(unsorted); !slices.Equal(got, []bool{true, false, true}) {
+		t.Errorf("Expected unsorted queries to be answered correctly, got %v", got)
+	}
+	if got := set.ContainsSortedBatch(nil); len(got) != 0 {
+		t.Errorf("Expected an empty batch to return no results, got %v", got)
+	}
+}
+
+func TestHashSet_ContainedSubset(t *testing.T) {
+	set := NewHashSet()
+	values := make([][]byte, 100)
+	for i := range values {
+		values[i] = []byte(fmt.Sprintf("test%d", i))
+		if i%3 == 0 {
+			set.Add(values[i])
+		}
+	}
+
+	present := set.ContainedSubset(values)
+	absent := set.NotContained(values)
+	if len(present)+len(absent) != len(values) {
+		t.Fatalf("Expected the batch to be partitioned, got %d present and %d absent", len(present), len(absent))
+	}
+
+	for i, value := range present {
+		if want := []byte(fmt.Sprintf("test%d", 3*i)); !bytes.Equal(value, want) {
+			t.Errorf("Expected present value %d to be %s, got %s", i, want, value)
+		}
+	}
+	for _, value := range absent {
+		if set.Contains(value) {
+			t.Errorf("Expected %s to be absent", value)
+		}
+	}
+}
+
+func TestHashSet_AddAllParallel(t *testing.T) {
+	values := make([][]byte, 0, 20000)
+	for i := 0; i < 20000; i++ {
+		values = append(values, []byte(fmt.Sprintf("key%d", i%15000))) // With duplicates
+	}
+
+	serial := mustHashSet(t, Options{InsertionOrder: true, Profiling: true})
+	if added := serial.AddAll(values); added != 15000 {
+		t.Fatalf("Expected 15000 elements added by AddAll, got %d", added)
+	}
+
+	for _, workers := range []int{0, 1, 3, 8} {
+		parallel := mustHashSet(t, Options{InsertionOrder: true, Profiling: true})
+		if added := parallel.AddAllParallel(values, workers); added != 15000 {
+			t.Errorf("Expected 15000 elements added with %d workers, got %d", workers, added)
+		}
+		if parallel.Capacity != serial.Capacity || !reflect.DeepEqual(parallel.Buckets, serial.Buckets) {
+			t.Errorf("Expected the set built with %d workers to be identical to AddAll", workers)
+		}
+		if !reflect.DeepEqual(parallel.ToSlice(), serial.ToSlice()) {
+			t.Errorf("Expected the insertion order with %d workers to match AddAll", workers)
+		}
+		if got := parallel.LatencyStats().Add.Count; got != serial.LatencyStats().Add.Count {
+			t.Errorf("Expected %d profiled adds with %d workers, got %d", serial.LatencyStats().Add.Count, workers, got)
+		}
+	}
+
+	if added := NewHashSet().AddAllParallel(nil, 4); added != 0 {
+		t.Errorf("Expected nothing added from no values, got %d", added)
+	}
+}
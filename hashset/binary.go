@@ -0,0 +1,252 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"math/bits"
+	"unsafe"
+)
+
+// Binary format
+//
+//	magic    [4]byte  "K4HS"
+//	version  uint8
+//	hasher   uint8    absent in version 1, which is always murmur
+//	seed     uint64   little endian
+//	capacity uvarint
+//	size     uvarint
+//	members  size x (uvarint length, bytes) in bucket order
+//	checksum uint32   little endian crc32 (IEEE) of everything before it
+const binaryMagic = "K4HS"
+const binaryVersion = 2
+const binaryHeaderLen = len(binaryMagic) + 2 + 8 // magic, version, hasher and seed
+const binaryChecksumLen = 4
+
+// maxSparseFactor bounds how much larger than required a decoded capacity may be.
+const maxSparseFactor = 64
+
+// MarshalBinary encodes the HashSet into the binary format.
+func (h *HashSet) MarshalBinary() ([]byte, error) {
+	if err := h.checkIDOnlyHasher("binary"); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, binaryHeaderLen+2*binary.MaxVarintLen64+binaryChecksumLen)
+	buf = append(buf, binaryMagic...)
+	buf = append(buf, binaryVersion)
+	buf = append(buf, h.Hasher)
+	buf = binary.LittleEndian.AppendUint64(buf, h.Seed)
+	buf = binary.AppendUvarint(buf, uint64(h.Capacity))
+	buf = binary.AppendUvarint(buf, uint64(h.Size))
+
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			value := item.([]byte)
+			buf = binary.AppendUvarint(buf, uint64(len(value)))
+			buf = append(buf, value...)
+		}
+	}
+
+	return binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf)), nil
+}
+
+// SerializedSize returns the exact length of the payload MarshalBinary encodes the set into, in O(n)
+// without encoding it.
+func (h *HashSet) SerializedSize() int {
+	n := binaryHeaderLen + uvarintLen(uint64(h.Capacity)) + uvarintLen(uint64(h.Size)) + binaryChecksumLen
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			n += uvarintLen(uint64(len(item.([]byte)))) + len(item.([]byte))
+		}
+	}
+	return n
+}
+
+// uvarintLen returns the number of bytes binary.AppendUvarint encodes v in.
+func uvarintLen(v uint64) int {
+	return (bits.Len64(v|1) + 6) / 7
+}
+
+// UnmarshalBinary decodes the binary format into the HashSet, replacing its contents.
+// Arbitrary input never panics, malformed input returns an error and leaves the set unchanged.
+// The members are copied out of data, which the caller may reuse, see DeserializeZeroCopy.
+func (h *HashSet) UnmarshalBinary(data []byte) error {
+	h.checkMutable()
+	return h.unmarshalBinary(bytes.Clone(data)) // One copy the members reference
+}
+
+// DeserializeZeroCopy decodes the binary format of MarshalBinary into a new HashSet whose members are
+// subslices of data rather than copies, so a set loaded from a memory-mapped file only allocates its buckets.
+// data must outlive the set and must not be modified while the set is in use. The set never writes to data:
+// mutations only change the buckets and add the caller's slices, and ClearSecure leaves the borrowed members
+// as they are, zeroing only those added since.
+func DeserializeZeroCopy(data []byte) (*HashSet, error) {
+	h := NewHashSet()
+	if err := h.unmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	h.borrowed = data
+	return h, nil
+}
+
+// borrows reports whether value lies in the buffer the set was decoded from by DeserializeZeroCopy.
+func (h *HashSet) borrows(value []byte) bool {
+	if len(h.borrowed) == 0 || len(value) == 0 {
+		return false
+	}
+	start := uintptr(unsafe.Pointer(unsafe.SliceData(h.borrowed)))
+	p := uintptr(unsafe.Pointer(unsafe.SliceData(value)))
+	return p >= start && p < start+uintptr(len(h.borrowed))
+}
+
+// unmarshalBinary is UnmarshalBinary keeping subslices of data as the members.
+func (h *HashSet) unmarshalBinary(data []byte) error {
+
+	if len(data) < len(binaryMagic)+1 {
+		return wrapf(ErrCorrupt, "corrupt hashset: payload too short")
+	}
+
+	if !bytes.Equal(data[:len(binaryMagic)], []byte(binaryMagic)) {
+		return wrapf(ErrCorrupt, "corrupt hashset: invalid magic")
+	}
+
+	// Version 1 has no hasher byte
+	headerLen := binaryHeaderLen
+	version := data[len(binaryMagic)]
+	switch version {
+	case 1:
+		headerLen--
+	case binaryVersion:
+	default:
+		return wrapf(ErrUnsupportedVersion, "unsupported hashset version %d", version)
+	}
+
+	if len(data) < headerLen+binaryChecksumLen {
+		return wrapf(ErrCorrupt, "corrupt hashset: payload too short")
+	}
+
+	// Verify the checksum before trusting any of the content
+	body := data[:len(data)-binaryChecksumLen]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(body):]) {
+		return wrapf(ErrChecksumMismatch, "corrupt hashset: checksum mismatch")
+	}
+
+	id := hasherMurmur
+	if version != 1 {
+		id = body[len(binaryMagic)+1]
+	}
+
+	// Decode with the hasher of the encoding set so its digests stay valid
+	hasher, err := lookupHasher(id)
+	if err != nil {
+		return err
+	}
+
+	seed := binary.LittleEndian.Uint64(body[headerLen-8:])
+	rest := body[headerLen:]
+
+	capacity, rest, err := readUvarint(rest)
+	if err != nil {
+		return err
+	}
+
+	size, rest, err := readUvarint(rest)
+	if err != nil {
+		return err
+	}
+
+	if capacity == 0 || capacity > maxCapacity || capacity&(capacity-1) != 0 {
+		return wrapf(ErrCorrupt, "corrupt hashset: invalid capacity %d", capacity)
+	}
+
+	// Every member takes at least its length prefix
+	if size > uint64(len(rest)) {
+		return wrapf(ErrCorrupt, "corrupt hashset: size %d exceeds payload", size)
+	}
+
+	decoded := newHashSet(decodedCapacity(int(capacity), int(size)), seed)
+	if h.opts.RecomputeCapacity {
+		decoded = newHashSet(max(capacityFor(int(size)), initialCapacity), seed) // Only the member count matters
+	}
+	decoded.Hasher, decoded.hasher = id, hasher
+	decoded.applyOptions(h.opts)
+
+	for i := uint64(0); i < size; i++ {
+		var n uint64
+		n, rest, err = readUvarint(rest)
+		if err != nil {
+			return err
+		}
+
+		if n > uint64(len(rest)) {
+			return wrapf(ErrCorrupt, "corrupt hashset: member length %d exceeds payload", n)
+		}
+
+		if _, err := decoded.Add(rest[:n:n]); err != nil {
+			return err
+		}
+		rest = rest[n:]
+	}
+
+	if len(rest) != 0 {
+		return wrapf(ErrCorrupt, "corrupt hashset: %d trailing bytes", len(rest))
+	}
+
+	if decoded.Size != int(size) {
+		return wrapf(ErrCorrupt, "corrupt hashset: size %d does not match %d members", size, decoded.Size)
+	}
+
+	decoded.generation = h.generation + 1
+	*h = *decoded
+	return nil
+}
+
+// decodedCapacity bounds the capacity declared by a payload holding size members.
+// A capacity far larger than the size requires is replaced so corrupt input cannot force a huge allocation.
+func decodedCapacity(capacity, size int) int {
+	required := max(capacityFor(size), initialCapacity)
+	if capacity > required*maxSparseFactor {
+		return required
+	}
+	return capacity
+}
+
+// readUvarint reads an unsigned varint from the start of buf, returning the remaining bytes.
+func readUvarint(buf []byte) (uint64, []byte, error) {
+	v, n := binary.Uvarint(buf)
+	if n <= 0 {
+		return 0, nil, wrapf(ErrCorrupt, "corrupt hashset: invalid varint")
+	}
+	return v, buf[n:], nil
+}
@@ -0,0 +1,286 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_MarshalUnmarshalBinary(t *testing.T) {
+	set := NewHashSetKeyed([]byte("tenant"))
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	set.Add([]byte{})
+
+	data, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var restored HashSet
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.Size != set.Size {
+		t.Errorf("Expected restored size to be %d, got %d", set.Size, restored.Size)
+	}
+
+	if restored.Seed != set.Seed || restored.Capacity != set.Capacity {
+		t.Errorf("Expected restored seed and capacity to match")
+	}
+
+	for i := 0; i < 1000; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		if !restored.Contains(value) {
+			t.Errorf("Expected restored set to contain %v", value)
+		}
+	}
+
+	if !restored.Contains([]byte{}) {
+		t.Errorf("Expected restored set to contain the empty value")
+	}
+}
+
+func TestHashSet_UnmarshalBinaryRecomputeCapacity(t *testing.T) {
+	// The same members held at different capacities load at the same one
+	sparse := NewHashSetWithCapacity(1 << 12)
+	dense := NewHashSet()
+	for i := 0; i < 100; i++ {
+		sparse.Add([]byte(fmt.Sprintf("test%d", i)))
+		dense.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	for _, set := range []*HashSet{sparse, dense} {
+		data, err := set.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		restored := mustHashSet(t, Options{RecomputeCapacity: true})
+		if err := restored.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if restored.Capacity != 256 {
+			t.Errorf("Expected the recomputed capacity to be 256, got %d", restored.Capacity)
+		}
+		for i := 0; i < 100; i++ {
+			if !restored.Contains([]byte(fmt.Sprintf("test%d", i))) {
+				t.Errorf("Expected restored set to contain test%d", i)
+			}
+		}
+	}
+}
+
+func TestDeserializeZeroCopy(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	data, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := bytes.Clone(data)
+
+	decoded, err := DeserializeZeroCopy(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if !decoded.Contains([]byte(fmt.Sprintf("test%d", i))) {
+			t.Errorf("Expected test%d to be found", i)
+		}
+	}
+	decoded.ForEach(func(value []byte) bool {
+		if !decoded.borrows(value) {
+			t.Errorf("Expected %s to reference the decoded buffer", value)
+		}
+		return true
+	})
+
+	// Mutations never write to the buffer
+	added := []byte("added")
+	decoded.Add(added)
+	decoded.Remove([]byte("test0"))
+	decoded.ClearSecure()
+	if !bytes.Equal(data, original) {
+		t.Errorf("Expected the decoded buffer to be left unchanged")
+	}
+	if !bytes.Equal(added, make([]byte, len(added))) {
+		t.Errorf("Expected ClearSecure to zero the members added after decoding")
+	}
+
+	// UnmarshalBinary copies, the buffer can be reused
+	copied := NewHashSet()
+	if err := copied.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	clear(data)
+	if !copied.Contains([]byte("test42")) {
+		t.Errorf("Expected UnmarshalBinary not to reference its input")
+	}
+
+	if _, err := DeserializeZeroCopy([]byte("corrupt")); err == nil {
+		t.Errorf("Expected an error for a corrupt payload")
+	}
+}
+
+func TestHashSet_SerializedSize(t *testing.T) {
+	set := NewHashSet()
+	for _, n := range []int{0, 1, 127, 128, 300, 20000} {
+		set.Add(make([]byte, n)) // Length prefixes of one to three bytes
+		for i := 0; i < 100; i++ {
+			set.Add([]byte(fmt.Sprintf("test%d-%d", n, i)))
+		}
+
+		data, err := set.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := set.SerializedSize(); got != len(data) {
+			t.Errorf("Expected serialized size %d, got %d", len(data), got)
+		}
+	}
+}
+
+func TestHashSet_UnmarshalBinaryCorrupt(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("test"))
+
+	data, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flip a byte in the members
+	corrupt := append([]byte(nil), data...)
+	corrupt[len(corrupt)-6] ^= 0xff
+
+	var restored HashSet
+	if err := restored.UnmarshalBinary(corrupt); err == nil {
+		t.Errorf("Expected checksum mismatch")
+	}
+
+	if err := restored.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Errorf("Expected error for truncated payload")
+	}
+
+	if err := restored.UnmarshalBinary(nil); err == nil {
+		t.Errorf("Expected error for empty payload")
+	}
+}
+
+func TestDeserializeCorrupt(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("test"))
+	set.Size = 5 // Inconsistent with the elements
+
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Deserialize(data); err == nil {
+		t.Errorf("Expected error for inconsistent size")
+	}
+
+	if _, err := Deserialize([]byte("not a hashset")); err == nil {
+		t.Errorf("Expected error for garbage input")
+	}
+}
+
+func FuzzUnmarshalBinary(f *testing.F) {
+	set := NewHashSet()
+	set.Add([]byte("test1"))
+	set.Add([]byte("test2"))
+	data, _ := set.MarshalBinary()
+
+	f.Add(data)
+	f.Add([]byte(binaryMagic))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var h HashSet
+		if err := h.UnmarshalBinary(data); err != nil {
+			return
+		}
+
+		if collapsed, err := h.Validate(); err != nil || collapsed != 0 {
+			t.Errorf("Decoded set is inconsistent: %v, %d duplicates", err, collapsed)
+		}
+	})
+}
+
+func FuzzDeserialize(f *testing.F) {
+	set := NewHashSet()
+	set.Add([]byte("test1"))
+	set.Add([]byte("test2"))
+	data, _ := set.Serialize()
+
+	f.Add(data)
+	f.Add([]byte{})
+
+	// Options sizing the structures the decoded set allocates
+	for _, opts := range []Options{
+		{BloomBits: 1 << 37},
+		{MaxDistinct: 1, ReservoirSample: 1 << 36},
+		{MissCache: 1 << 40},
+		{Capacity: 1 << 40},
+		{BucketHint: 1 << 30, SlabBuckets: true},
+		{BloomBits: 1024, MissCache: 16, InsertionOrder: true},
+	} {
+		set := mustHashSet(f, Options{BloomBits: 1024, MissCache: 16, MaxDistinct: 8, ReservoirSample: 4})
+		set.Add([]byte("test1"))
+		set.opts.BloomBits, set.opts.MissCache, set.opts.Capacity = opts.BloomBits, opts.MissCache, opts.Capacity
+		set.opts.BucketHint, set.opts.SlabBuckets, set.opts.InsertionOrder = opts.BucketHint, opts.SlabBuckets, opts.InsertionOrder
+		if opts.ReservoirSample > 0 {
+			set.opts.MaxDistinct, set.opts.ReservoirSample = opts.MaxDistinct, opts.ReservoirSample
+		}
+		data, err := set.Serialize()
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		h, err := Deserialize(data)
+		if err != nil {
+			return
+		}
+
+		h.Contains([]byte("test1"))
+	})
+}
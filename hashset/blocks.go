@@ -0,0 +1,176 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/guycipher/k4/pager"
+)
+
+// Block format
+//
+// The set is written as records of the K4 pager, the layout of SSTable files, so pager.GetPage reads them back.
+// Every page is a pager.HEADER_SIZE header holding the decimal ID of the next page of the record, or -1 on its
+// last page, NUL padded, followed by pager.PAGE_SIZE bytes of data, NUL padded. Records are:
+//
+//	page 0    header
+//	  magic     [4]byte "K4HB"
+//	  version   uint8
+//	  hasher    uint8   hasher of the build, which computes the fingerprints
+//	  threshold uint64  little endian FingerprintThreshold of the set, long values are stored by fingerprint
+//	  blocks    uint64  little endian number of member blocks
+//	  members   uint64  little endian number of members
+//	  index     uint64  little endian page of the block index
+//	  checksum  uint32  little endian crc32 (IEEE) of the header fields above
+//	pages 1.. member blocks, one record each, members sorted by bytes.Compare across blocks
+//	  count     uint32  little endian number of members in the block
+//	  members   count x (uvarint length, bytes)
+//	index     block index, one record
+//	  entries   blocks x (page uint64 little endian, uvarint length and bytes of the first member of the block)
+//
+// A block holds as many members as fit in blockSize bytes, a longer member is written alone in its own block.
+// A block longer than a page spans consecutive pages.
+const blocksMagic = "K4HB"
+const blocksVersion = 1
+
+// WriteBlocks writes the members of the set to w grouped into blocks of at most blockSize bytes,
+// with a block index, in the K4 SSTable page layout, see the block format.
+func (h *HashSet) WriteBlocks(w io.Writer, blockSize int) error {
+	if blockSize <= 4 {
+		return wrapf(ErrInvalidOption, "hashset: block size %d leaves no room for members", blockSize)
+	}
+
+	members := make([][]byte, 0, h.Size)
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			members = append(members, item.([]byte))
+		}
+	}
+	slices.SortFunc(members, bytes.Compare)
+
+	// Group the members into blocks
+	var blocks [][]byte
+	var firsts [][]byte
+	var block []byte
+	count := uint32(0)
+	for _, member := range members {
+		entry := binary.AppendUvarint(nil, uint64(len(member)))
+		entry = append(entry, member...)
+
+		if count > 0 && len(block)+len(entry) > blockSize {
+			binary.LittleEndian.PutUint32(block, count)
+			blocks = append(blocks, block)
+			block, count = nil, 0
+		}
+		if count == 0 {
+			block = make([]byte, 4, blockSize) // Room for the count
+			firsts = append(firsts, member)
+		}
+		block = append(block, entry...)
+		count++
+	}
+	if count > 0 {
+		binary.LittleEndian.PutUint32(block, count)
+		blocks = append(blocks, block)
+	}
+
+	// Blocks follow the header page, the index follows the blocks
+	pages := make([]int64, len(blocks))
+	next := int64(1)
+	for i, block := range blocks {
+		pages[i] = next
+		next += recordPages(block)
+	}
+
+	var index []byte
+	for i, first := range firsts {
+		index = binary.LittleEndian.AppendUint64(index, uint64(pages[i]))
+		index = binary.AppendUvarint(index, uint64(len(first)))
+		index = append(index, first...)
+	}
+
+	header := append([]byte(blocksMagic), blocksVersion, hasherID) // Fingerprints use the hasher of the build
+	header = binary.LittleEndian.AppendUint64(header, uint64(max(h.opts.FingerprintThreshold, 0)))
+	header = binary.LittleEndian.AppendUint64(header, uint64(len(blocks)))
+	header = binary.LittleEndian.AppendUint64(header, uint64(len(members)))
+	header = binary.LittleEndian.AppendUint64(header, uint64(next))
+	header = binary.LittleEndian.AppendUint32(header, crc32.ChecksumIEEE(header))
+
+	bw := bufio.NewWriter(w)
+	if err := writeRecord(bw, 0, header); err != nil {
+		return err
+	}
+	for i, block := range blocks {
+		if err := writeRecord(bw, pages[i], block); err != nil {
+			return err
+		}
+	}
+	if err := writeRecord(bw, next, index); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// recordPages returns the number of pager pages a record of data occupies, at least one.
+func recordPages(data []byte) int64 {
+	return max(int64(len(data)+pager.PAGE_SIZE-1)/pager.PAGE_SIZE, 1)
+}
+
+// writeRecord writes data as a pager record starting at page id, chaining every page to the following one.
+func writeRecord(w io.Writer, id int64, data []byte) error {
+	n := recordPages(data)
+	for i := int64(0); i < n; i++ {
+		header := make([]byte, pager.HEADER_SIZE)
+		if i == n-1 {
+			copy(header, "-1") // Last page of the record
+		} else {
+			copy(header, strconv.FormatInt(id+i+1, 10))
+		}
+
+		page := make([]byte, pager.PAGE_SIZE)
+		copy(page, data[min(int(i)*pager.PAGE_SIZE, len(data)):])
+
+		if _, err := w.Write(header); err != nil {
+			return err
+		}
+		if _, err := w.Write(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/guycipher/k4/pager"
+)
+
+// readBlocks reads the members of a file in the block format through the K4 pager.
+func readBlocks(t *testing.T, path string) (members [][]byte, blocks int) {
+	p, err := pager.OpenPager(path, os.O_RDONLY, 0644)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer p.Close()
+
+	header, err := p.GetPage(0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(header[:4]) != blocksMagic || header[4] != blocksVersion {
+		t.Fatalf("Expected a block format header, got %q", header[:5])
+	}
+	if binary.LittleEndian.Uint32(header[38:]) != crc32.ChecksumIEEE(header[:38]) {
+		t.Fatalf("Expected the header checksum to match")
+	}
+	blocks = int(binary.LittleEndian.Uint64(header[14:]))
+	count := int(binary.LittleEndian.Uint64(header[22:]))
+
+	index, err := p.GetPage(int64(binary.LittleEndian.Uint64(header[30:])))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for b := 0; b < blocks; b++ {
+		page := int64(binary.LittleEndian.Uint64(index))
+		n, k := binary.Uvarint(index[8:])
+		first := index[8+k : 8+k+int(n)]
+		index = index[8+k+int(n):]
+
+		block, err := p.GetPage(page)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		inBlock := int(binary.LittleEndian.Uint32(block))
+		block = block[4:]
+		for i := 0; i < inBlock; i++ {
+			n, k := binary.Uvarint(block)
+			member := block[k : k+int(n)]
+			if i == 0 && !bytes.Equal(member, first) {
+				t.Errorf("Expected the index to record %q first in block %d, got %q", member, b, first)
+			}
+			members = append(members, member)
+			block = block[k+int(n):]
+		}
+	}
+
+	if len(members) != count {
+		t.Errorf("Expected %d members from the header, read %d", count, len(members))
+	}
+	return members, blocks
+}
+
+func TestHashSet_WriteBlocks(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("member%04d", i)))
+	}
+	set.Add(bytes.Repeat([]byte("z"), 3*pager.PAGE_SIZE)) // Spans pages
+
+	path := filepath.Join(t.TempDir(), "set.sst")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := set.WriteBlocks(f, 512); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	f.Close()
+
+	members, blocks := readBlocks(t, path)
+	if blocks < 20 {
+		t.Errorf("Expected the members to be spread over many 512 byte blocks, got %d", blocks)
+	}
+
+	want := set.ToSortedSlice()
+	if len(members) != len(want) {
+		t.Fatalf("Expected %d members, got %d", len(want), len(members))
+	}
+	for i := range want {
+		if !bytes.Equal(members[i], want[i]) {
+			t.Errorf("Expected member %d to be %.20q, got %.20q", i, want[i], members[i])
+		}
+	}
+	if !slices.IsSortedFunc(members, bytes.Compare) {
+		t.Errorf("Expected the members to be sorted across blocks")
+	}
+}
+
+func TestHashSet_WriteBlocksEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.sst")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := NewHashSet().WriteBlocks(f, 4096); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	f.Close()
+
+	if members, blocks := readBlocks(t, path); len(members) != 0 || blocks != 0 {
+		t.Errorf("Expected no blocks, got %d with %d members", blocks, len(members))
+	}
+	if err := NewHashSet().WriteBlocks(&bytes.Buffer{}, 4); err == nil {
+		t.Errorf("Expected an error for a block size without room for members")
+	}
+}
@@ -0,0 +1,118 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import "slices"
+
+const bloomProbes = 3 // bits set per element
+
+// summaryBloom is a small bloom filter over the digests of the elements.
+// A nil summaryBloom reports every value as possibly present.
+type summaryBloom struct {
+	bits []uint64 // Bitfield
+}
+
+// newSummaryBloom creates a summaryBloom of at least size bits, or nil if size is not positive.
+func newSummaryBloom(size int) *summaryBloom {
+	if size <= 0 {
+		return nil
+	}
+	return &summaryBloom{
+		bits: make([]uint64, (size+63)/64),
+	}
+}
+
+// clone returns an independent copy of the filter.
+func (b *summaryBloom) clone() *summaryBloom {
+	if b == nil {
+		return nil
+	}
+	return &summaryBloom{bits: slices.Clone(b.bits)}
+}
+
+// empty returns a cleared filter of the same size, or nil for a nil filter.
+func (b *summaryBloom) empty() *summaryBloom {
+	if b == nil {
+		return nil
+	}
+	return &summaryBloom{bits: make([]uint64, len(b.bits))}
+}
+
+// positions derives the probed bit positions from a digest.
+// The digest is remixed first so the positions are independent of the bucket index taken from it.
+func (b *summaryBloom) positions(digest uint64) [bloomProbes]uint64 {
+	// splitmix64 finalizer
+	digest ^= digest >> 30
+	digest *= 0xbf58476d1ce4e5b9
+	digest ^= digest >> 27
+	digest *= 0x94d049bb133111eb
+	digest ^= digest >> 31
+
+	m := uint64(len(b.bits)) * 64
+	h1, h2 := digest&0xffffffff, digest>>32|1
+
+	var pos [bloomProbes]uint64
+	for i := range pos {
+		pos[i] = (h1 + uint64(i)*h2) % m
+	}
+	return pos
+}
+
+// add sets the bits of a digest.
+func (b *summaryBloom) add(digest uint64) {
+	if b == nil {
+		return
+	}
+	for _, p := range b.positions(digest) {
+		b.bits[p/64] |= 1 << (p % 64)
+	}
+}
+
+// mayContain returns false if the digest was definitely never added.
+func (b *summaryBloom) mayContain(digest uint64) bool {
+	if b == nil {
+		return true
+	}
+	for _, p := range b.positions(digest) {
+		if b.bits[p/64]&(1<<(p%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// reset clears every bit.
+func (b *summaryBloom) reset() {
+	if b == nil {
+		return
+	}
+	clear(b.bits)
+}
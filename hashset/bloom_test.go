@@ -0,0 +1,92 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_BloomBits(t *testing.T) {
+	set := mustHashSet(t, Options{BloomBits: 1 << 16})
+
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	for i := 0; i < 1000; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		if !set.Contains(value) {
+			t.Errorf("Expected set to contain %v", value)
+		}
+	}
+
+	// Most misses must be ruled out by the bloom alone
+	ruledOut := 0
+	for i := 0; i < 1000; i++ {
+		if !set.bloom.mayContain(set.Digest([]byte(fmt.Sprintf("miss%d", i)))) {
+			ruledOut++
+		}
+		if set.Contains([]byte(fmt.Sprintf("miss%d", i))) {
+			t.Errorf("Expected set to not contain miss%d", i)
+		}
+	}
+
+	if ruledOut < 950 {
+		t.Errorf("Expected the bloom to rule out most misses, got %d of 1000", ruledOut)
+	}
+
+	set.Remove([]byte("test0"))
+	if set.Contains([]byte("test0")) {
+		t.Errorf("Expected set to not contain test0 after remove")
+	}
+
+	set.Clear()
+	if set.Contains([]byte("test1")) {
+		t.Errorf("Expected set to not contain test1 after clear")
+	}
+}
+
+func TestHashSet_BloomBitsRebuiltOnResize(t *testing.T) {
+	set := mustHashSet(t, Options{BloomBits: 64})
+	set.Add([]byte("removed"))
+	set.Remove([]byte("removed"))
+
+	digest := set.Digest([]byte("removed"))
+	if !set.bloom.mayContain(digest) {
+		t.Fatalf("Expected the bits of a removed element to stay set before a resize")
+	}
+
+	set.resize()
+	if set.bloom.mayContain(digest) {
+		t.Errorf("Expected the resize to rebuild the bloom without removed elements")
+	}
+}
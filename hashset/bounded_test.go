@@ -0,0 +1,123 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_BucketLimit(t *testing.T) {
+	evicted := make([][]byte, 0)
+	set := mustHashSet(t, Options{
+		BucketLimit: 2,
+		OnEvict: func(value []byte) {
+			evicted = append(evicted, value)
+		},
+	})
+
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	if set.Capacity != initialCapacity {
+		t.Errorf("Expected the set to never resize, got capacity %d", set.Capacity)
+	}
+
+	for index, bucket := range set.Buckets {
+		if len(bucket) > 2 {
+			t.Errorf("Expected bucket %d to hold at most 2 elements, got %d", index, len(bucket))
+		}
+	}
+
+	if set.Size+len(evicted) != 1000 {
+		t.Errorf("Expected %d kept and %d evicted elements to add up to 1000", set.Size, len(evicted))
+	}
+
+	for _, value := range evicted {
+		if set.Contains(value) {
+			t.Errorf("Expected evicted %s to be gone", value)
+		}
+	}
+
+	// The newest element is always kept
+	if !set.Contains([]byte("test999")) {
+		t.Errorf("Expected the newest element to be kept")
+	}
+}
+
+func TestHashSet_BucketLimitEvictsOldest(t *testing.T) {
+	set := mustHashSet(t, Options{BucketLimit: 2})
+
+	// Find three values sharing a bucket
+	values := make([][]byte, 0, 3)
+	for i := 0; len(values) < 3; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		if set.hash(value, set.Capacity) == 0 {
+			values = append(values, value)
+		}
+	}
+
+	for _, value := range values {
+		set.Add(value)
+	}
+
+	if set.Contains(values[0]) || !set.Contains(values[1]) || !set.Contains(values[2]) {
+		t.Errorf("Expected only the oldest element of the bucket to be evicted")
+	}
+}
+
+func TestHashSet_BucketLimitBatch(t *testing.T) {
+	values := make([][]byte, 5000)
+	for i := range values {
+		values[i] = []byte(fmt.Sprintf("test%05d", i)) // Sorted for AddSorted
+	}
+
+	inserts := map[string]func(set *HashSet){
+		"AddAll":         func(set *HashSet) { set.AddAll(values) },
+		"AddAllParallel": func(set *HashSet) { set.AddAllParallel(values, 4) },
+		"AddSorted":      func(set *HashSet) { set.AddSorted(values) },
+		"Reserve":        func(set *HashSet) { set.Reserve(len(values)) },
+	}
+	for name, insert := range inserts {
+		set := mustHashSet(t, Options{BucketLimit: 2})
+		insert(set)
+
+		if set.Capacity != initialCapacity {
+			t.Errorf("Expected %s to never resize, got capacity %d", name, set.Capacity)
+		}
+		for index, bucket := range set.Buckets {
+			if len(bucket) > 2 {
+				t.Errorf("Expected bucket %d to hold at most 2 elements after %s, got %d", index, name, len(bucket))
+			}
+		}
+	}
+}
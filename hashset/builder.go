@@ -0,0 +1,103 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"slices"
+)
+
+// Builder collects values and builds a perfectly-sized, frozen HashSet.
+type Builder struct {
+	values [][]byte // Collected values, may contain duplicates
+}
+
+// NewBuilder creates a new instance of Builder.
+func NewBuilder() *Builder {
+	return &Builder{
+		values: make([][]byte, 0),
+	}
+}
+
+// Add collects a value to be inserted on Build.
+func (b *Builder) Add(value []byte) {
+	b.values = append(b.values, value)
+}
+
+// Build creates a frozen HashSet holding all collected values.
+// The capacity is the smallest power of two that keeps the set under the load factor threshold,
+// the buckets are allocated once and no resize takes place.
+func (b *Builder) Build() *HashSet {
+	// Sort a copy of the values so duplicates are adjacent and can be dropped
+	values := slices.Clone(b.values)
+	slices.SortFunc(values, bytes.Compare)
+	values = slices.CompactFunc(values, bytes.Equal)
+
+	capacity := capacityFor(len(values)) // Compute the exact capacity
+
+	h := newHashSet(capacity, defaultSeed)
+
+	// Values are distinct so we can append without checking for existence
+	for _, value := range values {
+		index := h.hash(value, h.Capacity)
+		h.Buckets[index] = append(h.Buckets[index], value)
+	}
+
+	h.Size = len(values)
+	h.recountMemory()
+	h.Freeze() // The built set is read-only
+
+	return h
+}
+
+// BuildExact builds a frozen HashSet of the distinct values in one call, as a Builder collecting them would.
+// The capacity is exact for the distinct count under the load factor threshold, so nothing is resized,
+// and duplicates are dropped after sorting rather than by a lookup per value. values is not modified.
+func BuildExact(values [][]byte) *HashSet {
+	b := Builder{values: values}
+	return b.Build()
+}
+
+// NewHashSetFixed creates a set of capacity buckets, rounded up to a power of two, holding the distinct values,
+// for benchmarks measuring lookups at a chosen load factor. It intentionally ignores the load factor threshold:
+// values are inserted without any resize however long the chains grow, later Adds resize as usual. A capacity
+// below 1 is raised to 1.
+func NewHashSetFixed(capacity int, values [][]byte) *HashSet {
+	h := newHashSet(min(max(capacity, 1), maxCapacity), defaultSeed)
+	for _, value := range values {
+		if index, i := h.locate(h.digest(value), value); i < 0 {
+			h.insert(index, value)
+			h.Size++
+		}
+	}
+	h.recountMemory()
+	return h
+}
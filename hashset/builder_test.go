@@ -0,0 +1,133 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	b := NewBuilder()
+
+	for i := 0; i < 1000; i++ {
+		b.Add([]byte(fmt.Sprintf("test%d", i)))
+		b.Add([]byte(fmt.Sprintf("test%d", i))) // duplicate
+	}
+
+	set := b.Build()
+
+	if set.Size != 1000 {
+		t.Errorf("Expected size to be 1000, got %d", set.Size)
+	}
+
+	if set.Capacity != 2048 {
+		t.Errorf("Expected capacity to be 2048, got %d", set.Capacity)
+	}
+
+	for i := 0; i < 1000; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		if !set.Contains(value) {
+			t.Errorf("Expected set to contain %v", value)
+		}
+	}
+
+	if !set.Frozen() {
+		t.Errorf("Expected built set to be frozen")
+	}
+}
+
+func TestBuilder_BuildEmpty(t *testing.T) {
+	set := NewBuilder().Build()
+
+	if set.Size != 0 {
+		t.Errorf("Expected size to be 0, got %d", set.Size)
+	}
+
+	if set.Contains([]byte("test")) {
+		t.Errorf("Expected set to not contain test")
+	}
+}
+
+func TestBuilder_BuildFrozen(t *testing.T) {
+	b := NewBuilder()
+	b.Add([]byte("test"))
+	set := b.Build()
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected Add on frozen set to panic")
+		}
+	}()
+
+	set.Add([]byte("test2"))
+}
+
+func TestBuildExact(t *testing.T) {
+	values := [][]byte{[]byte("c"), []byte("a"), []byte("b"), []byte("a"), []byte("c")}
+	set := BuildExact(values)
+
+	if set.Size != 3 || set.Capacity != capacityFor(3) || !set.Frozen() {
+		t.Errorf("Expected a frozen set of 3 elements in %d buckets, got %d in %d", capacityFor(3), set.Size, set.Capacity)
+	}
+	for _, value := range values {
+		if !set.Contains(value) {
+			t.Errorf("Expected set to contain %s", value)
+		}
+	}
+	if string(values[0]) != "c" || string(values[1]) != "a" {
+		t.Errorf("Expected the input to be left unsorted")
+	}
+}
+
+func TestNewHashSetFixed(t *testing.T) {
+	values := make([][]byte, 0, 200)
+	for i := 0; i < 100; i++ {
+		values = append(values, []byte(fmt.Sprintf("test%d", i)), []byte(fmt.Sprintf("test%d", i)))
+	}
+
+	set := NewHashSetFixed(16, values) // A load factor of 6.25
+	if set.Capacity != 16 || len(set.Buckets) != 16 || set.Size != 100 {
+		t.Errorf("Expected 100 elements in 16 buckets, got %d in %d", set.Size, set.Capacity)
+	}
+	for _, value := range values {
+		if !set.Contains(value) {
+			t.Errorf("Expected %s to be found", value)
+		}
+	}
+	if err := set.SelfTest(); err != nil {
+		t.Errorf("Expected a consistent set, got %v", err)
+	}
+
+	if set := NewHashSetFixed(0, nil); set.Capacity != 1 {
+		t.Errorf("Expected a capacity of 1, got %d", set.Capacity)
+	}
+}
@@ -0,0 +1,108 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+const chainTagThreshold = secondaryThreshold // chain length at which a bucket keeps the tags of its elements
+
+// chainTag returns the tag of the element with the given digest, the upper half of the digest.
+// The elements of a bucket share the lower bits of their digests, which place them, but rarely the upper half.
+func chainTag(digest uint64) uint32 {
+	return uint32(digest >> 32)
+}
+
+// chainTagInsert tags the element just appended to the bucket at index.
+func (h *HashSet) chainTagInsert(index int, value []byte) {
+	if !h.opts.ChainFingerprints {
+		return
+	}
+
+	tags, ok := h.tags[index]
+	if !ok {
+		if len(h.Buckets[index]) > chainTagThreshold {
+			h.chainTagIndex(index) // The chain just became long
+		}
+		return
+	}
+	h.tags[index] = append(tags, chainTag(h.digest(value)))
+}
+
+// chainTagRemove drops the tag of the element just removed from position i of the bucket at index.
+func (h *HashSet) chainTagRemove(index, i int) {
+	tags, ok := h.tags[index]
+	if !ok {
+		return
+	}
+
+	if len(h.Buckets[index]) <= chainTagThreshold {
+		delete(h.tags, index) // The chain is short again
+		return
+	}
+	h.tags[index] = append(tags[:i], tags[i+1:]...)
+}
+
+// chainTagReindex tags the bucket at index again after several of its elements were removed.
+func (h *HashSet) chainTagReindex(index int) {
+	if _, ok := h.tags[index]; !ok {
+		return
+	}
+
+	delete(h.tags, index)
+	if len(h.Buckets[index]) > chainTagThreshold {
+		h.chainTagIndex(index)
+	}
+}
+
+// chainTagIndex computes the tags of every element of the bucket at index.
+func (h *HashSet) chainTagIndex(index int) {
+	if h.tags == nil {
+		h.tags = make(map[int][]uint32)
+	}
+
+	tags := make([]uint32, len(h.Buckets[index]))
+	for i, item := range h.Buckets[index] {
+		tags[i] = chainTag(h.digest(item.([]byte)))
+	}
+	h.tags[index] = tags
+}
+
+// chainTagRebuild recomputes the tags of every long bucket.
+func (h *HashSet) chainTagRebuild() {
+	h.tags = nil
+	if !h.opts.ChainFingerprints {
+		return
+	}
+
+	for index, bucket := range h.Buckets {
+		if len(bucket) > chainTagThreshold {
+			h.chainTagIndex(index)
+		}
+	}
+}
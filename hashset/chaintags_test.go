@@ -0,0 +1,99 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"testing"
+)
+
+// checkChainTags fails the test unless every long bucket has the tags of its elements.
+func checkChainTags(t *testing.T, set *HashSet) {
+	t.Helper()
+	for index, bucket := range set.Buckets {
+		tags, ok := set.tags[index]
+		if ok != (len(bucket) > chainTagThreshold) {
+			t.Fatalf("Expected bucket %d of %d elements to be tagged only when long, got %v", index, len(bucket), ok)
+		}
+		for i, item := range bucket {
+			if ok && tags[i] != chainTag(set.digest(item.([]byte))) {
+				t.Fatalf("Expected tag %d of bucket %d to match its element", i, index)
+			}
+		}
+	}
+}
+
+func TestHashSet_ChainFingerprints(t *testing.T) {
+	set := mustHashSet(t, Options{ChainFingerprints: true, BucketLimit: 64}) // Bounded, so chains grow long
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	checkChainTags(t, set)
+	if len(set.tags) == 0 {
+		t.Fatalf("Expected long chains to be tagged")
+	}
+
+	for i := 0; i < 1000; i += 3 {
+		set.Remove([]byte(fmt.Sprintf("test%d", i)))
+	}
+	set.IterRemove(func(value []byte) bool { return len(value) == 6 }) // test10 to test99
+	checkChainTags(t, set)
+
+	want := NewHashSetWithCapacity(32)
+	set.ForEach(func(value []byte) bool {
+		want.Add(value)
+		return true
+	})
+	for i := 0; i < 1000; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		if set.Contains(value) != want.Contains(value) {
+			t.Errorf("Expected Contains(%s) to be %v", value, want.Contains(value))
+		}
+	}
+	if found, exhausted := set.ContainsWithin([]byte("missing"), 1); found || exhausted {
+		t.Errorf("Expected a missing value to be ruled out by its tag, got %v, %v", found, exhausted)
+	}
+
+	// Tags are recomputed by clones and decoded sets
+	checkChainTags(t, set.Clone())
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkChainTags(t, decoded)
+	if !decoded.opts.ChainFingerprints || !decoded.Contains([]byte("test1")) {
+		t.Errorf("Expected the decoded set to keep the option and the elements")
+	}
+}
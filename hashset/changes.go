@@ -0,0 +1,143 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+)
+
+// memberChanges records the generation at which each element was added or removed, for the TrackChanges option.
+// A nil memberChanges records nothing.
+type memberChanges struct {
+	added   map[string]uint64 // Generation each element was added at
+	removed map[string]uint64 // Generation each removed element was removed at, until it is added again
+}
+
+// newMemberChanges creates an empty memberChanges.
+func newMemberChanges() *memberChanges {
+	return &memberChanges{
+		added:   make(map[string]uint64),
+		removed: make(map[string]uint64),
+	}
+}
+
+// clone returns an independent copy of the changes.
+func (c *memberChanges) clone() *memberChanges {
+	if c == nil {
+		return nil
+	}
+	return &memberChanges{added: maps.Clone(c.added), removed: maps.Clone(c.removed)}
+}
+
+// add records value as added at generation.
+func (c *memberChanges) add(value []byte, generation uint64) {
+	if c == nil {
+		return
+	}
+	c.added[string(value)] = generation
+	delete(c.removed, string(value))
+}
+
+// remove records value as removed at generation.
+func (c *memberChanges) remove(value []byte, generation uint64) {
+	if c == nil {
+		return
+	}
+	delete(c.added, string(value))
+	c.removed[string(value)] = generation
+}
+
+// removeAll records every element as removed at generation, for Clear.
+func (c *memberChanges) removeAll(generation uint64) {
+	if c == nil {
+		return
+	}
+	for key := range c.added {
+		c.removed[key] = generation
+	}
+	clear(c.added)
+}
+
+// clear drops every change.
+func (c *memberChanges) clear() {
+	if c == nil {
+		return
+	}
+	clear(c.added)
+	clear(c.removed)
+}
+
+// since returns the keys of changes made after generation, ordered by bytes.Compare.
+func since(changes map[string]uint64, generation uint64) [][]byte {
+	var keys [][]byte
+	for key, changed := range changes {
+		if changed > generation {
+			keys = append(keys, []byte(key))
+		}
+	}
+	slices.SortFunc(keys, bytes.Compare)
+	return keys
+}
+
+// MembersSince returns the members added after generation, a reading of Generation, ordered by bytes.Compare.
+// With RemovedSince it lists the changes to push to a replica that was in sync at generation: removing the
+// removed keys and adding the members brings it up to date. Members of a decoded set carry generation 0, the
+// payload itself is the starting point of a replica. It returns nil if the set was not created with the
+// TrackChanges option.
+func (h *HashSet) MembersSince(generation uint64) [][]byte {
+	if h.changes == nil {
+		return nil
+	}
+	return since(h.changes.added, generation)
+}
+
+// RemovedSince returns the keys removed after generation that were not added again, ordered by bytes.Compare.
+// Clear counts as removing every member. Keys are in stored form, see the FingerprintThreshold option.
+// It returns nil if the set was not created with the TrackChanges option.
+func (h *HashSet) RemovedSince(generation uint64) [][]byte {
+	if h.changes == nil {
+		return nil
+	}
+	return since(h.changes.removed, generation)
+}
+
+// PurgeChanges drops the removals made at or before generation, once every replica has caught up with it.
+func (h *HashSet) PurgeChanges(generation uint64) {
+	h.checkMutable()
+	if h.changes == nil {
+		return
+	}
+	maps.DeleteFunc(h.changes.removed, func(_ string, removed uint64) bool {
+		return removed <= generation
+	})
+}
@@ -0,0 +1,87 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHashSet_MembersSince(t *testing.T) {
+	set := mustHashSet(t, Options{TrackChanges: true})
+	set.Add([]byte("a"))
+	set.Add([]byte("b"))
+	synced := set.Generation()
+
+	set.Add([]byte("c"))
+	set.Remove([]byte("a"))
+	set.Add([]byte("d"))
+	set.Remove([]byte("d"))
+	set.Add([]byte("b")) // Already present, not a change
+
+	if got := set.MembersSince(synced); !reflect.DeepEqual(got, [][]byte{[]byte("c")}) {
+		t.Errorf("Expected [c] added since the sync, got %q", got)
+	}
+	if got := set.RemovedSince(synced); !reflect.DeepEqual(got, [][]byte{[]byte("a"), []byte("d")}) {
+		t.Errorf("Expected [a d] removed since the sync, got %q", got)
+	}
+	if got := set.MembersSince(0); len(got) != 2 {
+		t.Errorf("Expected every member to be added since generation 0, got %q", got)
+	}
+
+	// Adding a removed key again drops its removal
+	set.Add([]byte("a"))
+	if got := set.RemovedSince(synced); !reflect.DeepEqual(got, [][]byte{[]byte("d")}) {
+		t.Errorf("Expected only [d] removed once a is back, got %q", got)
+	}
+	if got := set.MembersSince(set.Generation()); got != nil {
+		t.Errorf("Expected nothing changed since the current generation, got %q", got)
+	}
+
+	set.PurgeChanges(set.Generation())
+	if got := set.RemovedSince(0); got != nil {
+		t.Errorf("Expected the purged removals to be dropped, got %q", got)
+	}
+
+	cleared := set.Generation()
+	set.IterRemove(func(value []byte) bool { return string(value) == "b" })
+	set.Clear()
+	if got := set.RemovedSince(cleared); !reflect.DeepEqual(got, [][]byte{[]byte("a"), []byte("b"), []byte("c")}) {
+		t.Errorf("Expected IterRemove and Clear to remove [a b c], got %q", got)
+	}
+	if got := set.MembersSince(0); got != nil {
+		t.Errorf("Expected no members after Clear, got %q", got)
+	}
+
+	if got := NewHashSet().MembersSince(0); got != nil {
+		t.Errorf("Expected nil without the TrackChanges option, got %q", got)
+	}
+}
@@ -0,0 +1,191 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// Checked format
+//
+//	magic    [4]byte "K4HC"
+//	version  uint8
+//	hasher   uint8
+//	seed     uint64  little endian
+//	capacity uvarint
+//	checksum uint32  little endian crc32 (IEEE) of the header fields above
+//	buckets  capacity x (uvarint body length, body, uint32 little endian crc32 of the body)
+//
+// A bucket body is a uvarint member count followed by count x (uvarint length, bytes).
+const checkedMagic = "K4HC"
+const checkedVersion = 1
+
+// SerializeChecked writes the set to w with a checksum per bucket.
+// DeserializeChecked can then recover the intact buckets of a partially corrupted payload.
+func (h *HashSet) SerializeChecked(w io.Writer) error {
+	if err := h.checkIDOnlyHasher("checked"); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+
+	header := append([]byte(checkedMagic), checkedVersion, h.Hasher)
+	header = binary.LittleEndian.AppendUint64(header, h.Seed)
+	header = binary.AppendUvarint(header, uint64(h.Capacity))
+	header = binary.LittleEndian.AppendUint32(header, crc32.ChecksumIEEE(header))
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+
+	var body []byte
+	for _, bucket := range h.chains() { // Members are checked against the bucket their hash places them in
+		body = binary.AppendUvarint(body[:0], uint64(len(bucket)))
+		for _, item := range bucket {
+			value := item.([]byte)
+			body = binary.AppendUvarint(body, uint64(len(value)))
+			body = append(body, value...)
+		}
+
+		if err := writeBytes(bw, body); err != nil {
+			return err
+		}
+		if _, err := bw.Write(binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(body))); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// DeserializeChecked decodes a set written by SerializeChecked, skipping the buckets that fail verification.
+// It returns the set of the intact buckets and the indices of the failed ones.
+// A corrupt bucket length or a truncated payload hides the buckets after it,
+// in that case the set recovered so far is returned together with the error.
+func DeserializeChecked(r io.Reader) (*HashSet, []int, error) {
+	br := newChecksumReader(r)
+
+	header := make([]byte, len(checkedMagic)+2+8)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, nil, err
+	}
+
+	if !bytes.Equal(header[:len(checkedMagic)], []byte(checkedMagic)) {
+		return nil, nil, wrapf(ErrCorrupt, "corrupt hashset: invalid magic")
+	}
+
+	if version := header[len(checkedMagic)]; version != checkedVersion {
+		return nil, nil, wrapf(ErrUnsupportedVersion, "unsupported checked hashset version %d", version)
+	}
+
+	capacity, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := br.verify(); err != nil {
+		return nil, nil, err
+	}
+
+	id := header[len(checkedMagic)+1]
+	hasher, err := lookupHasher(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if capacity == 0 || capacity > maxCapacity || capacity&(capacity-1) != 0 {
+		return nil, nil, wrapf(ErrCorrupt, "corrupt hashset: invalid capacity %d", capacity)
+	}
+
+	seed := binary.LittleEndian.Uint64(header[len(checkedMagic)+2:])
+	h := newHashSet(initialCapacity, seed) // Grown as members are recovered
+	h.Hasher, h.hasher = id, hasher
+
+	failed := make([]int, 0)
+	for i := 0; i < int(capacity); i++ {
+		body, err := readBytes(br)
+		if err != nil {
+			return h, append(failed, i), wrapf(ErrCorrupt, "corrupt hashset: bucket %d: %w", i, err)
+		}
+
+		var stored [4]byte
+		if _, err := io.ReadFull(br, stored[:]); err != nil {
+			return h, append(failed, i), wrapf(ErrCorrupt, "corrupt hashset: bucket %d: %w", i, err)
+		}
+
+		if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(stored[:]) {
+			failed = append(failed, i)
+			continue
+		}
+
+		members, ok := checkedMembers(body, hasher, seed, int(capacity), i)
+		if !ok {
+			failed = append(failed, i)
+			continue
+		}
+
+		for _, value := range members {
+			h.Add(value)
+		}
+	}
+
+	return h, failed, nil
+}
+
+// checkedMembers parses a bucket body, checking every member belongs to the bucket at index.
+func checkedMembers(body []byte, hasher Hasher, seed uint64, capacity, index int) ([][]byte, bool) {
+	count, rest, err := readUvarint(body)
+	if err != nil || count > uint64(len(rest)) {
+		return nil, false
+	}
+
+	members := make([][]byte, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var n uint64
+		n, rest, err = readUvarint(rest)
+		if err != nil || n > uint64(len(rest)) {
+			return nil, false
+		}
+
+		value := rest[:n:n]
+		if digestIndex(hasher.Hash64(value, seed), capacity) != index {
+			return nil, false // Misplaced member
+		}
+
+		members = append(members, value)
+		rest = rest[n:]
+	}
+
+	return members, len(rest) == 0
+}
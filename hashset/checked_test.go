@@ -0,0 +1,124 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_SerializeChecked(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	var buf bytes.Buffer
+	if err := set.SerializeChecked(&buf); err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+
+	decoded, failed, err := DeserializeChecked(&buf)
+	if err != nil {
+		t.Fatalf("Failed to deserialize: %v", err)
+	}
+
+	if len(failed) != 0 {
+		t.Errorf("Expected no failed buckets, got %v", failed)
+	}
+
+	if decoded.Fingerprint() != set.Fingerprint() {
+		t.Errorf("Expected decoded set to have the same contents")
+	}
+}
+
+func TestHashSet_DeserializeCheckedRecovers(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	var buf bytes.Buffer
+	if err := set.SerializeChecked(&buf); err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+
+	// Flip a byte inside the member bytes of some bucket, keeping the framing intact
+	data := buf.Bytes()
+	at := bytes.Index(data, []byte("test500"))
+	data[at+4] ^= 0xff
+
+	decoded, failed, err := DeserializeChecked(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to deserialize: %v", err)
+	}
+
+	if len(failed) != 1 || failed[0] != set.hash([]byte("test500"), set.Capacity) {
+		t.Fatalf("Expected the bucket of test500 to fail, got %v", failed)
+	}
+
+	// Every member of the intact buckets is recovered
+	lost := len(set.Buckets[failed[0]])
+	if decoded.Size != set.Size-lost {
+		t.Errorf("Expected %d recovered members, got %d", set.Size-lost, decoded.Size)
+	}
+
+	if decoded.Contains([]byte("test500")) {
+		t.Errorf("Expected the member of the failed bucket to be dropped")
+	}
+}
+
+func TestHashSet_DeserializeCheckedTruncated(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	var buf bytes.Buffer
+	if err := set.SerializeChecked(&buf); err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+
+	data := buf.Bytes()
+	decoded, failed, err := DeserializeChecked(bytes.NewReader(data[:len(data)/2]))
+	if err == nil {
+		t.Fatalf("Expected a truncated payload to return an error")
+	}
+
+	if decoded == nil || decoded.Size == 0 || len(failed) != 1 {
+		t.Errorf("Expected the buckets before the truncation to be recovered")
+	}
+
+	if _, _, err := DeserializeChecked(bytes.NewReader(data[:8])); err == nil {
+		t.Errorf("Expected a truncated header to return an error")
+	}
+}
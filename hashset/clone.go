@@ -0,0 +1,121 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"slices"
+	"unsafe"
+)
+
+// Clone returns a copy of the set that shares the buckets with it until they diverge. Copying costs
+// O(capacity) for the bucket array, the state kept per element by the options is copied in full.
+// The first mutation of a bucket on either side copies that bucket alone, so cheap snapshots can be taken
+// frequently and only pay for the buckets that change. The element bytes are shared like Add shares the
+// slices passed to it, so ClearSecure on one set zeroes the elements of its clones too.
+// Clone marks the buckets of the set as shared and must not run concurrently with its mutations.
+func (h *HashSet) Clone() *HashSet {
+	c := *h
+	c.Buckets = slices.Clone(h.Buckets)
+	c.frozen = false // A snapshot of a frozen set can diverge
+	c.order = h.order.clone()
+	c.bloom = h.bloom.clone()
+	c.access = h.access.clone()
+	c.counts = h.counts.clone()
+	c.times = h.times.clone()
+	c.reservoir = h.reservoir.clone()
+	c.misses = newMissCache(h.opts.MissCache) // Misses are only a cache, the clone starts cold
+	c.deleted = h.deleted.clone()
+	c.seqs = h.seqs.clone()
+	c.changes = h.changes.clone()
+	c.lengths = h.lengths.clone()
+	c.originals = h.originals.clone()
+	c.rate = h.rate.clone()
+	c.adaptive = h.adaptive.clone()
+	if h.largest != nil {
+		largest := *h.largest
+		c.largest = &largest
+	}
+	if h.latency != nil {
+		latency := *h.latency
+		c.latency = &latency
+	}
+	if h.probes != nil {
+		probes := *h.probes
+		c.probes = &probes
+	}
+
+	h.shared = make([]bool, h.Capacity)
+	c.shared = make([]bool, c.Capacity)
+	for i, bucket := range h.Buckets {
+		h.shared[i] = cap(bucket) > 0 // An unallocated bucket has no backing array to share
+		c.shared[i] = h.shared[i]
+	}
+
+	c.secondaryRebuild()
+	c.chainTagRebuild()
+	return &c
+}
+
+// own copies the bucket at index before it is modified if it is shared with a clone, see Clone.
+func (h *HashSet) own(index int) {
+	if !h.isShared(index) {
+		return
+	}
+	if h.opts.Allocator != nil {
+		h.Buckets[index] = append(h.allocBucket(len(h.Buckets[index])), h.Buckets[index]...)
+	} else {
+		h.Buckets[index] = slices.Clone(h.Buckets[index])
+	}
+	h.shared[index] = false
+}
+
+// isShared reports whether the bucket at index is shared with a clone and must not be modified in place.
+func (h *HashSet) isShared(index int) bool {
+	return h.shared != nil && h.shared[index]
+}
+
+// SharesBackingWith reports whether any bucket of the set uses the same backing array as a bucket of other,
+// as a clone does until the bucket is first modified on either side. It is a testing affordance for asserting
+// that Clone did not copy eagerly and that a mutation did copy the bucket it modified. It is O(capacity).
+func (h *HashSet) SharesBackingWith(other *HashSet) bool {
+	arrays := make(map[*interface{}]struct{}, len(h.Buckets))
+	for _, bucket := range h.Buckets {
+		if cap(bucket) > 0 { // An unallocated bucket has no backing array
+			arrays[unsafe.SliceData(bucket)] = struct{}{}
+		}
+	}
+	for _, bucket := range other.Buckets {
+		if _, ok := arrays[unsafe.SliceData(bucket)]; ok && cap(bucket) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestHashSet_Clone(t *testing.T) {
+	set := mustHashSet(t, Options{InsertionOrder: true, Multiset: true})
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	clone := set.Clone()
+	set.Add([]byte("original"))
+	set.Remove([]byte("test0"))
+	clone.Add([]byte("clone"))
+	clone.Add([]byte("test1")) // Counted twice in the clone only
+
+	if set.Contains([]byte("clone")) || !set.Contains([]byte("original")) || set.Contains([]byte("test0")) {
+		t.Errorf("Expected the original to only see its own mutations")
+	}
+	if clone.Contains([]byte("original")) || !clone.Contains([]byte("clone")) || !clone.Contains([]byte("test0")) {
+		t.Errorf("Expected the clone to only see its own mutations")
+	}
+	if set.Count([]byte("test1")) != 1 || clone.Count([]byte("test1")) != 2 {
+		t.Errorf("Expected the occurrence counts to diverge")
+	}
+	if set.Size != 100 || clone.Size != 101 {
+		t.Errorf("Expected sizes 100 and 101, got %d and %d", set.Size, clone.Size)
+	}
+
+	ordered := clone.ToOrderedSlice()
+	if string(ordered[0]) != "test0" || string(ordered[len(ordered)-1]) != "clone" {
+		t.Errorf("Expected the clone to keep its own insertion order")
+	}
+
+	clone.IterRemove(func(value []byte) bool { return true })
+	if clone.Size != 0 || set.Size != 100 || !set.Contains([]byte("test50")) {
+		t.Errorf("Expected removing everything from the clone to leave the original intact")
+	}
+}
+
+func TestHashSet_CloneCopiesOnWrite(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 20; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	clone := set.Clone()
+	changed := set.hash([]byte("test0"), set.Capacity)
+	set.Remove([]byte("test0"))
+
+	for i := range set.Buckets {
+		if len(clone.Buckets[i]) == 0 {
+			continue
+		}
+		same := len(set.Buckets[i]) > 0 && &set.Buckets[i][0] == &clone.Buckets[i][0]
+		if i == changed && same {
+			t.Errorf("Expected the mutated bucket %d to be copied", i)
+		}
+		if i != changed && !same {
+			t.Errorf("Expected the untouched bucket %d to stay shared", i)
+		}
+	}
+}
+
+func TestHashSet_CloneConcurrentReads(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	// The snapshot is read while the original keeps changing
+	snapshot := set.Clone()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			if !snapshot.Contains([]byte(fmt.Sprintf("test%d", i))) {
+				t.Errorf("Expected the snapshot to contain test%d", i)
+			}
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		set.Remove([]byte(fmt.Sprintf("test%d", i)))
+		set.Add([]byte(fmt.Sprintf("new%d", i)))
+	}
+	wg.Wait()
+}
+
+func TestHashSet_SharesBackingWith(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 10; i++ {
+		set.Add([]byte(fmt.Sprintf("value%d", i)))
+	}
+
+	if other := NewHashSet(); set.SharesBackingWith(other) {
+		t.Errorf("Expected an unrelated set to share nothing")
+	}
+
+	clone := set.Clone()
+	if !set.SharesBackingWith(clone) || !clone.SharesBackingWith(set) {
+		t.Fatalf("Expected a clone to share the buckets")
+	}
+
+	clone.Remove([]byte("value0"))
+	if !set.SharesBackingWith(clone) {
+		t.Errorf("Expected the buckets left unmodified to stay shared")
+	}
+
+	// Writing every bucket of the clone copies them all
+	for i := 0; i < 10; i++ {
+		clone.Remove([]byte(fmt.Sprintf("value%d", i)))
+		clone.Add([]byte(fmt.Sprintf("value%d", i)))
+	}
+	if set.SharesBackingWith(clone) {
+		t.Errorf("Expected the modified buckets to be copied")
+	}
+}
@@ -0,0 +1,390 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ConcurrentHashSet is a hash set safe for concurrent use.
+// Readers never take a lock, every bucket chain is immutable once published and is swapped atomically.
+// Writers serialize among themselves and copy a chain before changing it.
+// Growing the bucket array happens in the background, neither readers nor writers wait for it.
+type ConcurrentHashSet struct {
+	lock  *sync.Mutex                     // Lock serializing writers
+	table atomic.Pointer[concurrentTable] // Current bucket array
+	size  atomic.Int64                    // Number of elements in the set
+	seed  uint64                          // Murmur seed used to hash elements, the same for every set
+	opts  Options                         // Options the set was created with
+
+	generation atomic.Uint64     // Bumped on every mutation
+	resizing   *concurrentResize // Resize in progress, guarded by lock
+	resizes    sync.WaitGroup    // Resizes running in the background
+}
+
+// concurrentResize journals the writes made while a larger bucket array is built in the background.
+type concurrentResize struct {
+	log      []concurrentWrite         // Writes applied to the current array since the resize started
+	progress func(migrated, total int) // OnResizeProgress option when the resize started, read without the lock
+}
+
+// concurrentWrite is a journaled Add or Remove.
+type concurrentWrite struct {
+	value  []byte // Element written
+	remove bool   // Whether the element was removed rather than added
+}
+
+// concurrentTable is a bucket array of atomically swapped chains.
+type concurrentTable struct {
+	buckets []atomic.Pointer[[]interface{}] // Buckets to store elements
+}
+
+// NewConcurrentHashSet creates a new instance of ConcurrentHashSet.
+func NewConcurrentHashSet() *ConcurrentHashSet {
+	return NewConcurrentHashSetWithOptions(Options{})
+}
+
+// NewConcurrentHashSetWithOptions creates a new instance of ConcurrentHashSet configured by opts.
+// Only the Capacity and OnMutate options apply.
+func NewConcurrentHashSetWithOptions(opts Options) *ConcurrentHashSet {
+	c := &ConcurrentHashSet{
+		lock: &sync.Mutex{},
+		seed: defaultSeed,
+		opts: opts,
+	}
+	c.table.Store(newConcurrentTable(opts.initialCapacity()))
+	return c
+}
+
+// newConcurrentTable creates an empty bucket array of the given capacity.
+func newConcurrentTable(capacity int) *concurrentTable {
+	return &concurrentTable{
+		buckets: make([]atomic.Pointer[[]interface{}], capacity),
+	}
+}
+
+// notify reports a mutation to the OnMutate option before it is applied, it is called under the writer lock.
+func (c *ConcurrentHashSet) notify(op Op, value []byte) {
+	if c.opts.OnMutate != nil {
+		c.opts.OnMutate(op, value)
+	}
+}
+
+// chain returns the published chain of the bucket at index.
+func (t *concurrentTable) chain(index int) []interface{} {
+	if p := t.buckets[index].Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// chainIndex returns the position of value in chain, or -1 if it is not present.
+func chainIndex(chain []interface{}, value []byte) int {
+	for i, item := range chain {
+		if bytes.Equal(item.([]byte), value) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add inserts a new element into the set.
+func (c *ConcurrentHashSet) Add(value []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t := c.table.Load()
+	index := hashIndex(value, c.seed, len(t.buckets))
+	chain := t.chain(index)
+
+	if chainIndex(chain, value) >= 0 {
+		return // Element already exists
+	}
+
+	c.notify(OpAdd, value)
+
+	// Publish a copy of the chain with the element appended
+	next := make([]interface{}, len(chain), len(chain)+1)
+	copy(next, chain)
+	next = append(next, value)
+	t.buckets[index].Store(&next)
+	c.generation.Add(1)
+	c.size.Add(1)
+	c.journal(value, false)
+
+	c.growIfNeeded(t)
+}
+
+// journal records a write for the resize in progress, if any, it is called under the writer lock.
+func (c *ConcurrentHashSet) journal(value []byte, remove bool) {
+	if c.resizing != nil {
+		c.resizing.log = append(c.resizing.log, concurrentWrite{value: value, remove: remove})
+	}
+}
+
+// growIfNeeded starts a background resize if the load factor is too high and none is in progress.
+// It is called under the writer lock.
+func (c *ConcurrentHashSet) growIfNeeded(t *concurrentTable) {
+	if c.resizing != nil || float64(c.size.Load())/float64(len(t.buckets)) <= loadFactorThreshold {
+		return
+	}
+	if _, ok := growCapacity(len(t.buckets)); !ok {
+		return // At the maximum capacity, let the chains grow
+	}
+
+	r := &concurrentResize{progress: c.opts.OnResizeProgress} // Swap may change the options meanwhile
+	c.resizing = r
+	c.resizes.Add(1)
+	go func() {
+		defer c.resizes.Done()
+		c.resize(t, r)
+	}()
+}
+
+// resize builds a doubled bucket array from t without holding the writer lock, readers and writers keep using t.
+// Writes made meanwhile are journaled and replayed onto the new array under the lock before it is published.
+// Replaying is idempotent per element, so a write already seen while copying the chains is harmless.
+func (c *ConcurrentHashSet) resize(t *concurrentTable, r *concurrentResize) {
+	newCapacity, _ := growCapacity(len(t.buckets))
+
+	chains := make([][]interface{}, newCapacity)
+	progress := r.progress
+	for i := range t.buckets {
+		if progress != nil && i%progressInterval == 0 && i > 0 {
+			progress(i, len(t.buckets)) // Outside the writer lock
+		}
+		for _, item := range t.chain(i) {
+			newIndex := hashIndex(item.([]byte), c.seed, newCapacity)
+			chains[newIndex] = append(chains[newIndex], item)
+		}
+	}
+	if progress != nil {
+		progress(len(t.buckets), len(t.buckets))
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.resizing != r {
+		return // The set was cleared meanwhile
+	}
+
+	for _, w := range r.log {
+		index := hashIndex(w.value, c.seed, newCapacity)
+		i := chainIndex(chains[index], w.value)
+		switch {
+		case w.remove && i >= 0:
+			chains[index] = append(chains[index][:i], chains[index][i+1:]...)
+		case !w.remove && i < 0:
+			chains[index] = append(chains[index], w.value)
+		}
+	}
+
+	next := newConcurrentTable(newCapacity)
+	for i := range chains {
+		if len(chains[i]) > 0 {
+			next.buckets[i].Store(&chains[i])
+		}
+	}
+
+	c.table.Store(next)
+	c.resizing = nil
+	c.generation.Add(1)
+
+	c.growIfNeeded(next) // Writes made meanwhile may call for another resize
+}
+
+// Remove deletes an element from the set.
+func (c *ConcurrentHashSet) Remove(value []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t := c.table.Load()
+	index := hashIndex(value, c.seed, len(t.buckets))
+	chain := t.chain(index)
+
+	i := chainIndex(chain, value)
+	if i < 0 {
+		return // Element does not exist
+	}
+
+	c.notify(OpRemove, value)
+
+	// Publish a copy of the chain without the element
+	next := make([]interface{}, 0, len(chain)-1)
+	next = append(next, chain[:i]...)
+	next = append(next, chain[i+1:]...)
+	t.buckets[index].Store(&next)
+	c.generation.Add(1)
+	c.size.Add(-1)
+	c.journal(value, true)
+}
+
+// Contains checks if an element is in the set without taking a lock.
+func (c *ConcurrentHashSet) Contains(value []byte) bool {
+	t := c.table.Load()
+	index := hashIndex(value, c.seed, len(t.buckets))
+	return chainIndex(t.chain(index), value) >= 0
+}
+
+// Len returns the number of elements in the set.
+func (c *ConcurrentHashSet) Len() int {
+	return int(c.size.Load())
+}
+
+// Clear removes all elements from the set and returns it to the capacity it was created with.
+func (c *ConcurrentHashSet) Clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.notify(OpClear, nil)
+	c.table.Store(newConcurrentTable(c.opts.initialCapacity()))
+	c.size.Store(0)
+	c.resizing = nil // Abandon any resize in progress
+	c.generation.Add(1)
+}
+
+// Swap exchanges the contents and options of the two sets in O(1), for double buffered sets.
+// Both writer locks are taken in address order, so concurrent swaps of the same sets cannot deadlock.
+// Resizes in progress on either set are abandoned and started over on the swapped set if still needed.
+// An abandoned resize only reads what it captured when it started, so it never sees the swapped options.
+func (c *ConcurrentHashSet) Swap(other *ConcurrentHashSet) {
+	if c == other {
+		return
+	}
+
+	first, second := c, other
+	if uintptr(unsafe.Pointer(second)) < uintptr(unsafe.Pointer(first)) {
+		first, second = second, first
+	}
+	first.lock.Lock()
+	defer first.lock.Unlock()
+	second.lock.Lock()
+	defer second.lock.Unlock()
+
+	t, size := c.table.Load(), c.size.Load()
+	c.table.Store(other.table.Load())
+	c.size.Store(other.size.Load())
+	other.table.Store(t)
+	other.size.Store(size)
+	c.opts, other.opts = other.opts, c.opts
+
+	for _, s := range []*ConcurrentHashSet{c, other} {
+		s.resizing = nil // The journal of a resize belongs to the table it was started on
+		s.generation.Add(1)
+		s.growIfNeeded(s.table.Load())
+	}
+}
+
+// ReplaceFromSerialized replaces the contents of the set with the set encoded in data by Serialize, in one atomic
+// step for hot reloads. The new bucket array is decoded and built off to the side, then published under the writer
+// lock, so readers see either the old or the new contents in full and never an empty set in between. The OnMutate
+// option is reported a clear followed by an add of every new element, under the lock. An error leaves the set as it
+// was. Writes racing with the replace either land in the old contents and are dropped, or follow the replace.
+func (c *ConcurrentHashSet) ReplaceFromSerialized(data []byte) error {
+	h, err := Deserialize(data)
+	if err != nil {
+		return err
+	}
+
+	capacity := max(capacityFor(h.Size), c.opts.initialCapacity())
+	chains := make([][]interface{}, capacity)
+	size := 0
+	h.ForEach(func(value []byte) bool {
+		index := hashIndex(value, c.seed, capacity)
+		chains[index] = append(chains[index], value)
+		size++
+		return true
+	})
+
+	next := newConcurrentTable(capacity)
+	for i := range chains {
+		if len(chains[i]) > 0 {
+			next.buckets[i].Store(&chains[i])
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.opts.OnMutate != nil {
+		c.notify(OpClear, nil)
+		for _, chain := range chains {
+			for _, item := range chain {
+				c.notify(OpAdd, item.([]byte))
+			}
+		}
+	}
+	c.table.Store(next)
+	c.size.Store(int64(size))
+	c.resizing = nil // Abandon any resize in progress, its journal belongs to the old contents
+	c.generation.Add(1)
+	return nil
+}
+
+// snapshot returns the chains of every bucket and the size at a single point in time.
+// Chains are immutable once published, so the writer lock is only held to copy the chain references.
+func (c *ConcurrentHashSet) snapshot() ([][]interface{}, int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t := c.table.Load()
+	chains := make([][]interface{}, len(t.buckets))
+	for i := range t.buckets {
+		chains[i] = t.chain(i)
+	}
+	return chains, int(c.size.Load())
+}
+
+// Serialize encodes a snapshot of the set in the format of HashSet.Serialize, decoded by Deserialize.
+// Writers are only held off while the snapshot is taken, not during the encode.
+func (c *ConcurrentHashSet) Serialize() ([]byte, error) {
+	chains, size := c.snapshot()
+
+	h := newHashSet(len(chains), c.seed) // Placement matches hashIndex, the hash of the build
+	h.Buckets = chains
+	h.Size = size
+	return h.Serialize()
+}
+
+// waitResize waits for the resizes running in the background to finish.
+func (c *ConcurrentHashSet) waitResize() {
+	c.resizes.Wait()
+}
+
+// Generation returns a counter bumped on every mutation of the set, including resizes.
+// A reader can retry an optimistic operation if the generation changed between two readings.
+func (c *ConcurrentHashSet) Generation() uint64 {
+	return c.generation.Load()
+}
@@ -0,0 +1,477 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentHashSet(t *testing.T) {
+	set := NewConcurrentHashSet()
+
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	if set.Len() != 1000 {
+		t.Errorf("Expected size to be 1000, got %d", set.Len())
+	}
+
+	for i := 0; i < 1000; i += 2 {
+		set.Remove([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	for i := 0; i < 1000; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		if set.Contains(value) != (i%2 == 1) {
+			t.Errorf("Unexpected membership for %v", value)
+		}
+	}
+
+	set.Clear()
+	if set.Len() != 0 {
+		t.Errorf("Expected size to be 0 after clear, got %d", set.Len())
+	}
+}
+
+func TestConcurrentHashSet_ReadersAndWriters(t *testing.T) {
+	set := NewConcurrentHashSet()
+
+	// Values present from the start must always be visible to readers
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("stable%d", i)))
+	}
+
+	wg := &sync.WaitGroup{}
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				value := []byte(fmt.Sprintf("writer%d-%d", w, i))
+				set.Add(value)
+				if i%3 == 0 {
+					set.Remove(value)
+				}
+			}
+		}(w)
+	}
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20_000; i++ {
+				value := []byte(fmt.Sprintf("stable%d", i%100))
+				if !set.Contains(value) {
+					t.Errorf("Expected set to contain %v", value)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	expected := 100 + 4*(2000-667)
+	if set.Len() != expected {
+		t.Errorf("Expected size to be %d, got %d", expected, set.Len())
+	}
+}
+
+func TestConcurrentHashSet_Generation(t *testing.T) {
+	set := NewConcurrentHashSet()
+	value := []byte("test")
+
+	gen := set.Generation()
+	set.Add(value)
+	if set.Generation() <= gen {
+		t.Errorf("Expected generation to increase on Add")
+	}
+
+	gen = set.Generation()
+	set.Remove(value)
+	if set.Generation() <= gen {
+		t.Errorf("Expected generation to increase on Remove")
+	}
+
+	gen = set.Generation()
+	set.Clear()
+	if set.Generation() <= gen {
+		t.Errorf("Expected generation to increase on Clear")
+	}
+}
+
+func TestConcurrentHashSet_OnMutate(t *testing.T) {
+	ops := make([]Op, 0)
+	set := NewConcurrentHashSetWithOptions(Options{OnMutate: func(op Op, value []byte) {
+		ops = append(ops, op) // Called under the writer lock
+	}})
+
+	wg := &sync.WaitGroup{}
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				set.Add([]byte(fmt.Sprintf("test%d-%d", w, i)))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if len(ops) != 400 {
+		t.Errorf("Expected 400 logged mutations, got %d", len(ops))
+	}
+}
+
+func TestConcurrentHashSet_BackgroundResize(t *testing.T) {
+	set := NewConcurrentHashSet()
+	for i := 0; i < 10000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	set.waitResize()
+
+	if capacity := len(set.table.Load().buckets); float64(set.Len())/float64(capacity) > loadFactorThreshold {
+		t.Errorf("Expected the set to have grown, %d elements in %d buckets", set.Len(), capacity)
+	}
+	for i := 0; i < 10000; i++ {
+		if !set.Contains([]byte(fmt.Sprintf("test%d", i))) {
+			t.Errorf("Expected test%d to be found after resizing", i)
+		}
+	}
+}
+
+func TestConcurrentHashSet_ResizeReplaysWrites(t *testing.T) {
+	set := NewConcurrentHashSet()
+	for i := 0; i < 10; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	// Start a resize by hand and write to the old array before it completes
+	set.lock.Lock()
+	table := set.table.Load()
+	r := &concurrentResize{}
+	set.resizing = r
+	set.lock.Unlock()
+
+	set.Add([]byte("added"))
+	set.Remove([]byte("test3"))
+	set.Add([]byte("test3"))
+	set.Remove([]byte("test5"))
+
+	set.resize(table, r)
+
+	if set.table.Load() == table {
+		t.Fatalf("Expected a new bucket array to be published")
+	}
+	if len(set.table.Load().buckets) != 2*len(table.buckets) {
+		t.Errorf("Expected %d buckets, got %d", 2*len(table.buckets), len(set.table.Load().buckets))
+	}
+	for _, value := range []string{"added", "test3", "test9"} {
+		if !set.Contains([]byte(value)) {
+			t.Errorf("Expected %s to be found after the resize", value)
+		}
+	}
+	if set.Contains([]byte("test5")) {
+		t.Errorf("Expected test5 to stay removed after the resize")
+	}
+	if set.Len() != 10 {
+		t.Errorf("Expected size to be 10, got %d", set.Len())
+	}
+}
+
+func TestConcurrentHashSet_ClearAbandonsResize(t *testing.T) {
+	set := NewConcurrentHashSet()
+	set.Add([]byte("a"))
+
+	set.lock.Lock()
+	table := set.table.Load()
+	r := &concurrentResize{}
+	set.resizing = r
+	set.lock.Unlock()
+
+	set.Clear()
+	set.resize(table, r)
+
+	if set.Contains([]byte("a")) || set.Len() != 0 {
+		t.Errorf("Expected the resize to be abandoned after Clear")
+	}
+}
+
+func TestConcurrentHashSet_Serialize(t *testing.T) {
+	set := NewConcurrentHashSet()
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("stable%d", i)))
+	}
+
+	// Writers keep going while snapshots are encoded
+	stop := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			set.Add([]byte(fmt.Sprintf("churn%d", i)))
+			set.Remove([]byte(fmt.Sprintf("churn%d", i-10)))
+		}
+	}()
+
+	for round := 0; round < 10; round++ {
+		data, err := set.Serialize()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		decoded, err := Deserialize(data)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for i := 0; i < 1000; i++ {
+			if !decoded.Contains([]byte(fmt.Sprintf("stable%d", i))) {
+				t.Fatalf("Expected stable%d in the snapshot of round %d", i, round)
+			}
+		}
+		if len(decoded.ToSlice()) != decoded.Size {
+			t.Errorf("Expected the snapshot size %d to match its elements, got %d", decoded.Size, len(decoded.ToSlice()))
+		}
+	}
+
+	close(stop)
+	wg.Wait()
+	set.waitResize()
+}
+
+func TestConcurrentHashSet_Swap(t *testing.T) {
+	active, standby := NewConcurrentHashSet(), NewConcurrentHashSet()
+	for i := 0; i < 100; i++ {
+		active.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	standby.Add([]byte("standby"))
+
+	active.Swap(standby)
+	if active.Len() != 1 || !active.Contains([]byte("standby")) {
+		t.Errorf("Expected the active set to hold the standby contents")
+	}
+	if standby.Len() != 100 || !standby.Contains([]byte("test50")) {
+		t.Errorf("Expected the standby set to hold the active contents")
+	}
+
+	// Swaps in opposite directions take the locks in the same order
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				active.Swap(standby)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				standby.Swap(active)
+				active.Add([]byte("more"))
+			}
+		}()
+	}
+	wg.Wait()
+	active.waitResize()
+	standby.waitResize()
+
+	if active.Len()+standby.Len() < 101 {
+		t.Errorf("Expected no element to be lost by swapping, got %d and %d", active.Len(), standby.Len())
+	}
+}
+
+func TestConcurrentHashSet_SwapDuringResize(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	active := NewConcurrentHashSetWithOptions(Options{OnResizeProgress: func(migrated, total int) {
+		once.Do(func() {
+			close(started)
+			<-release // Hold the first resize until the sets are swapped
+		})
+	}})
+	standby := NewConcurrentHashSet()
+	for i := 0; i < 100; i++ {
+		active.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	standby.Add([]byte("standby"))
+
+	<-started
+	active.Swap(standby)
+	active.Add([]byte("swapped"))
+	close(release)
+	active.waitResize()
+	standby.waitResize()
+
+	if active.Len() != 2 || !active.Contains([]byte("standby")) || !active.Contains([]byte("swapped")) {
+		t.Errorf("Expected the active set to hold the standby contents, got %d elements", active.Len())
+	}
+	if standby.Len() != 100 {
+		t.Errorf("Expected the standby set to hold 100 elements, got %d", standby.Len())
+	}
+	for i := 0; i < 100; i++ {
+		if !standby.Contains([]byte(fmt.Sprintf("test%d", i))) {
+			t.Errorf("Expected the standby set to contain test%d", i)
+		}
+	}
+
+	// Resizes starting while the sets are swapped must not read the swapped options
+	progress := Options{OnResizeProgress: func(migrated, total int) {}}
+	active, standby = NewConcurrentHashSetWithOptions(progress), NewConcurrentHashSet()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 2000; i++ {
+			active.Add([]byte(fmt.Sprintf("test%d", i)))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			active.Swap(standby)
+		}
+	}()
+	wg.Wait()
+	active.waitResize()
+	standby.waitResize()
+
+	if active.Len()+standby.Len() != 2000 {
+		t.Errorf("Expected 2000 elements across the sets, got %d and %d", active.Len(), standby.Len())
+	}
+}
+
+func TestConcurrentHashSet_OnResizeProgress(t *testing.T) {
+	var mu sync.Mutex
+	var last [2]int
+	var set *ConcurrentHashSet
+	set = NewConcurrentHashSetWithOptions(Options{OnResizeProgress: func(migrated, total int) {
+		set.Add([]byte("progress")) // The writer lock is not held
+		mu.Lock()
+		last = [2]int{migrated, total}
+		mu.Unlock()
+	}})
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	set.waitResize()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if last[0] == 0 || last[0] != last[1] {
+		t.Errorf("Expected the last progress to report every bucket migrated, got %v", last)
+	}
+	if !set.Contains([]byte("progress")) {
+		t.Errorf("Expected the add made during the resize to be kept")
+	}
+}
+
+func TestConcurrentHashSet_ReplaceFromSerialized(t *testing.T) {
+	set := NewConcurrentHashSet()
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("old%d", i)))
+	}
+
+	replacement := NewHashSet()
+	for i := 0; i < 1500; i++ {
+		replacement.Add([]byte(fmt.Sprintf("new%d", i)))
+	}
+	data, err := replacement.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Readers see the old or the new contents, never neither
+	done := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if !set.Contains([]byte("old0")) && !set.Contains([]byte("new0")) {
+					t.Error("Expected a reader to see the old or the new contents")
+					return
+				}
+			}
+		}()
+	}
+
+	if err := set.ReplaceFromSerialized(data); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	close(done)
+	wg.Wait()
+
+	if set.Len() != 1500 || set.Contains([]byte("old0")) {
+		t.Errorf("Expected the old contents to be dropped, got %d elements", set.Len())
+	}
+	for i := 0; i < 1500; i++ {
+		if !set.Contains([]byte(fmt.Sprintf("new%d", i))) {
+			t.Fatalf("Expected new%d to be in the replaced set", i)
+		}
+	}
+
+	if err := set.ReplaceFromSerialized([]byte("garbage")); err == nil || set.Len() != 1500 {
+		t.Errorf("Expected a corrupt payload to leave the set unchanged, got %v and %d elements", err, set.Len())
+	}
+
+	var ops []Op
+	observed := NewConcurrentHashSetWithOptions(Options{OnMutate: func(op Op, value []byte) {
+		ops = append(ops, op)
+	}})
+	small := NewHashSet()
+	small.Add([]byte("a"))
+	small.Add([]byte("b"))
+	if data, err = small.Serialize(); err != nil {
+		t.Fatal(err)
+	}
+	if err := observed.ReplaceFromSerialized(data); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ops) != 3 || ops[0] != OpClear || ops[1] != OpAdd || ops[2] != OpAdd {
+		t.Errorf("Expected a clear and two adds to be reported, got %v", ops)
+	}
+}
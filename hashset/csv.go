@@ -0,0 +1,105 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// ExportCSV writes every element of the set to w as a single column CSV, one element per record.
+// With base64Encode the elements are base64 encoded, otherwise written raw and quoted as needed.
+// Raw export is only lossless for text, a CSV reader turns a "\r\n" inside a field into "\n".
+func (h *HashSet) ExportCSV(w io.Writer, base64Encode bool) error {
+	bw := bufio.NewWriter(w)
+	cw := csv.NewWriter(bw)
+
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			field := string(item.([]byte))
+			if base64Encode {
+				field = base64.StdEncoding.EncodeToString(item.([]byte))
+			}
+
+			if field == "" {
+				// csv.Writer writes an empty record as a blank line, which readers skip
+				cw.Flush()
+				if _, err := bw.WriteString("\"\"\n"); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := cw.Write([]string{field}); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ImportCSV reads a set from a single column CSV, such as one written by ExportCSV.
+// With base64Decode every field is base64 decoded.
+func ImportCSV(r io.Reader, base64Decode bool) (*HashSet, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 1
+
+	h := NewHashSet()
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		value := []byte(record[0])
+		if base64Decode {
+			value, err = base64.StdEncoding.DecodeString(record[0])
+			if err != nil {
+				line, _ := cr.FieldPos(0)
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		h.Add(value)
+	}
+
+	return h, nil
+}
@@ -0,0 +1,89 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHashSet_ExportCSV(t *testing.T) {
+	for _, base64Encode := range []bool{false, true} {
+		set := NewHashSet()
+		for _, value := range []string{"plain", "a,b", "quote\"d", "multi\nline", ""} {
+			set.Add([]byte(value))
+		}
+
+		var buf bytes.Buffer
+		if err := set.ExportCSV(&buf, base64Encode); err != nil {
+			t.Fatalf("Failed to export: %v", err)
+		}
+
+		decoded, err := ImportCSV(&buf, base64Encode)
+		if err != nil {
+			t.Fatalf("Failed to import: %v", err)
+		}
+
+		if decoded.Fingerprint() != set.Fingerprint() {
+			t.Errorf("Expected the imported set to match the exported one (base64 %v)", base64Encode)
+		}
+	}
+}
+
+func TestHashSet_ExportCSVBinary(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte{0x00, '\r', '\n', 0xff})
+
+	var buf bytes.Buffer
+	if err := set.ExportCSV(&buf, true); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	decoded, err := ImportCSV(&buf, true)
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+
+	if !decoded.Contains([]byte{0x00, '\r', '\n', 0xff}) {
+		t.Errorf("Expected binary member to survive a base64 round trip")
+	}
+}
+
+func TestImportCSVInvalid(t *testing.T) {
+	if _, err := ImportCSV(strings.NewReader("a,b\n"), false); err == nil {
+		t.Errorf("Expected a record with two fields to be rejected")
+	}
+
+	if _, err := ImportCSV(strings.NewReader("!!!\n"), true); err == nil {
+		t.Errorf("Expected invalid base64 to be rejected")
+	}
+}
@@ -0,0 +1,157 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Diff format
+//
+//	magic    [4]byte "K4HD"
+//	version  uint8
+//	base     uint64  little endian fingerprint of the set the diff applies to
+//	added    uvarint count, then count x (uvarint length, bytes)
+//	removed  uvarint count, then count x (uvarint length, bytes)
+//	checksum uint32  little endian crc32 (IEEE) of everything before it
+const diffMagic = "K4HD"
+const diffVersion = 1
+
+// SerializeDiff writes the elements added to and removed from base to reach h.
+// The diff records the fingerprint of base so ApplyDiff can refuse to apply it to another set.
+func (h *HashSet) SerializeDiff(base *HashSet, w io.Writer) error {
+	added := make([][]byte, 0)
+	h.ForEach(func(value []byte) bool {
+		if !base.Contains(value) {
+			added = append(added, value)
+		}
+		return true
+	})
+
+	removed := make([][]byte, 0)
+	base.ForEach(func(value []byte) bool {
+		if !h.Contains(value) {
+			removed = append(removed, value)
+		}
+		return true
+	})
+
+	crc := crc32.NewIEEE()
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+
+	header := append([]byte(diffMagic), diffVersion)
+	header = binary.LittleEndian.AppendUint64(header, base.Fingerprint())
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+
+	for _, values := range [][][]byte{added, removed} {
+		if err := writeUvarint(bw, uint64(len(values))); err != nil {
+			return err
+		}
+		for _, value := range values {
+			if err := writeBytes(bw, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := w.Write(binary.LittleEndian.AppendUint32(nil, crc.Sum32()))
+	return err
+}
+
+// ApplyDiff applies a diff written by SerializeDiff to the set.
+// The set must match the base the diff was computed against, the diff is verified before any change is made.
+func (h *HashSet) ApplyDiff(r io.Reader) error {
+	h.checkMutable()
+
+	br := newChecksumReader(r)
+
+	header := make([]byte, len(diffMagic)+1+8)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(header[:len(diffMagic)], []byte(diffMagic)) {
+		return wrapf(ErrCorrupt, "corrupt hashset diff: invalid magic")
+	}
+
+	if version := header[len(diffMagic)]; version != diffVersion {
+		return wrapf(ErrUnsupportedVersion, "unsupported hashset diff version %d", version)
+	}
+
+	if base := binary.LittleEndian.Uint64(header[len(diffMagic)+1:]); base != h.Fingerprint() {
+		return fmt.Errorf("hashset diff does not apply to this set: base fingerprint mismatch")
+	}
+
+	// Read the whole diff before touching the set
+	sections := make([][][]byte, 2)
+	for i := range sections {
+		count, err := binary.ReadUvarint(br)
+		if err != nil {
+			return err
+		}
+
+		for j := uint64(0); j < count; j++ {
+			value, err := readBytes(br)
+			if err != nil {
+				return err
+			}
+			sections[i] = append(sections[i], value)
+		}
+	}
+
+	if err := br.verify(); err != nil {
+		return err
+	}
+
+	// Remove first so the memory of removed elements is freed before any MaxMemory check
+	for _, value := range sections[1] {
+		h.Remove(value)
+	}
+
+	for _, value := range sections[0] {
+		if _, err := h.Add(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_SerializeApplyDiff(t *testing.T) {
+	base := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		base.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	// Build the next version from a copy of the base
+	data, err := base.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		next.Remove([]byte(fmt.Sprintf("test%d", i)))
+		next.Add([]byte(fmt.Sprintf("new%d", i)))
+	}
+
+	var diff bytes.Buffer
+	if err := next.SerializeDiff(base, &diff); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff.Len() >= len(data) {
+		t.Errorf("Expected diff of %d bytes to be smaller than the full set of %d bytes", diff.Len(), len(data))
+	}
+
+	if err := base.ApplyDiff(bytes.NewReader(diff.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	if base.Size != next.Size {
+		t.Errorf("Expected size to be %d, got %d", next.Size, base.Size)
+	}
+
+	next.ForEach(func(value []byte) bool {
+		if !base.Contains(value) {
+			t.Errorf("Expected patched set to contain %v", value)
+		}
+		return true
+	})
+
+	// The base has changed, so the diff no longer applies
+	if err := base.ApplyDiff(bytes.NewReader(diff.Bytes())); err == nil {
+		t.Errorf("Expected error applying a diff to the wrong base")
+	}
+}
+
+func TestHashSet_ApplyDiffCorrupt(t *testing.T) {
+	base := NewHashSet()
+	next := NewHashSet()
+	next.Add([]byte("test"))
+
+	var diff bytes.Buffer
+	if err := next.SerializeDiff(base, &diff); err != nil {
+		t.Fatal(err)
+	}
+
+	corrupt := diff.Bytes()
+	corrupt[len(corrupt)-5] ^= 0xff
+
+	if err := base.ApplyDiff(bytes.NewReader(corrupt)); err == nil {
+		t.Errorf("Expected checksum mismatch")
+	}
+
+	if base.Size != 0 {
+		t.Errorf("Expected a corrupt diff to leave the set unchanged")
+	}
+}
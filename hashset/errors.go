@@ -0,0 +1,68 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by the set, wrapped so errors.Is identifies them whatever the message details.
+// ErrCapacityExceeded, ErrEmptyValue, ErrValueTooLarge, ErrFrozenClosed and ErrSpillClosed are returned as is.
+var (
+	// ErrCorrupt is wrapped by every error describing malformed encoded data, including gob payloads
+	// that fail to decode. Reading the data again from an intact copy may succeed.
+	ErrCorrupt = errors.New("hashset: corrupt data")
+
+	// ErrChecksumMismatch is wrapped by the errors of encoded data failing its checksum. It wraps ErrCorrupt.
+	ErrChecksumMismatch = fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+
+	// ErrUnsupportedVersion is wrapped by the errors of encoded data in a format version or placed by
+	// a hasher this build cannot decode. Another build may be able to decode it.
+	ErrUnsupportedVersion = errors.New("hashset: unsupported encoding")
+
+	// ErrInvalidOption is wrapped by the errors of invalid options and arguments.
+	ErrInvalidOption = errors.New("hashset: invalid option")
+)
+
+// sentinelError is an error matching a sentinel error for errors.Is while keeping its own message.
+type sentinelError struct {
+	err      error // Error as reported, possibly wrapping a cause
+	sentinel error // Sentinel error identifying the kind of failure
+}
+
+func (e *sentinelError) Error() string   { return e.err.Error() }
+func (e *sentinelError) Unwrap() []error { return []error{e.err, e.sentinel} }
+
+// wrapf formats an error like fmt.Errorf that also matches sentinel.
+func wrapf(sentinel error, format string, args ...any) error {
+	return &sentinelError{err: fmt.Errorf(format, args...), sentinel: sentinel}
+}
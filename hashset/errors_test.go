@@ -0,0 +1,79 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHashSet_Errors(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("test"))
+	data, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flipped := append([]byte(nil), data...)
+	flipped[len(flipped)-1] ^= 0xff
+	unsupported := append([]byte(nil), data...)
+	unsupported[len(binaryMagic)] = 0xff
+
+	_, invalid := NewHashSetWithOptions(Options{Capacity: -1})
+	_, gobErr := Deserialize([]byte("not gob"))
+
+	for _, tc := range []struct {
+		name  string
+		err   error
+		match []error
+	}{
+		{"checksum", NewHashSet().UnmarshalBinary(flipped), []error{ErrChecksumMismatch, ErrCorrupt}},
+		{"magic", NewHashSet().UnmarshalBinary([]byte("XXXX\x02")), []error{ErrCorrupt}},
+		{"version", NewHashSet().UnmarshalBinary(unsupported), []error{ErrUnsupportedVersion}},
+		{"gob", gobErr, []error{ErrCorrupt}},
+		{"option", invalid, []error{ErrInvalidOption}},
+		{"argument", set.TrimToFit(2), []error{ErrInvalidOption}},
+	} {
+		for _, target := range tc.match {
+			if !errors.Is(tc.err, target) {
+				t.Errorf("Expected the %s error %v to match %v", tc.name, tc.err, target)
+			}
+		}
+		if errors.Is(tc.err, ErrInvalidOption) != (tc.match[0] == ErrInvalidOption) {
+			t.Errorf("Expected only option errors to match ErrInvalidOption, got %v for %s", tc.err, tc.name)
+		}
+	}
+
+	if err := NewHashSet().UnmarshalBinary(flipped); err.Error() != "corrupt hashset: checksum mismatch" {
+		t.Errorf("Expected the message to be kept, got %q", err)
+	}
+}
@@ -0,0 +1,109 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import "testing"
+
+func TestHashSet_Fallback(t *testing.T) {
+	cold := NewHashSet()
+	cold.Add([]byte("cold"))
+
+	hot := mustHashSet(t, Options{Fallback: cold, MissCache: 4})
+	hot.Add([]byte("hot"))
+
+	if !hot.Contains([]byte("hot")) {
+		t.Errorf("Expected hot to be found in the hot set")
+	}
+	if hot.Contains([]byte("missing")) {
+		t.Errorf("Expected missing not to be found in either set")
+	}
+
+	// A value added to the fallback after a cached miss is still found
+	cold.Add([]byte("missing"))
+	if !hot.Contains([]byte("missing")) {
+		t.Errorf("Expected missing to be found in the fallback")
+	}
+
+	if !hot.Contains([]byte("cold")) {
+		t.Errorf("Expected cold to be found in the fallback")
+	}
+	cold.Remove([]byte("cold"))
+	if !hot.Contains([]byte("cold")) || hot.Size != 3 {
+		t.Errorf("Expected cold to be promoted into the hot set, size %d", hot.Size)
+	}
+}
+
+func TestHashSet_FallbackReusedProbe(t *testing.T) {
+	cold := NewHashSet()
+	cold.Add([]byte("cold"))
+	hot := mustHashSet(t, Options{Fallback: cold})
+
+	probe := []byte("cold")
+	if !hot.Contains(probe) {
+		t.Fatalf("Expected cold to be found in the fallback")
+	}
+	copy(probe, "warm") // The caller reuses its buffer after the promoting lookup
+	cold.Remove([]byte("cold"))
+
+	if !hot.Contains([]byte("cold")) || hot.Contains([]byte("warm")) {
+		t.Errorf("Expected the promoted member to keep its value")
+	}
+	hot.Remove([]byte("cold"))
+	if hot.Size != 0 {
+		t.Errorf("Expected the promoted member to be removable, size %d", hot.Size)
+	}
+
+	// The pooled buffer of ContainsUint64 is reused by the next lookup
+	cold.AddUint64(1)
+	if !hot.ContainsUint64(1) || hot.Size != 1 {
+		t.Fatalf("Expected 1 to be promoted from the fallback, size %d", hot.Size)
+	}
+	cold.RemoveUint64(1)
+	hot.ContainsUint64(2)
+	if !hot.ContainsUint64(1) || hot.ContainsUint64(2) || hot.Size != 1 {
+		t.Errorf("Expected the promoted 1 to keep its value, size %d", hot.Size)
+	}
+}
+
+func TestHashSet_FallbackBounded(t *testing.T) {
+	cold := NewHashSet()
+	cold.Add([]byte("a"))
+	cold.Add([]byte("b"))
+
+	// Promotions beyond the limit are refused but still answered from the fallback
+	hot := mustHashSet(t, Options{Fallback: cold, MaxDistinct: 1})
+	if !hot.Contains([]byte("a")) || !hot.Contains([]byte("b")) {
+		t.Errorf("Expected both values to be found in the fallback")
+	}
+	if hot.Size != 1 {
+		t.Errorf("Expected the hot set to keep its limit of 1, got %d", hot.Size)
+	}
+}
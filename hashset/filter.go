@@ -0,0 +1,62 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+// Filter is the membership filter K4 keeps with every SSTable to skip the tables that cannot hold a key,
+// the role the bloomfilter package had before HashSet replaced it. HashSet implements it, see MightContain.
+//
+// A bloom filter answers with false positives at a rate set by its size, and takes a few bits per key
+// whatever the key length. A HashSet answers exactly, with neither false positives nor false negatives, but
+// stores every key, so its memory and serialized size grow with the key bytes. Callers sizing pages or
+// caches for the compact bloom profile should budget for the keys, or use FingerprintThreshold to cap
+// the bytes kept for long keys.
+type Filter interface {
+	Add(key []byte) (bool, error) // Adds key, reporting whether it was new
+	MightContain(key []byte) bool // Whether key may have been added, exact for a HashSet
+	Serialize() ([]byte, error)   // Encodes the filter for DeserializeFilter
+}
+
+var _ Filter = (*HashSet)(nil)
+
+// MightContain is Contains under the name of the Filter interface. A HashSet has no false positives,
+// so it answers true exactly for the keys in the set.
+func (h *HashSet) MightContain(key []byte) bool {
+	return h.Contains(key)
+}
+
+// DeserializeFilter decodes a Filter encoded by its Serialize method, a set encoded by Serialize.
+func DeserializeFilter(data []byte) (Filter, error) {
+	h, err := Deserialize(data)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
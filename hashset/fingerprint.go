@@ -0,0 +1,85 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import "encoding/binary"
+
+const fingerprintLen = 16                    // size of a long value fingerprint
+const fingerprintSeedHi = 0x6a09e667f3bcc908 // seed of the high half of a fingerprint
+const fingerprintSeedLo = 0xbb67ae8584caa73b // seed of the low half of a fingerprint
+
+// key returns the form value is stored and compared in.
+// Values are first normalized by the Normalize option, then those longer than the FingerprintThreshold option
+// are replaced by their fingerprint.
+func (h *HashSet) key(value []byte) []byte {
+	if h.opts.Normalize != nil {
+		value = h.opts.Normalize(value)
+	}
+	return storedKey(value, h.opts.FingerprintThreshold)
+}
+
+// storedKey returns the form value is stored in by a set with the given fingerprint threshold.
+func storedKey(value []byte, threshold int) []byte {
+	if threshold <= 0 {
+		return value
+	}
+
+	if len(value) <= max(threshold, fingerprintLen) {
+		return value
+	}
+
+	return fingerprintOf(value)
+}
+
+// fingerprintOf computes the 128 bit fingerprint of value.
+func fingerprintOf(value []byte) []byte {
+	fp := make([]byte, 0, fingerprintLen)
+	fp = binary.BigEndian.AppendUint64(fp, hash64(value, fingerprintSeedHi))
+	fp = binary.BigEndian.AppendUint64(fp, hash64(value, fingerprintSeedLo))
+	return fp
+}
+
+// CountFalsePositives returns how many of negatives, values the caller knows were never added, Contains
+// reports present, to measure the false positive rate of the FingerprintThreshold option against known
+// negative keys in tests. Only values stored by fingerprint can be false positives, values stored in full are
+// compared exactly and never counted, so a set without the option always returns 0.
+func (h *HashSet) CountFalsePositives(negatives [][]byte) int {
+	count := 0
+	for _, value := range negatives {
+		if h.opts.Normalize != nil {
+			value = h.opts.Normalize(value)
+		}
+		if stored := storedKey(value, h.opts.FingerprintThreshold); len(stored) != len(value) && h.has(stored) {
+			count++
+		}
+	}
+	return count
+}
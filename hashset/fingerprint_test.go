@@ -0,0 +1,108 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHashSet_FingerprintThreshold(t *testing.T) {
+	set := mustHashSet(t, Options{FingerprintThreshold: 64})
+
+	short := []byte("short value")
+	long := bytes.Repeat([]byte("a"), 1<<20)
+	other := bytes.Repeat([]byte("b"), 1<<20)
+
+	set.Add(short)
+	set.Add(long)
+
+	if !set.Contains(short) || !set.Contains(long) {
+		t.Errorf("Expected set to contain the short and long values")
+	}
+
+	if set.Contains(other) {
+		t.Errorf("Expected set to not contain another long value")
+	}
+
+	// Only the fingerprint of the long value is kept
+	for _, value := range set.ToSlice() {
+		if len(value) > 64 {
+			t.Errorf("Expected no stored value longer than the threshold, got %d bytes", len(value))
+		}
+	}
+
+	set.Remove(long)
+	if set.Contains(long) {
+		t.Errorf("Expected set to not contain the long value after remove")
+	}
+
+	if set.Size != 1 {
+		t.Errorf("Expected size to be 1, got %d", set.Size)
+	}
+}
+
+func TestHashSet_FingerprintThresholdMinimum(t *testing.T) {
+	set := mustHashSet(t, Options{FingerprintThreshold: 1})
+	value := []byte("exactly16bytes!!")
+
+	set.Add(value)
+	if !bytes.Equal(set.ToSlice()[0], value) {
+		t.Errorf("Expected values up to 16 bytes to be stored in full")
+	}
+}
+
+func TestHashSet_CountFalsePositives(t *testing.T) {
+	set := mustHashSet(t, Options{FingerprintThreshold: 32})
+	exact := NewHashSet()
+	negatives := make([][]byte, 100)
+	for i := range negatives {
+		set.Add(bytes.Repeat([]byte{byte(i)}, 64))
+		exact.Add(bytes.Repeat([]byte{byte(i)}, 64))
+		negatives[i] = bytes.Repeat([]byte{byte(i + 100)}, 64)
+	}
+	negatives = append(negatives, []byte("short"))
+
+	if n := set.CountFalsePositives(negatives); n != 0 {
+		t.Errorf("Expected no false positives, got %d", n)
+	}
+
+	// A 16 byte member equal to the fingerprint of a long negative collides with it
+	collision := fingerprintOf(negatives[7])
+	set.Add(collision)
+	exact.Add(collision)
+	if n := set.CountFalsePositives(negatives); n != 1 {
+		t.Errorf("Expected 1 false positive, got %d", n)
+	}
+	if n := exact.CountFalsePositives(negatives); n != 0 {
+		t.Errorf("Expected an exact set to report no false positives, got %d", n)
+	}
+}
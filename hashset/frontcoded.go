@@ -0,0 +1,157 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"slices"
+)
+
+// Front coded format
+//
+//	magic    [4]byte "K4FC"
+//	version  uint8
+//	count    uvarint
+//	members  count x (uvarint shared prefix length with the previous member, uvarint suffix length, suffix)
+//	checksum uint32  little endian crc32 (IEEE) of everything before it
+//
+// Members are sorted by bytes.Compare, so keys sharing long prefixes store little more than their suffixes.
+const frontCodedMagic = "K4FC"
+const frontCodedVersion = 1
+
+// SerializeFrontCoded writes the sorted members of the set to w, each stored as the length of the prefix
+// it shares with the previous member followed by the rest of it.
+// It is far more compact than the other formats for namespaced keys with long common prefixes.
+func (h *HashSet) SerializeFrontCoded(w io.Writer) error {
+	members := make([][]byte, 0, h.Size)
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			members = append(members, item.([]byte))
+		}
+	}
+	slices.SortFunc(members, bytes.Compare)
+
+	crc := crc32.NewIEEE()
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+
+	if _, err := bw.Write(append([]byte(frontCodedMagic), frontCodedVersion)); err != nil {
+		return err
+	}
+
+	if err := writeUvarint(bw, uint64(len(members))); err != nil {
+		return err
+	}
+
+	var prev []byte
+	for _, member := range members {
+		shared := commonPrefixLen(prev, member)
+		if err := writeUvarint(bw, uint64(shared)); err != nil {
+			return err
+		}
+		if err := writeBytes(bw, member[shared:]); err != nil {
+			return err
+		}
+		prev = member
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := w.Write(binary.LittleEndian.AppendUint32(nil, crc.Sum32()))
+	return err
+}
+
+// DeserializeFrontCoded reads a set written by SerializeFrontCoded.
+func DeserializeFrontCoded(r io.Reader) (*HashSet, error) {
+	br := newChecksumReader(r)
+
+	header := make([]byte, len(frontCodedMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(header[:len(frontCodedMagic)], []byte(frontCodedMagic)) {
+		return nil, wrapf(ErrCorrupt, "corrupt hashset: invalid magic")
+	}
+
+	if version := header[len(frontCodedMagic)]; version != frontCodedVersion {
+		return nil, wrapf(ErrUnsupportedVersion, "unsupported front coded hashset version %d", version)
+	}
+
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+
+	h := NewHashSet()
+	var prev []byte
+	for i := uint64(0); i < count; i++ {
+		shared, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+
+		if shared > uint64(len(prev)) {
+			return nil, wrapf(ErrCorrupt, "corrupt hashset: member %d shares %d bytes of a %d byte member", i, shared, len(prev))
+		}
+
+		suffix, err := readBytes(br)
+		if err != nil {
+			return nil, err
+		}
+
+		member := make([]byte, 0, int(shared)+len(suffix))
+		member = append(append(member, prev[:shared]...), suffix...)
+		h.Add(member)
+		prev = member
+	}
+
+	if err := br.verify(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// commonPrefixLen returns the length of the longest common prefix of a and b.
+func commonPrefixLen(a, b []byte) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
@@ -0,0 +1,90 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_SerializeFrontCoded(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("/k4/namespace/tenant/%d/segment/%d", i%10, i)))
+	}
+	set.Add([]byte{})
+
+	var buf bytes.Buffer
+	if err := set.SerializeFrontCoded(&buf); err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+
+	binary, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	if buf.Len() >= len(binary)/2 {
+		t.Errorf("Expected front coding to at least halve the size, got %d of %d bytes", buf.Len(), len(binary))
+	}
+
+	decoded, err := DeserializeFrontCoded(&buf)
+	if err != nil {
+		t.Fatalf("Failed to deserialize: %v", err)
+	}
+
+	if decoded.Fingerprint() != set.Fingerprint() {
+		t.Errorf("Expected decoded set to have the same contents")
+	}
+}
+
+func TestDeserializeFrontCodedCorrupt(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("prefix-a"))
+	set.Add([]byte("prefix-b"))
+
+	var buf bytes.Buffer
+	if err := set.SerializeFrontCoded(&buf); err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+	data := buf.Bytes()
+
+	corrupt := bytes.Clone(data)
+	corrupt[len(corrupt)-5] ^= 0xff
+	if _, err := DeserializeFrontCoded(bytes.NewReader(corrupt)); err == nil {
+		t.Errorf("Expected a checksum mismatch to be rejected")
+	}
+
+	if _, err := DeserializeFrontCoded(bytes.NewReader(data[:len(data)-1])); err == nil {
+		t.Errorf("Expected a truncated payload to be rejected")
+	}
+}
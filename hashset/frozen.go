@@ -0,0 +1,256 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"slices"
+	"sync/atomic"
+)
+
+// Frozen format
+//
+//	magic     [4]byte "K4HF"
+//	version   uint8
+//	hasher    uint8   hasher of the build, which computes the fingerprints
+//	threshold uint64  little endian FingerprintThreshold of the set, long values are looked up by fingerprint
+//	count     uint64  little endian
+//	checksum  uint32  little endian crc32 (IEEE) of the header fields above
+//	offsets   (count + 1) x uint64 little endian, member i spans [offsets[i], offsets[i+1]) of the data
+//	data      members sorted by bytes.Compare, concatenated
+//
+// Members are found by binary search over the offsets, a lookup reads O(log n) small ranges of the file.
+const frozenMagic = "K4HF"
+const frozenVersion = 1
+const frozenHeaderLen = len(frozenMagic) + 2 + 8 + 8 + 4 // magic, version, hasher, threshold, count and checksum
+
+// ErrFrozenClosed is returned by the lookups of a FrozenFile after Close.
+var ErrFrozenClosed = errors.New("hashset: frozen file is closed")
+
+// WriteFrozen writes the set to w in the frozen format, see OpenFrozen.
+func (h *HashSet) WriteFrozen(w io.Writer) error {
+	members := make([][]byte, 0, h.Size)
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			members = append(members, item.([]byte))
+		}
+	}
+	slices.SortFunc(members, bytes.Compare)
+
+	bw := bufio.NewWriter(w)
+
+	header := append([]byte(frozenMagic), frozenVersion, hasherID) // Fingerprints use the hasher of the build
+	header = binary.LittleEndian.AppendUint64(header, uint64(max(h.opts.FingerprintThreshold, 0)))
+	header = binary.LittleEndian.AppendUint64(header, uint64(len(members)))
+	header = binary.LittleEndian.AppendUint32(header, crc32.ChecksumIEEE(header))
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+
+	// Write the offsets
+	offset := uint64(0)
+	var buf [8]byte
+	for i := 0; i <= len(members); i++ {
+		binary.LittleEndian.PutUint64(buf[:], offset)
+		if _, err := bw.Write(buf[:]); err != nil {
+			return err
+		}
+		if i < len(members) {
+			offset += uint64(len(members[i]))
+		}
+	}
+
+	// Write the data
+	for _, member := range members {
+		if _, err := bw.Write(member); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// FrozenFile is a read-only set answering lookups directly from a file in the frozen format.
+type FrozenFile struct {
+	file      io.ReaderAt // Source of the offsets and data
+	closer    io.Closer   // Closes the source
+	count     int         // Number of members
+	threshold int         // FingerprintThreshold of the encoded set
+	data      int64       // Position of the data
+	dataLen   int64       // Length of the data
+	closed    atomic.Bool // Whether Close was called
+}
+
+// OpenFrozen opens a file written by WriteFrozen.
+// Only the header is read, lookups read the offsets and members they need from the file.
+func OpenFrozen(path string) (*FrozenFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	f, err := newFrozenFile(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	f.closer = file
+	return f, nil
+}
+
+// newFrozenFile reads and checks the header of size bytes in the frozen format, read from file.
+// The returned FrozenFile has no closer, the caller sets it when the FrozenFile owns file.
+func newFrozenFile(file io.ReaderAt, size int64) (*FrozenFile, error) {
+	header := make([]byte, frozenHeaderLen)
+	if _, err := file.ReadAt(header, 0); err != nil {
+		return nil, wrapf(ErrCorrupt, "corrupt frozen hashset: %w", err)
+	}
+
+	if !bytes.Equal(header[:len(frozenMagic)], []byte(frozenMagic)) {
+		return nil, wrapf(ErrCorrupt, "corrupt frozen hashset: invalid magic")
+	}
+
+	if version := header[len(frozenMagic)]; version != frozenVersion {
+		return nil, wrapf(ErrUnsupportedVersion, "unsupported frozen hashset version %d", version)
+	}
+
+	body := header[:frozenHeaderLen-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(header[len(body):]) {
+		return nil, wrapf(ErrChecksumMismatch, "corrupt frozen hashset: checksum mismatch")
+	}
+
+	if err := checkHasher(header[len(frozenMagic)+1]); err != nil {
+		return nil, err
+	}
+
+	threshold := binary.LittleEndian.Uint64(header[len(frozenMagic)+2:])
+	count := binary.LittleEndian.Uint64(header[len(frozenMagic)+10:])
+
+	// The offsets must fit in the file
+	if size < int64(frozenHeaderLen) || count >= uint64(size-int64(frozenHeaderLen))/8 {
+		return nil, wrapf(ErrCorrupt, "corrupt frozen hashset: %d members exceed the file", count)
+	}
+
+	if threshold > uint64(maxCapacity) {
+		return nil, wrapf(ErrCorrupt, "corrupt frozen hashset: invalid fingerprint threshold %d", threshold)
+	}
+
+	data := int64(frozenHeaderLen) + int64(count+1)*8
+	return &FrozenFile{
+		file:      file,
+		count:     int(count),
+		threshold: int(threshold),
+		data:      data,
+		dataLen:   size - data,
+	}, nil
+}
+
+// Len returns the number of members.
+func (f *FrozenFile) Len() int {
+	return f.count
+}
+
+// member reads the member at position i.
+func (f *FrozenFile) member(i int) ([]byte, error) {
+	var offsets [16]byte
+	if _, err := f.file.ReadAt(offsets[:], int64(frozenHeaderLen)+int64(i)*8); err != nil {
+		return nil, f.readError(err)
+	}
+
+	start := binary.LittleEndian.Uint64(offsets[:8])
+	end := binary.LittleEndian.Uint64(offsets[8:])
+	if start > end || end > uint64(f.dataLen) {
+		return nil, wrapf(ErrCorrupt, "corrupt frozen hashset: member %d spans [%d,%d) of %d data bytes", i, start, end, f.dataLen)
+	}
+
+	value := make([]byte, end-start)
+	if _, err := f.file.ReadAt(value, f.data+int64(start)); err != nil {
+		return nil, f.readError(err)
+	}
+	return value, nil
+}
+
+// readError describes a failed read, a read racing Close fails with ErrFrozenClosed rather than as corruption.
+func (f *FrozenFile) readError(err error) error {
+	if f.closed.Load() {
+		return ErrFrozenClosed
+	}
+	return wrapf(ErrCorrupt, "corrupt frozen hashset: %w", err)
+}
+
+// Contains checks if an element is in the set by binary search over the members.
+// It returns ErrFrozenClosed after Close.
+func (f *FrozenFile) Contains(value []byte) (bool, error) {
+	if f.closed.Load() {
+		return false, ErrFrozenClosed
+	}
+	value = storedKey(value, f.threshold)
+
+	lo, hi := 0, f.count
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		member, err := f.member(mid)
+		if err != nil {
+			return false, err
+		}
+
+		switch c := bytes.Compare(member, value); {
+		case c == 0:
+			return true, nil
+		case c < 0:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return false, nil
+}
+
+// Close closes the underlying file, releasing it deterministically rather than when the FrozenFile is collected.
+// Lookups afterwards return ErrFrozenClosed. Close is idempotent, calls after the first return nil.
+func (f *FrozenFile) Close() error {
+	if f.closed.Swap(true) || f.closer == nil {
+		return nil
+	}
+	return f.closer.Close()
+}
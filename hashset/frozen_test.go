@@ -0,0 +1,150 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFrozenFile writes set to a frozen file in a temporary directory and returns its path.
+func writeFrozenFile(t *testing.T, set *HashSet) string {
+	path := filepath.Join(t.TempDir(), "set.frozen")
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer file.Close()
+
+	if err := set.WriteFrozen(file); err != nil {
+		t.Fatalf("Failed to write frozen set: %v", err)
+	}
+	return path
+}
+
+func TestHashSet_WriteFrozen(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	set.Add([]byte{}) // The empty member
+
+	frozen, err := OpenFrozen(writeFrozenFile(t, set))
+	if err != nil {
+		t.Fatalf("Failed to open frozen set: %v", err)
+	}
+	defer frozen.Close()
+
+	if frozen.Len() != set.Size {
+		t.Errorf("Expected frozen length to be %d, got %d", set.Size, frozen.Len())
+	}
+
+	for i := 0; i < 1000; i++ {
+		if ok, err := frozen.Contains([]byte(fmt.Sprintf("test%d", i))); !ok || err != nil {
+			t.Errorf("Expected frozen set to contain test%d, got %v %v", i, ok, err)
+		}
+		if ok, err := frozen.Contains([]byte(fmt.Sprintf("miss%d", i))); ok || err != nil {
+			t.Errorf("Expected frozen set to not contain miss%d, got %v %v", i, ok, err)
+		}
+	}
+
+	if ok, _ := frozen.Contains([]byte{}); !ok {
+		t.Errorf("Expected frozen set to contain the empty member")
+	}
+}
+
+func TestHashSet_WriteFrozenFingerprinted(t *testing.T) {
+	set := mustHashSet(t, Options{FingerprintThreshold: 32})
+	long := bytes.Repeat([]byte("x"), 100)
+	set.Add(long)
+
+	frozen, err := OpenFrozen(writeFrozenFile(t, set))
+	if err != nil {
+		t.Fatalf("Failed to open frozen set: %v", err)
+	}
+	defer frozen.Close()
+
+	if ok, err := frozen.Contains(long); !ok || err != nil {
+		t.Errorf("Expected frozen set to contain the long value, got %v %v", ok, err)
+	}
+}
+
+func TestFrozenFile_Close(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("test"))
+
+	frozen, err := OpenFrozen(writeFrozenFile(t, set))
+	if err != nil {
+		t.Fatalf("Failed to open frozen set: %v", err)
+	}
+	if err := frozen.Close(); err != nil {
+		t.Errorf("Expected no error closing, got %v", err)
+	}
+	if err := frozen.Close(); err != nil {
+		t.Errorf("Expected closing twice to be a no-op, got %v", err)
+	}
+	if ok, err := frozen.Contains([]byte("test")); ok || !errors.Is(err, ErrFrozenClosed) {
+		t.Errorf("Expected ErrFrozenClosed after Close, got %v %v", ok, err)
+	}
+}
+
+func TestOpenFrozenCorrupt(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("test"))
+	path := writeFrozenFile(t, set)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+
+	data[len(frozenMagic)+10] ^= 0xff // Corrupt the count
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if _, err := OpenFrozen(path); err == nil {
+		t.Errorf("Expected a corrupt header to be rejected")
+	}
+
+	if err := os.WriteFile(path, data[:4], 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if _, err := OpenFrozen(path); err == nil {
+		t.Errorf("Expected a truncated file to be rejected")
+	}
+}
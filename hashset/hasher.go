@@ -0,0 +1,228 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"reflect"
+	"sync"
+)
+
+// Hasher is a seeded 64 bit hash function placing the elements of a set in buckets.
+// ID identifies the hasher in serialized sets, which only decode where a hasher of the same ID is registered.
+type Hasher interface {
+	ID() uint8                              // Identity recorded in serialized sets
+	Hash64(data []byte, seed uint64) uint64 // Hash of data for the given seed
+}
+
+// Hashers shipped with the package.
+// The murmur hasher is the zero value so payloads encoded before the hasher was recorded decode as murmur.
+const (
+	hasherMurmur uint8 = 0 // github.com/guycipher/k4/murmur, the default
+	hasherFNV    uint8 = 1 // hash/fnv, the default when built with the nomurmur tag
+
+	hasherMurmurX86_32  uint8 = 2 // Reference MurmurHash3_x86_32
+	hasherMurmurX86_128 uint8 = 3 // Reference MurmurHash3_x86_128
+	hasherMurmurX64_128 uint8 = 4 // Reference MurmurHash3_x64_128
+)
+
+// FNVHasher hashes with 64 bit FNV-1a. It is available in every build.
+var FNVHasher Hasher = fnvHasher{}
+
+// fnvHasher implements Hasher with hash/fnv.
+type fnvHasher struct{}
+
+// ID returns the identity of the FNV hasher.
+func (fnvHasher) ID() uint8 {
+	return hasherFNV
+}
+
+// Hash64 hashes data with the given seed.
+// FNV takes no seed, so the seed is hashed ahead of the data.
+func (fnvHasher) Hash64(data []byte, seed uint64) uint64 {
+	h := fnv.New64a()
+	h.Write(binary.LittleEndian.AppendUint64(nil, seed))
+	h.Write(data)
+	return h.Sum64()
+}
+
+var hashersLock sync.RWMutex                         // Guards hashers, hasherNames and hasherNameByID
+var hashers = map[uint8]Hasher{hasherFNV: FNVHasher} // Hashers sets can be decoded with, by ID
+var hasherNames = map[string]Hasher{}                // Hashers registered under a name, by name
+var hasherNameByID = map[uint8]string{}              // Names of the hashers registered under a name
+
+// RegisterHasher makes a custom hasher available to decode sets that were rebuilt with it by RehashWith.
+// Serialize records the name of a named hasher and Deserialize looks the hasher up by it, so a payload
+// decodes only where the same name is registered even if another hasher took the one byte ID there.
+// The binary, checked and split formats record the ID only and refuse sets hashed by a named hasher.
+// An empty name registers the hasher under its ID only. It returns an error if the name or the ID is taken.
+func RegisterHasher(name string, hasher Hasher) error {
+	if hasher == nil {
+		return wrapf(ErrInvalidOption, "hashset: nil hasher")
+	}
+
+	hashersLock.Lock()
+	defer hashersLock.Unlock()
+
+	if _, ok := hasherNames[name]; ok && name != "" {
+		return wrapf(ErrInvalidOption, "hashset hasher %q is already registered", name)
+	}
+	if _, ok := hashers[hasher.ID()]; ok {
+		return wrapf(ErrInvalidOption, "hashset hasher %d is already registered", hasher.ID())
+	}
+	hashers[hasher.ID()] = hasher
+	if name != "" {
+		hasherNames[name] = hasher
+		hasherNameByID[hasher.ID()] = name
+	}
+	return nil
+}
+
+// sameHasher reports whether a and b are the same hasher without comparing interfaces, which panics for hasher
+// types holding a slice or map. Hashers of such a type are the same if their types are.
+func sameHasher(a, b Hasher) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb {
+		return false
+	}
+	return !ta.Comparable() || a == b
+}
+
+// lookupNamedHasher returns the hasher registered under name by RegisterHasher.
+func lookupNamedHasher(name string) (Hasher, error) {
+	hashersLock.RLock()
+	defer hashersLock.RUnlock()
+
+	hasher, ok := hasherNames[name]
+	if !ok {
+		return nil, wrapf(ErrUnsupportedVersion, "hashset encoded with hasher %q, which is not registered in this build", name)
+	}
+	return hasher, nil
+}
+
+// hasherName returns the name the hasher with the given ID was registered under, or "" for an unnamed hasher.
+func hasherName(id uint8) string {
+	hashersLock.RLock()
+	defer hashersLock.RUnlock()
+	return hasherNameByID[id]
+}
+
+// checkIDOnlyHasher returns an error if the set is hashed by a named hasher, which a format recording the
+// one byte ID only could not identify in a build where another hasher took the ID.
+func (h *HashSet) checkIDOnlyHasher(format string) error {
+	if name := hasherName(h.Hasher); name != "" {
+		return wrapf(ErrInvalidOption, "hashset: the %s format records hasher IDs only, it cannot encode a set hashed by %q", format, name)
+	}
+	return nil
+}
+
+// lookupHasher returns the registered hasher with the given ID.
+func lookupHasher(id uint8) (Hasher, error) {
+	hashersLock.RLock()
+	defer hashersLock.RUnlock()
+
+	hasher, ok := hashers[id]
+	if !ok {
+		return nil, wrapf(ErrUnsupportedVersion, "hashset encoded with hasher %d, which is not available in this build", id)
+	}
+	return hasher, nil
+}
+
+// checkHasher returns an error if a payload was hashed by a different hasher than this build uses.
+func checkHasher(id uint8) error {
+	if id != hasherID {
+		return wrapf(ErrUnsupportedVersion, "hashset encoded with hasher %d, this build uses hasher %d", id, hasherID)
+	}
+	return nil
+}
+
+// RehashWith rebuilds the set with a different hasher and records its ID, so later operations
+// and serialized payloads use it. Decoding the payloads requires the hasher to be registered, see RegisterHasher.
+// Every element is rehashed, it is heavier than a resize of the same set.
+func (h *HashSet) RehashWith(hasher Hasher) error {
+	h.checkMutable()
+
+	if hasher == nil {
+		return wrapf(ErrInvalidOption, "hashset: nil hasher")
+	}
+
+	h.Hasher, h.hasher = hasher.ID(), hasher
+	h.rehash(h.Capacity) // Re-place every element with the new hasher
+	return nil
+}
+
+// CollisionStats describes how evenly the elements are spread over the buckets their hashes select.
+type CollisionStats struct {
+	LongestChain int // Elements hashed to the most crowded bucket
+	Collisions   int // Elements hashed to a bucket already selected by another element
+}
+
+// ReseedReport compares the collisions of a set before and after Reseed.
+type ReseedReport struct {
+	Previous uint64         // Seed before the reseed, passing it to Reseed restores the previous placement
+	Before   CollisionStats // Collisions under the previous seed
+	After    CollisionStats // Collisions under the new seed
+}
+
+// Improved reports whether the new seed placed the elements with fewer collisions.
+func (r ReseedReport) Improved() bool {
+	return r.After.Collisions < r.Before.Collisions
+}
+
+// collisionStats counts the collisions of the elements at the current seed. It is O(size + capacity).
+func (h *HashSet) collisionStats() CollisionStats {
+	var stats CollisionStats
+	for _, chain := range h.chains() {
+		stats.LongestChain = max(stats.LongestChain, len(chain))
+		if len(chain) > 1 {
+			stats.Collisions += len(chain) - 1
+		}
+	}
+	return stats
+}
+
+// Reseed rebuilds the set once with seed and reports the collisions before and after, so a caller fixing
+// a bad distribution can check the new seed helped and otherwise try another one, or go back to the
+// previous seed. The zero seed is refused, it marks payloads encoded before seeds were persisted.
+func (h *HashSet) Reseed(seed uint64) (ReseedReport, error) {
+	h.checkMutable()
+
+	if seed == 0 {
+		return ReseedReport{}, wrapf(ErrInvalidOption, "hashset: zero seed")
+	}
+
+	report := ReseedReport{Previous: h.Seed, Before: h.collisionStats()}
+	h.Seed = seed
+	h.rehash(h.Capacity) // Re-place every element with the new seed
+	report.After = h.collisionStats()
+	return report, nil
+}
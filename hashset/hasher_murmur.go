@@ -0,0 +1,94 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//go:build !nomurmur
+
+package hashset
+
+import "github.com/guycipher/k4/murmur"
+
+const hasherID = hasherMurmur // hasher of this build
+
+// MurmurHasher hashes with 64 bit murmur, the default hasher of a set.
+// It is not available when built with the nomurmur tag.
+var MurmurHasher Hasher = murmurHasher{}
+
+var defaultHasher = MurmurHasher // hasher of new sets
+
+// Hashers of the reference MurmurHash3 variants, matching other implementations hashing with the low 32 bits
+// of the seed. The 32-bit variant is zero extended, so bucket and shard indexes agree with the reference hash
+// reduced modulo the same count. They are not available when built with the nomurmur tag.
+var (
+	MurmurX86_32Hasher  Hasher = murmurVariantHasher{hasherMurmurX86_32, murmur.X86_32}
+	MurmurX86_128Hasher Hasher = murmurVariantHasher{hasherMurmurX86_128, murmur.X86_128}
+	MurmurX64_128Hasher Hasher = murmurVariantHasher{hasherMurmurX64_128, murmur.X64_128}
+)
+
+func init() {
+	hashers[hasherMurmur] = MurmurHasher
+	for _, hasher := range []Hasher{MurmurX86_32Hasher, MurmurX86_128Hasher, MurmurX64_128Hasher} {
+		hashers[hasher.ID()] = hasher
+	}
+}
+
+// murmurHasher implements Hasher with github.com/guycipher/k4/murmur.
+type murmurHasher struct{}
+
+// ID returns the identity of the murmur hasher.
+func (murmurHasher) ID() uint8 {
+	return hasherMurmur
+}
+
+// Hash64 hashes data with the given seed.
+func (murmurHasher) Hash64(data []byte, seed uint64) uint64 {
+	return murmur.Hash64(data, seed)
+}
+
+// murmurVariantHasher implements Hasher with a reference variant of github.com/guycipher/k4/murmur.
+type murmurVariantHasher struct {
+	id      uint8          // Identity of the variant
+	variant murmur.Variant // Variant hashing the elements
+}
+
+// ID returns the identity of the variant.
+func (m murmurVariantHasher) ID() uint8 {
+	return m.id
+}
+
+// Hash64 hashes data with the low 32 bits of the given seed.
+func (m murmurVariantHasher) Hash64(data []byte, seed uint64) uint64 {
+	return m.variant.Sum64(data, uint32(seed))
+}
+
+// hash64 hashes data with the given seed using the hasher of this build.
+func hash64(data []byte, seed uint64) uint64 {
+	return murmur.Hash64(data, seed)
+}
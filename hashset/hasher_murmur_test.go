@@ -0,0 +1,97 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//go:build !nomurmur
+
+package hashset
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/guycipher/k4/murmur"
+)
+
+func TestHashSet_MurmurVariantHasher(t *testing.T) {
+	set := mustHashSet(t, Options{Capacity: 64, Hasher: MurmurX86_32Hasher})
+	if set.Hasher != hasherMurmurX86_32 {
+		t.Fatalf("Expected hasher %d to be recorded, got %d", hasherMurmurX86_32, set.Hasher)
+	}
+
+	// Elements land in the bucket the reference x86_32 hash picks
+	for i := 0; i < 40; i++ {
+		value := []byte(fmt.Sprintf("key%d", i))
+		set.Add(value)
+		if index := set.hash(value, set.Capacity); index != int(murmur.Hash32(value, uint32(set.Seed))%uint32(set.Capacity)) {
+			t.Errorf("Expected %s in the bucket of its x86_32 hash, got %d", value, index)
+		}
+	}
+
+	data, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	decoded := NewHashSet()
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decoded.Hasher != hasherMurmurX86_32 {
+		t.Errorf("Expected the decoded set to use hasher %d, got %d", hasherMurmurX86_32, decoded.Hasher)
+	}
+	for i := 0; i < 40; i++ {
+		if !decoded.Contains([]byte(fmt.Sprintf("key%d", i))) {
+			t.Errorf("Expected key%d to be found after decoding", i)
+		}
+	}
+}
+
+func TestMurmurVariantHashers(t *testing.T) {
+	value := []byte("hello")
+	x86, _ := murmur.Hash128x86(value, 4)
+	x64, _ := murmur.Hash128x64(value, 4)
+
+	tests := []struct {
+		hasher Hasher
+		want   uint64
+	}{
+		{MurmurX86_32Hasher, uint64(murmur.Hash32(value, 4))},
+		{MurmurX86_128Hasher, x86},
+		{MurmurX64_128Hasher, x64},
+	}
+	for _, tt := range tests {
+		if h := tt.hasher.Hash64(value, 4); h != tt.want {
+			t.Errorf("Expected hasher %d to hash to %016x, got %016x", tt.hasher.ID(), tt.want, h)
+		}
+		if registered, err := lookupHasher(tt.hasher.ID()); err != nil || registered != tt.hasher {
+			t.Errorf("Expected hasher %d to be registered", tt.hasher.ID())
+		}
+	}
+}
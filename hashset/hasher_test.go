@@ -0,0 +1,392 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"testing"
+)
+
+func TestHashSet_HasherRecorded(t *testing.T) {
+	set := NewHashSet()
+	if set.Hasher != hasherID {
+		t.Errorf("Expected hasher to be %d, got %d", hasherID, set.Hasher)
+	}
+
+	set.Add([]byte("test"))
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+
+	decoded, err := Deserialize(data)
+	if err != nil {
+		t.Fatalf("Failed to deserialize: %v", err)
+	}
+
+	if !decoded.Contains([]byte("test")) {
+		t.Errorf("Expected decoded set to contain test")
+	}
+}
+
+func TestHashSet_HasherMismatchRejected(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("test"))
+	set.Hasher = 255 // Pretend a set of an unregistered hasher
+
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+
+	if _, err := Deserialize(data); err == nil {
+		t.Errorf("Expected a gob payload of an unregistered hasher to be rejected")
+	}
+
+	data, err = set.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	if err := NewHashSet().UnmarshalBinary(data); err == nil {
+		t.Errorf("Expected a binary payload of an unregistered hasher to be rejected")
+	}
+
+	m := NewHashMap()
+	m.Hasher = hasherID + 1 // Maps always use the hasher of the build
+	data, err = m.Serialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+
+	if _, err := DeserializeMap(data); err == nil {
+		t.Errorf("Expected a map payload of another hasher to be rejected")
+	}
+}
+
+func TestHashSet_UnmarshalBinaryVersion1(t *testing.T) {
+	if hasherID != hasherMurmur {
+		t.Skip("version 1 payloads are always murmur")
+	}
+
+	set := NewHashSet()
+	set.Add([]byte("test"))
+
+	data, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	// Drop the hasher byte and the checksum, then re-sign as version 1
+	v1 := append([]byte(binaryMagic), 1)
+	v1 = append(v1, data[len(binaryMagic)+2:len(data)-binaryChecksumLen]...)
+	v1 = binary.LittleEndian.AppendUint32(v1, crc32.ChecksumIEEE(v1))
+
+	decoded := NewHashSet()
+	if err := decoded.UnmarshalBinary(v1); err != nil {
+		t.Fatalf("Failed to unmarshal version 1: %v", err)
+	}
+
+	if !decoded.Contains([]byte("test")) {
+		t.Errorf("Expected decoded set to contain test")
+	}
+}
+
+// xorHasher is a custom hasher for tests.
+type xorHasher struct{}
+
+func (xorHasher) ID() uint8 { return 200 }
+
+func (xorHasher) Hash64(data []byte, seed uint64) uint64 {
+	return FNVHasher.Hash64(data, seed) ^ 0x5555555555555555
+}
+
+func TestHashSet_RehashWith(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	if err := set.RehashWith(FNVHasher); err != nil {
+		t.Fatalf("Failed to rehash: %v", err)
+	}
+
+	if set.Hasher != hasherFNV {
+		t.Errorf("Expected recorded hasher to be %d, got %d", hasherFNV, set.Hasher)
+	}
+
+	for index, bucket := range set.Buckets {
+		for _, item := range bucket {
+			if digestIndex(FNVHasher.Hash64(item.([]byte), set.Seed), set.Capacity) != index {
+				t.Fatalf("Expected every element to be placed by the new hasher")
+			}
+		}
+	}
+
+	for i := 0; i < 1000; i++ {
+		if !set.Contains([]byte(fmt.Sprintf("test%d", i))) {
+			t.Errorf("Expected set to contain test%d after rehash", i)
+		}
+	}
+
+	// The hasher survives a serialization round trip
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+
+	decoded, err := Deserialize(data)
+	if err != nil {
+		t.Fatalf("Failed to deserialize: %v", err)
+	}
+
+	if decoded.Hasher != hasherFNV || !decoded.Contains([]byte("test500")) {
+		t.Errorf("Expected decoded set to use the FNV hasher")
+	}
+
+	if err := set.RehashWith(nil); err == nil {
+		t.Errorf("Expected a nil hasher to be rejected")
+	}
+}
+
+func TestRegisterHasher(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("test"))
+	if err := set.RehashWith(xorHasher{}); err != nil {
+		t.Fatalf("Failed to rehash: %v", err)
+	}
+
+	data, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	if err := NewHashSet().UnmarshalBinary(data); err == nil {
+		t.Errorf("Expected a payload of an unregistered hasher to be rejected")
+	}
+
+	if err := RegisterHasher("", xorHasher{}); err != nil {
+		t.Fatalf("Failed to register hasher: %v", err)
+	}
+
+	decoded := NewHashSet()
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+
+	if !decoded.Contains([]byte("test")) || decoded.Hasher != 200 {
+		t.Errorf("Expected decoded set to use the registered hasher")
+	}
+
+	again, err := decoded.MarshalBinary()
+	if err != nil || !bytes.Equal(again, data) {
+		t.Errorf("Expected the decoded set to marshal identically")
+	}
+
+	if err := RegisterHasher("", xorHasher{}); err == nil {
+		t.Errorf("Expected a duplicate registration to be rejected")
+	}
+}
+
+// namedHasher is a custom hasher for tests whose ID is its value, every ID hashes the same.
+type namedHasher uint8
+
+func (n namedHasher) ID() uint8 { return uint8(n) }
+
+func (namedHasher) Hash64(data []byte, seed uint64) uint64 {
+	return FNVHasher.Hash64(data, seed) ^ 0xaaaaaaaaaaaaaaaa
+}
+
+// unregisterNamedHasher removes a hasher registered under a name.
+func unregisterNamedHasher(name string) {
+	hashersLock.Lock()
+	defer hashersLock.Unlock()
+	if hasher, ok := hasherNames[name]; ok {
+		delete(hashers, hasher.ID())
+		delete(hasherNameByID, hasher.ID())
+		delete(hasherNames, name)
+	}
+}
+
+func TestRegisterHasher_Named(t *testing.T) {
+	if err := RegisterHasher("test/xor", namedHasher(210)); err != nil {
+		t.Fatalf("Failed to register hasher: %v", err)
+	}
+	defer unregisterNamedHasher("test/xor")
+
+	if err := RegisterHasher("test/xor", namedHasher(211)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected a taken name to be rejected, got %v", err)
+	}
+	if err := RegisterHasher("test/other", namedHasher(210)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected a taken ID to be rejected, got %v", err)
+	}
+	if err := RegisterHasher("test/nil", nil); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected a nil hasher to be rejected, got %v", err)
+	}
+
+	set := mustHashSet(t, Options{Hasher: namedHasher(210)})
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Hasher != 210 || !decoded.Contains([]byte("test50")) {
+		t.Errorf("Expected the decoded set to use the named hasher")
+	}
+
+	// Formats recording the ID only cannot identify the named hasher
+	if _, err := set.MarshalBinary(); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption marshaling a named hasher, got %v", err)
+	}
+	if err := set.SerializeChecked(io.Discard); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption for the checked format, got %v", err)
+	}
+	opened := false
+	err = set.SerializeSplit(1<<20, func(int) (io.WriteCloser, error) {
+		opened = true
+		return bufferCloser{&bytes.Buffer{}}, nil
+	})
+	if !errors.Is(err, ErrInvalidOption) || opened {
+		t.Errorf("Expected ErrInvalidOption for the split format before any part, got %v", err)
+	}
+
+	// The name is looked up, not the ID, so a build registering the hasher under another ID decodes it
+	unregisterNamedHasher("test/xor")
+	if _, err := Deserialize(data); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion for an unregistered name, got %v", err)
+	}
+	if err := RegisterHasher("test/xor", namedHasher(211)); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err = Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Hasher != 211 || !decoded.Contains([]byte("test50")) {
+		t.Errorf("Expected the decoded set to use the hasher registered under the name, got ID %d", decoded.Hasher)
+	}
+}
+
+// tableHasher is a custom hasher for tests of an uncomparable type, interface == on it panics.
+type tableHasher struct {
+	table []uint64
+}
+
+func (tableHasher) ID() uint8 { return 220 }
+
+func (h tableHasher) Hash64(data []byte, seed uint64) uint64 {
+	return FNVHasher.Hash64(data, seed) ^ h.table[0]
+}
+
+func TestHashSet_UncomparableHasher(t *testing.T) {
+	hasher := tableHasher{table: []uint64{0x1234}}
+	if err := RegisterHasher("", hasher); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		hashersLock.Lock()
+		delete(hashers, hasher.ID())
+		hashersLock.Unlock()
+	}()
+
+	set := mustHashSet(t, Options{Hasher: tableHasher{table: []uint64{0x1234}}})
+	set.Add([]byte("test"))
+	if !set.Contains([]byte("test")) || set.Hasher != 220 {
+		t.Errorf("Expected the set to use the uncomparable hasher")
+	}
+
+	if _, err := NewHashSetWithOptions(Options{Hasher: namedHasher(220)}); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected a hasher of another type under the same ID to conflict, got %v", err)
+	}
+}
+
+func TestHashSet_Reseed(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 500; i++ {
+		set.Add([]byte(fmt.Sprintf("key%d", i)))
+	}
+	original := set.collisionStats()
+
+	report, err := set.Reseed(12345)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report.Previous != defaultSeed || set.Seed != 12345 {
+		t.Errorf("Expected the seed to move from %d to 12345, got %d to %d", defaultSeed, report.Previous, set.Seed)
+	}
+	if report.Before != original || report.After != set.collisionStats() {
+		t.Errorf("Expected the report to describe the set before and after, got %+v", report)
+	}
+	if report.Improved() != (report.After.Collisions < report.Before.Collisions) {
+		t.Errorf("Expected Improved to compare the collisions")
+	}
+	for i := 0; i < 500; i++ {
+		if !set.Contains([]byte(fmt.Sprintf("key%d", i))) {
+			t.Fatalf("Expected key%d to be found after the reseed", i)
+		}
+	}
+
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Seed != 12345 || !decoded.Contains([]byte("key7")) {
+		t.Errorf("Expected the new seed to be serialized")
+	}
+
+	back, err := set.Reseed(report.Previous)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if back.After != original {
+		t.Errorf("Expected reseeding back to restore %+v, got %+v", original, back.After)
+	}
+
+	if _, err := set.Reseed(0); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption for the zero seed, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// MapEntry is a key/value pair stored in a HashMap.
+type MapEntry struct {
+	Key   []byte // The key of the entry
+	Value []byte // The value associated with the key
+}
+
+// HashMap is a hash map of byte slice keys to byte slice values.
+// It shares the hashing, growth and serialization approach of HashSet.
+type HashMap struct {
+	Buckets  [][]MapEntry // Buckets of entries
+	Size     int          // Number of entries
+	Capacity int          // Number of buckets, always a power of two
+	Seed     uint64       // Seed used to hash keys
+	Hasher   uint8        // Hasher used to hash keys, fixed at compile time
+}
+
+// NewHashMap creates a new instance of HashMap.
+func NewHashMap() *HashMap {
+	return &HashMap{
+		Buckets:  make([][]MapEntry, initialCapacity),
+		Size:     0,
+		Capacity: initialCapacity,
+		Seed:     defaultSeed,
+		Hasher:   hasherID,
+	}
+}
+
+// find returns the position of key in the bucket at index, or -1 if it is absent.
+func (m *HashMap) find(index int, key []byte) int {
+	for i, entry := range m.Buckets[index] {
+		if bytes.Equal(entry.Key, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Put associates value with key, replacing any previous value.
+func (m *HashMap) Put(key, value []byte) {
+	index := hashIndex(key, m.Seed, m.Capacity) // Compute the index
+
+	if i := m.find(index, key); i >= 0 {
+		m.Buckets[index][i].Value = value // Replace the value
+		return
+	}
+
+	m.Buckets[index] = append(m.Buckets[index], MapEntry{Key: key, Value: value})
+	m.Size++
+
+	// Check if we need to resize the map
+	if float64(m.Size)/float64(m.Capacity) > loadFactorThreshold {
+		m.resize()
+	}
+}
+
+// Get returns the value associated with key and whether the key is present.
+func (m *HashMap) Get(key []byte) ([]byte, bool) {
+	index := hashIndex(key, m.Seed, m.Capacity) // Compute the index
+	if i := m.find(index, key); i >= 0 {
+		return m.Buckets[index][i].Value, true
+	}
+	return nil, false
+}
+
+// Delete removes key and its value from the map.
+func (m *HashMap) Delete(key []byte) {
+	index := hashIndex(key, m.Seed, m.Capacity) // Compute the index
+	if i := m.find(index, key); i >= 0 {
+		m.Buckets[index] = append(m.Buckets[index][:i], m.Buckets[index][i+1:]...) // Remove the entry
+		m.Size--
+	}
+}
+
+// Len returns the number of entries in the map.
+func (m *HashMap) Len() int {
+	return m.Size
+}
+
+// resize increases the capacity of the hash map.
+func (m *HashMap) resize() {
+	newCapacity, ok := growCapacity(m.Capacity) // new capacity
+	if !ok {
+		return // At the maximum capacity, let the chains grow
+	}
+
+	newBuckets := make([][]MapEntry, newCapacity) // new buckets
+
+	for _, bucket := range m.Buckets {
+		for _, entry := range bucket {
+			newIndex := hashIndex(entry.Key, m.Seed, newCapacity) // Compute the new index
+			newBuckets[newIndex] = append(newBuckets[newIndex], entry)
+		}
+	}
+
+	m.Buckets = newBuckets   // Update the buckets
+	m.Capacity = newCapacity // Update the capacity
+}
+
+// Serialize encodes the HashMap into a byte slice.
+func (m *HashMap) Serialize() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	err := enc.Encode(m)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DeserializeMap decodes the byte slice into a HashMap.
+func DeserializeMap(data []byte) (m *HashMap, err error) {
+	// Malformed input must never crash the caller
+	defer func() {
+		if r := recover(); r != nil {
+			m, err = nil, wrapf(ErrCorrupt, "corrupt hashmap: %v", r)
+		}
+	}()
+
+	m = &HashMap{}
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	err = dec.Decode(m)
+	if err != nil {
+		return nil, wrapf(ErrCorrupt, "corrupt hashmap: %w", err)
+	}
+
+	if err := checkHasher(m.Hasher); err != nil {
+		return nil, err
+	}
+
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// validate checks the structural invariants of a decoded map.
+func (m *HashMap) validate() error {
+	if m.Capacity <= 0 || m.Capacity > maxCapacity || m.Capacity&(m.Capacity-1) != 0 {
+		return wrapf(ErrCorrupt, "corrupt hashmap: invalid capacity %d", m.Capacity)
+	}
+
+	if len(m.Buckets) != m.Capacity {
+		return wrapf(ErrCorrupt, "corrupt hashmap: %d buckets for capacity %d", len(m.Buckets), m.Capacity)
+	}
+
+	if m.Seed == 0 {
+		return wrapf(ErrCorrupt, "corrupt hashmap: zero seed")
+	}
+
+	count := 0
+	for _, bucket := range m.Buckets {
+		count += len(bucket)
+	}
+
+	if count != m.Size {
+		return wrapf(ErrCorrupt, "corrupt hashmap: size %d does not match %d entries", m.Size, count)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestHashMap_PutGet(t *testing.T) {
+	m := NewHashMap()
+
+	for i := 0; i < 1000; i++ {
+		m.Put([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+	}
+
+	if m.Len() != 1000 {
+		t.Errorf("Expected map length to be 1000, got %d", m.Len())
+	}
+
+	for i := 0; i < 1000; i++ {
+		value, ok := m.Get([]byte(fmt.Sprintf("key%d", i)))
+		if !ok || !bytes.Equal(value, []byte(fmt.Sprintf("value%d", i))) {
+			t.Errorf("Expected key%d to map to value%d, got %q %v", i, i, value, ok)
+		}
+	}
+
+	if _, ok := m.Get([]byte("missing")); ok {
+		t.Errorf("Expected missing key to be absent")
+	}
+}
+
+func TestHashMap_PutReplaces(t *testing.T) {
+	m := NewHashMap()
+	m.Put([]byte("key"), []byte("old"))
+	m.Put([]byte("key"), []byte("new"))
+
+	if m.Len() != 1 {
+		t.Errorf("Expected map length to be 1, got %d", m.Len())
+	}
+
+	if value, _ := m.Get([]byte("key")); !bytes.Equal(value, []byte("new")) {
+		t.Errorf("Expected value to be new, got %q", value)
+	}
+}
+
+func TestHashMap_Delete(t *testing.T) {
+	m := NewHashMap()
+	m.Put([]byte("key"), []byte("value"))
+	m.Delete([]byte("key"))
+	m.Delete([]byte("missing"))
+
+	if _, ok := m.Get([]byte("key")); ok {
+		t.Errorf("Expected key to be deleted")
+	}
+
+	if m.Len() != 0 {
+		t.Errorf("Expected map length to be 0, got %d", m.Len())
+	}
+}
+
+func TestHashMap_Serialize(t *testing.T) {
+	m := NewHashMap()
+	for i := 0; i < 100; i++ {
+		m.Put([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+	}
+
+	data, err := m.Serialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+
+	decoded, err := DeserializeMap(data)
+	if err != nil {
+		t.Fatalf("Failed to deserialize: %v", err)
+	}
+
+	if decoded.Len() != 100 {
+		t.Errorf("Expected map length to be 100, got %d", decoded.Len())
+	}
+
+	for i := 0; i < 100; i++ {
+		value, ok := decoded.Get([]byte(fmt.Sprintf("key%d", i)))
+		if !ok || !bytes.Equal(value, []byte(fmt.Sprintf("value%d", i))) {
+			t.Errorf("Expected key%d to map to value%d, got %q %v", i, i, value, ok)
+		}
+	}
+
+	if _, err := DeserializeMap(data[:len(data)/2]); err == nil {
+		t.Errorf("Expected truncated data to be rejected")
+	}
+}
@@ -34,124 +34,1551 @@ package hashset
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
 	"encoding/gob"
-	"github.com/guycipher/k4/murmur"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"slices"
+	"time"
+	"unsafe"
 )
 
-const initialCapacity = 32      // initial hashset capacity
-const loadFactorThreshold = 0.7 // load factor threshold
+const initialCapacity = 32             // initial hashset capacity
+const loadFactorThreshold = 0.7        // load factor threshold
+const rehashCheckInterval = 4096       // elements placed between two checks for a cancelled rehash
+const progressInterval = 1 << 14       // buckets migrated between two calls of the OnResizeProgress option
+const defaultSeed = 4                  // default murmur seed
+const contentSeed = 0x3c6ef372fe94f82b // seed of the element hashes combined by Fingerprint
+
+// maxCapacity is the largest power of two capacity that can be doubled without overflowing int.
+// Once reached the set stops resizing and bucket chains grow instead.
+const maxCapacity = 1 << (bits.UintSize - 2)
 
 // HashSet represents a hash set.
 type HashSet struct {
 	Buckets  [][]interface{} // Buckets to store elements
 	Size     int             // Number of elements in the set
 	Capacity int             // Capacity of the set
+	Seed     uint64          // Seed used to hash elements
+	Hasher   uint8           // ID of the hasher used to place elements
+	Strategy Strategy        // Collision resolution strategy placing elements
+	hasher   Hasher          // Hasher used to place elements
+	frozen   bool            // Whether the set is read-only
+	opts     Options         // Options the set was created with
+
+	generation uint64 // Bumped on every mutation
+	memory     int    // Estimated memory held by the elements
+	borrowed   []byte // Buffer the members were decoded from by DeserializeZeroCopy, never written to
+	contents   uint64 // XOR of the element hashes of Fingerprint, kept under the IncrementalFingerprint option
+
+	secondary map[int][][]int   // Secondary hash index of long bucket chains
+	tags      map[int][]uint32  // Tags of the elements of long bucket chains, see ChainFingerprints
+	order     *insertionOrder   // Insertion order of the elements
+	bloom     *summaryBloom     // Summary of the element digests for fast negative lookups
+	lengths   *lengthFilter     // Lengths of the elements for fast negative lookups, for the LengthFilter option
+	access    *accessCounter    // Lookup counts of the elements
+	latency   *LatencyStats     // Latencies of the operations
+	probes    *ProbeStats       // Comparisons made by Contains, for the Profiling option
+	counts    *multisetCounts   // Occurrence counts of the elements
+	times     *insertTimes      // Times the elements were added, for expiry
+	reservoir *reservoir        // Sample of the values refused by MaxDistinct
+	arena     *arena            // Contiguous copy of the elements for sequential scans
+	misses    *missCache        // Recent values Contains found absent
+	deleted   *tombstoneSet     // Keys deleted by Remove, for the Tombstones option
+	seqs      *memberSequences  // Sequence numbers of the changes to the elements, for the Sequences option
+	changes   *memberChanges    // Generations the elements were added or removed at, for the TrackChanges option
+	originals *originalForms    // Forms the elements were added in, for the Normalize option
+	legacy    legacyFilter      // Filter answering for the keys of a migrated SSTable
+	shared    []bool            // Buckets shared with clones, copied before they are modified
+	largest   *largestMember    // Length of the largest element, for the TrackMaxMemberSize option
+	growth    incrementalGrowth // Doubling in progress, for the IncrementalResize option
+	rate      *insertRate       // Recent rate of new elements, for the ResizeHorizon option
+	adaptive  *adaptiveLoad     // Read/write mix steering the load factor, for the AdaptiveLoadFactor option
 }
 
 // NewHashSet creates a new instance of HashSet.
 func NewHashSet() *HashSet {
+	return newHashSet(initialCapacity, defaultSeed)
+}
+
+// NewHashSetWithCapacity creates a new instance of HashSet with the given capacity rounded up to a power of two.
+func NewHashSetWithCapacity(capacity int) *HashSet {
+	return newHashSetWithOptions(Options{Capacity: capacity})
+}
+
+// NewHashSetKeyed creates a new instance of HashSet with a seed derived from key.
+// Sets created with the same key hash identically, sets created with different keys do not.
+func NewHashSetKeyed(key []byte) *HashSet {
+	return newHashSet(initialCapacity, deriveSeed(key))
+}
+
+// NewHashSetForTest creates a new instance of HashSet with the given seed and capacity, rounded up to a power of two,
+// so tests can assert exact bucket placement. A zero seed is replaced by the default seed as it marks a payload
+// encoded before seeds were persisted. It is a testing affordance, production code should use the other constructors.
+func NewHashSetForTest(seed uint32, capacity int) *HashSet {
+	h := newHashSet(nextPowerOfTwo(capacity), uint64(seed))
+	if seed == 0 {
+		h.Seed = defaultSeed
+	}
+	h.opts.Capacity = capacity // Clear returns to the same capacity
+	return h
+}
+
+// NewHashSetWithOptions creates a new instance of HashSet configured by opts.
+// It returns an error describing the first invalid option or combination of options, see Options.
+func NewHashSetWithOptions(opts Options) (*HashSet, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	return newHashSetWithOptions(opts), nil
+}
+
+// newHashSetWithOptions creates a new instance of HashSet configured by opts without validating them.
+func newHashSetWithOptions(opts Options) *HashSet {
+	h := newHashSet(opts.initialCapacity(), defaultSeed)
+	if opts.NormalizerID != 0 || opts.NormalizerName != "" {
+		opts.Normalize = opts.selectedNormalizer()
+	}
+	if opts.Hasher != nil {
+		h.Hasher, h.hasher = opts.Hasher.ID(), opts.Hasher
+	}
+	h.applyOptions(opts)
+	if opts.SlabBuckets {
+		h.Buckets = h.makeBuckets(h.Capacity)
+	}
+	return h
+}
+
+// applyOptions configures the set with opts, creating the structures the options need.
+func (h *HashSet) applyOptions(opts Options) {
+	// SortedBuckets is the SortedChaining strategy
+	if opts.SortedBuckets && opts.Strategy == SeparateChaining {
+		opts.Strategy = SortedChaining
+	}
+	opts.SortedBuckets = opts.Strategy == SortedChaining
+	h.opts = opts
+	h.Strategy = opts.Strategy
+
+	h.order = nil
+	if opts.InsertionOrder {
+		h.order = newInsertionOrder()
+	}
+
+	h.bloom = newSummaryBloom(opts.BloomBits)
+
+	h.access = nil
+	if opts.CountAccess {
+		h.access = newAccessCounter()
+	}
+
+	h.latency = nil
+	h.probes = nil
+	if opts.Profiling {
+		h.latency = &LatencyStats{}
+		h.probes = &ProbeStats{}
+	}
+
+	h.counts = nil
+	if opts.Multiset {
+		h.counts = newMultisetCounts()
+	}
+
+	h.times = nil
+	if opts.TTL > 0 {
+		h.times = newInsertTimes(opts.TTL)
+	}
+
+	h.reservoir = nil
+	if opts.MaxDistinct > 0 {
+		h.reservoir = newReservoir(opts.ReservoirSample)
+	}
+
+	h.misses = newMissCache(opts.MissCache)
+
+	h.deleted = nil
+	if opts.Tombstones {
+		h.deleted = newTombstoneSet()
+	}
+
+	h.seqs = nil
+	if opts.Sequences {
+		h.seqs = newMemberSequences()
+	}
+
+	h.changes = nil
+	if opts.TrackChanges {
+		h.changes = newMemberChanges()
+	}
+
+	h.lengths = nil
+	if opts.LengthFilter {
+		h.lengths = &lengthFilter{}
+	}
+
+	h.originals = nil
+	if opts.Normalize != nil {
+		h.originals = newOriginalForms()
+	}
+
+	h.largest = nil
+	if opts.TrackMaxMemberSize {
+		h.largest = &largestMember{}
+	}
+
+	h.rate = nil
+	if opts.ResizeHorizon > 0 {
+		h.rate = newInsertRate(opts.ResizeHorizon)
+	}
+
+	h.adaptive = nil
+	if opts.AdaptiveLoadFactor {
+		h.adaptive = newAdaptiveLoad()
+	}
+}
+
+// tracksElements reports whether opts keep structures over the elements, which a decoded set has to rebuild.
+func (opts Options) tracksElements() bool {
+	return opts.InsertionOrder || opts.Multiset || opts.TTL > 0 || opts.BloomBits > 0 || opts.TrackMaxMemberSize || opts.SlabBuckets
+}
+
+// initialCapacity returns the capacity a set created with opts starts with, and returns to on Clear.
+func (opts Options) initialCapacity() int {
+	if opts.Capacity > 0 {
+		return nextPowerOfTwo(opts.Capacity)
+	}
+	return initialCapacity
+}
+
+// newHashSet creates a new instance of HashSet with the given capacity, rounded up to a power of two, and seed.
+func newHashSet(capacity int, seed uint64) *HashSet {
+	capacity = nextPowerOfTwo(capacity) // Placement and resizes rely on a power of two capacity
 	return &HashSet{
-		Buckets:  make([][]interface{}, initialCapacity), // Initialize buckets
-		Capacity: initialCapacity,                        // Set initial capacity
+		Buckets:  make([][]interface{}, capacity), // Initialize buckets
+		Capacity: capacity,                        // Set initial capacity
+		Seed:     seed,                            // Set the seed
+		Hasher:   defaultHasher.ID(),              // Record the hasher
+		hasher:   defaultHasher,                   // Set the hasher
+	}
+}
+
+// deriveSeed derives a murmur seed from key.
+// A zero seed is never returned as it marks a payload encoded before seeds were persisted.
+func deriveSeed(key []byte) uint64 {
+	seed := hash64(key, defaultSeed)
+	if seed == 0 {
+		return defaultSeed
+	}
+	return seed
+}
+
+// nextPowerOfTwo returns the smallest power of two greater than or equal to n, capped at maxCapacity.
+func nextPowerOfTwo(n int) int {
+	capacity := 1
+	for capacity < n && capacity < maxCapacity {
+		capacity <<= 1
+	}
+	return capacity
+}
+
+// capacityFor returns the smallest power of two capacity that holds n elements
+// without crossing the load factor threshold.
+func capacityFor(n int) int {
+	return OptimalCapacity(n, loadFactorThreshold)
+}
+
+// OptimalCapacity returns the smallest power of two capacity holding n elements at or below loadFactor,
+// the capacity to pass to NewHashSetWithCapacity to add n elements without a resize.
+// A loadFactor outside (0, 1] is replaced by the default threshold of 0.7.
+func OptimalCapacity(n int, loadFactor float64) int {
+	if loadFactor <= 0 || loadFactor > 1 {
+		loadFactor = loadFactorThreshold
+	}
+
+	capacity := nextPowerOfTwo(n)
+	for float64(n)/float64(capacity) > loadFactor && capacity < maxCapacity {
+		capacity <<= 1
+	}
+
+	return capacity
+}
+
+// growCapacity returns the doubled capacity, or false if doubling would exceed maxCapacity.
+func growCapacity(capacity int) (int, bool) {
+	if capacity >= maxCapacity {
+		return capacity, false
+	}
+	return capacity * 2, true
+}
+
+// Generation returns a counter bumped on every mutation of the set, including resizes.
+// Comparing two readings tells whether the set changed in between.
+func (h *HashSet) Generation() uint64 {
+	return h.generation
+}
+
+// Frozen returns whether the set is read-only.
+func (h *HashSet) Frozen() bool {
+	return h.frozen
+}
+
+// notify reports a mutation to the OnMutate option before it is applied.
+func (h *HashSet) notify(op Op, value []byte) {
+	if h.opts.OnMutate != nil {
+		h.opts.OnMutate(op, value)
+	}
+}
+
+// checkMutable panics if the set is frozen.
+func (h *HashSet) checkMutable() {
+	if h.frozen {
+		panic("hashset: mutation of frozen set")
 	}
 }
 
 // Hash function to compute the index for a given value.
 func (h *HashSet) hash(value []byte, capacity int) int {
-	return int(murmur.Hash64(value, 4) % uint64(capacity)) // Use murmur hash
+	return digestIndex(h.digest(value), capacity)
+}
+
+// hashIndex computes the bucket index of value for the given seed and capacity.
+func hashIndex(value []byte, seed uint64, capacity int) int {
+	return digestIndex(hash64(value, seed), capacity) // Use the hasher configured by the build tags, murmur unless nomurmur
+}
+
+// digestIndex computes the bucket index of a digest for the given capacity.
+func digestIndex(digest uint64, capacity int) int {
+	return int(digest % uint64(capacity))
+}
+
+// Digest returns the hash of value used to place it in the set.
+// The digest is valid for every set sharing the same seed and can be passed to ContainsDigest.
+func (h *HashSet) Digest(value []byte) uint64 {
+	return h.digest(h.key(value))
 }
 
-// Add inserts a new element into the set.
-func (h *HashSet) Add(value []byte) {
+// digest computes the hash of a value in its stored form.
+func (h *HashSet) digest(value []byte) uint64 {
+	return h.hasher.Hash64(value, h.Seed)
+}
 
-	index := h.hash(value, h.Capacity) // Compute the index
+// ContainsDigest checks if an element is in the set using a digest computed by Digest,
+// skipping the hash step. value is still compared to rule out collisions.
+func (h *HashSet) ContainsDigest(digest uint64, value []byte) bool {
+	if !h.bloom.mayContain(digest) {
+		return false // Definitely not present
+	}
+
+	value = h.key(value)
+	if _, i := h.locate(digest, value); i < 0 {
+		return false
+	}
+	h.access.hit(value)
+	return true
+}
+
+// Add inserts a new element into the set and reports whether it was added.
+// It returns ErrCapacityExceeded, leaving the set unchanged, if the element would exceed the MaxMemory
+// or MaxDistinct option or a full open addressing set has no bucket left for it, ErrEmptyValue for an empty value under the RejectEmpty option and ErrValueTooLarge
+// for a value longer than the MaxValueLen option.
+// Otherwise the empty value is a member like any other. nil and a zero length slice are the same value,
+// they compare equal and encode identically, so adding either adds the one empty member.
+func (h *HashSet) Add(value []byte) (bool, error) {
+	h.checkMutable()
+	if h.latency != nil {
+		defer h.latency.Add.observe(time.Now())
+	}
+
+	stored := h.key(value) // Compute the stored form
+	return h.addHashed(value, stored, h.digest(stored))
+}
+
+// addHashed is Add for a value whose stored form and digest are already computed.
+func (h *HashSet) addHashed(original, value []byte, digest uint64) (bool, error) {
+	index, i := h.locate(digest, value) // Compute the index
 
 	// Check if the element already exists
-	for _, item := range h.Buckets[index] {
-		if bytes.Equal(item.([]byte), value) {
-			return // Element already exists
+	if i >= 0 {
+		if h.counts != nil {
+			h.notify(OpAdd, value)
+			h.counts.add(value, 1) // Count one more occurrence
 		}
+		h.times.touch(value) // Refresh the element
+		return false, nil    // Element already exists
 	}
 
-	// Add the element to the set
-	h.Buckets[index] = append(h.Buckets[index], value)
+	if err := h.admit(value); err != nil {
+		return false, err
+	}
+
+	h.addNew(index, digest, value) // Add the element to the set
+	h.originals.add(value, original)
+	return true, nil
+}
+
+// TryAdd inserts value, returning only the error of Add for callers that do not need to know
+// whether value was new, such as ErrCapacityExceeded under the MaxMemory option.
+func (h *HashSet) TryAdd(value []byte) error {
+	_, err := h.Add(value)
+	return err
+}
+
+// AddLocate inserts value and returns the bucket and the position within it where the element is stored,
+// after any resize the insertion triggered, and whether it was new. The placement stays valid until the next
+// mutation, which may move elements, so it is only meant for structures kept in lockstep with the set.
+// It returns -1, -1 and false if Add refused the value, see ErrCapacityExceeded.
+func (h *HashSet) AddLocate(value []byte) (bucket int, pos int, added bool) {
+	added, err := h.Add(value)
+	if err != nil {
+		return -1, -1, false
+	}
+
+	stored := h.key(value)
+	bucket, pos = h.locate(h.digest(stored), stored)
+	return bucket, pos, added
+}
+
+// Intern returns the stored instance equal to value, adding value first if it is not in the set.
+// Callers can drop their copy and share the returned one, deduplicating the memory of equal values.
+// Values stored as a fingerprint, see the FingerprintThreshold option, or refused by the MaxMemory option
+// are not retained and value itself is returned.
+func (h *HashSet) Intern(value []byte) []byte {
+	h.checkMutable()
+
+	stored := h.key(value)               // Compute the stored form
+	digest := h.digest(stored)           // Compute the digest
+	index, i := h.locate(digest, stored) // Compute the index
+
+	if i >= 0 {
+		if len(stored) != len(value) {
+			return value // Only the fingerprint is stored
+		}
+		return h.Buckets[index][i].([]byte)
+	}
+
+	if h.admit(stored) == nil {
+		h.addNew(index, digest, stored)
+		h.originals.add(stored, value)
+	}
+	return value
+}
+
+// AddUnchecked inserts value without checking whether it is already in the set.
+//
+// WARNING: the caller must guarantee value is not in the set. Adding a duplicate stores it twice,
+// Size then overcounts and Remove only deletes one copy, leaving the set corrupt.
+// It is meant for trusted bulk loads such as rebuilding a set from its own dump, combined with Reserve.
+// It returns ErrCapacityExceeded, leaving the set unchanged, if the element would exceed the MaxMemory or
+// MaxDistinct option or a full open addressing set has no bucket left for it.
+func (h *HashSet) AddUnchecked(value []byte) error {
+	h.checkMutable()
+
+	stored := h.key(value)     // Compute the stored form
+	digest := h.digest(stored) // Compute the digest
+
+	if err := h.admit(stored); err != nil {
+		return err
+	}
+
+	h.addNew(h.vacant(h.home(digest)), digest, stored)
+	h.originals.add(stored, value)
+	return nil
+}
+
+// addNew adds a value known to be absent to the bucket at index.
+// With the BucketLimit option a full bucket first evicts its oldest element and the set never resizes.
+func (h *HashSet) addNew(index int, digest uint64, value []byte) {
+	if limit := h.opts.BucketLimit; limit > 0 {
+		if len(h.Buckets[index]) >= limit {
+			h.evictOldest(index)
+		}
+	}
+
+	h.notify(OpAdd, value)
+	h.insert(index, value)
+	h.misses.remove(value)
+	h.deleted.remove(value)
+	h.counts.add(value, 1)
+	h.times.touch(value)
+	h.seqs.add(value)
+	h.bloom.add(digest)
 	h.Size++ // Increment the size
 
-	// Resize if the load factor is too high
-	if float64(h.Size)/float64(h.Capacity) > loadFactorThreshold { // Load factor
+	h.rate.add()
+
+	// Resize if the load factor is too high, or a burst of inserts is about to make it too high
+	if h.opts.BucketLimit <= 0 && (float64(h.Size)/float64(h.Capacity) > h.ResizeThreshold() || h.rate.burst(h.Size, h.Capacity)) {
 		h.resize() // Resize the hash set
 	}
+	h.splitBuckets(h.opts.IncrementalResize)
+	h.adapt(false)
+}
+
+// evictOldest removes the oldest element of the bucket at index and reports it to the OnEvict option.
+func (h *HashSet) evictOldest(index int) {
+	oldest := h.Buckets[index][0].([]byte) // Buckets keep elements in insertion order unless sorted
+	h.notify(OpRemove, oldest)
+	h.removeAt(index, 0)
+	h.Size--
+
+	if h.opts.OnEvict != nil {
+		h.opts.OnEvict(oldest)
+	}
+}
+
+// find returns the position of value, of the given digest, within the bucket at index, or -1 if it is not present.
+func (h *HashSet) find(index int, digest uint64, value []byte) int {
+	i, _ := h.findWithin(index, digest, value, -1)
+	return i
+}
+
+// findWithin is find comparing at most maxProbes elements, or every element if maxProbes is negative.
+// It returns -1 and true if it gave up before ruling value out.
+func (h *HashSet) findWithin(index int, digest uint64, value []byte, maxProbes int) (int, bool) {
+	if h.opts.SortedBuckets {
+		return h.searchWithin(index, value, maxProbes)
+	}
+
+	if positions, ok := h.secondaryPositions(index, value); ok {
+		for probes, i := range positions { // Only scan the elements sharing the secondary hash
+			if probes == maxProbes {
+				return -1, true
+			}
+			if bytes.Equal(h.Buckets[index][i].([]byte), value) {
+				return i, false
+			}
+		}
+		return -1, false
+	}
+
+	if tags, ok := h.tags[index]; ok {
+		tag, probes := chainTag(digest), 0
+		for i, t := range tags { // Only compare the elements sharing the tag
+			if t != tag {
+				continue
+			}
+			if probes == maxProbes {
+				return -1, true
+			}
+			probes++
+			if bytes.Equal(h.Buckets[index][i].([]byte), value) {
+				return i, false
+			}
+		}
+		return -1, false
+	}
+
+	for i, item := range h.Buckets[index] {
+		if i == maxProbes {
+			return -1, true
+		}
+		if bytes.Equal(item.([]byte), value) {
+			return i, false
+		}
+	}
+	return -1, false
+}
+
+// searchWithin is findWithin for sorted buckets, binary searching the bucket at index.
+func (h *HashSet) searchWithin(index int, value []byte, maxProbes int) (int, bool) {
+	bucket := h.Buckets[index]
+	lo, hi := 0, len(bucket)
+	for probes := 0; lo < hi; probes++ {
+		if probes == maxProbes {
+			return -1, true
+		}
+
+		mid := int(uint(lo+hi) >> 1)
+		switch c := bytes.Compare(bucket[mid].([]byte), value); {
+		case c == 0:
+			return mid, false
+		case c < 0:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return -1, false
+}
+
+// compareItems orders bucket elements by bytes.Compare.
+func compareItems(a, b interface{}) int {
+	return bytes.Compare(a.([]byte), b.([]byte))
+}
+
+// insert appends value to the bucket at index, or inserts it in sorted position with the SortedBuckets option.
+func (h *HashSet) insert(index int, value []byte) {
+	h.own(index)
+	if h.Buckets[index] == nil {
+		h.Buckets[index] = h.newBucket() // Preallocate on first insert
+	}
+	h.Buckets[index] = h.growBucket(h.Buckets[index], false) // Owned, so the full bucket can be freed
+	if h.opts.SortedBuckets {
+		i, _ := slices.BinarySearchFunc(h.Buckets[index], interface{}(value), compareItems)
+		h.Buckets[index] = slices.Insert(h.Buckets[index], i, interface{}(value))
+	} else {
+		h.Buckets[index] = append(h.Buckets[index], value)
+	}
+	h.memory += elementCost(value)
+	h.toggleContent(value)
+	h.largest.add(value)
+	h.lengths.add(value)
+	h.secondaryInsert(index, value)
+	h.chainTagInsert(index, value)
+	h.order.insert(value)
+	h.changes.add(value, h.generation+1)
+	h.generation++
+}
+
+// removeAt removes the element at position i from the bucket at index.
+func (h *HashSet) removeAt(index, i int) {
+	h.own(index)
+	h.order.remove(h.Buckets[index][i].([]byte))
+	h.access.remove(h.Buckets[index][i].([]byte))
+	h.counts.remove(h.Buckets[index][i].([]byte))
+	h.times.remove(h.Buckets[index][i].([]byte))
+	h.seqs.remove(h.Buckets[index][i].([]byte))
+	h.changes.remove(h.Buckets[index][i].([]byte), h.generation+1)
+	h.originals.remove(h.Buckets[index][i].([]byte))
+	h.largest.remove(h.Buckets[index][i].([]byte))
+	h.lengths.remove(h.Buckets[index][i].([]byte))
+	h.memory -= elementCost(h.Buckets[index][i].([]byte))
+	h.toggleContent(h.Buckets[index][i].([]byte))
+	h.Buckets[index] = append(h.Buckets[index][:i], h.Buckets[index][i+1:]...) // Remove the element
+	if len(h.Buckets[index]) == 0 && h.recycles() {
+		h.releaseBucket(h.Buckets[index]) // Return the emptied bucket to the pool
+		h.Buckets[index] = nil
+	}
+	h.secondaryRemove(index)
+	h.chainTagRemove(index, i)
+	h.backshift(index)
+	h.generation++
 }
 
 // Resize increases the capacity of the hash set.
 func (h *HashSet) resize() {
-	newCapacity := h.Capacity * 2                    // new capacity
-	newBuckets := make([][]interface{}, newCapacity) // new buckets
+	newCapacity, ok := growCapacity(h.Capacity) // new capacity
+	if !ok {
+		return // At the maximum capacity, let the chains grow
+	}
 
-	for _, bucket := range h.Buckets {
+	if h.growIncrementally(newCapacity) {
+		return // The elements move over the next mutations
+	}
+	h.rehash(newCapacity)
+}
+
+// rehash moves every element into a new bucket array of the given capacity.
+// Old buckets are walked in index order and each bucket front to back, so elements sharing a new bucket
+// keep their relative order and identical operation histories always produce identical buckets.
+func (h *HashSet) rehash(newCapacity int) {
+	h.rehashContext(context.Background(), newCapacity) // Never cancelled
+}
+
+// rehashContext is rehash giving up when ctx is done, checking it every rehashCheckInterval elements.
+// The set is only modified once every element is placed, a cancelled rehash leaves it as it was.
+func (h *HashSet) rehashContext(ctx context.Context, newCapacity int) error {
+	newCapacity = nextPowerOfTwo(newCapacity) // Keep the capacity a power of two whatever the caller computed
+	newBuckets := h.makeBuckets(newCapacity)  // new buckets
+	bloom := h.bloom.empty()                  // Rebuilt without the bits of removed elements
+	done := ctx.Done()
+	progress := h.opts.OnResizeProgress
+	placed := 0
+
+	for i, bucket := range h.Buckets {
+		if progress != nil && i%progressInterval == 0 && i > 0 {
+			progress(i, len(h.Buckets))
+		}
 		for _, value := range bucket {
-			newIndex := h.hash(value.([]byte), newCapacity)            // Compute the new index
-			newBuckets[newIndex] = append(newBuckets[newIndex], value) // Add the value
+			if placed++; done != nil && placed%rehashCheckInterval == 0 {
+				select {
+				case <-done:
+					for _, bucket := range newBuckets {
+						h.releaseBucket(bucket)
+					}
+					return ctx.Err()
+				default:
+				}
+			}
+
+			digest := h.digest(value.([]byte))
+			bloom.add(digest)
+			newIndex := digestIndex(digest, newCapacity) // Compute the new index
+			if h.opts.Strategy == OpenAddressing {
+				for len(newBuckets[newIndex]) != 0 {
+					newIndex = (newIndex + 1) % newCapacity // Probe for a free bucket
+				}
+			}
+			if newBuckets[newIndex] == nil {
+				newBuckets[newIndex] = h.newBucket()
+			}
+			newBuckets[newIndex] = append(h.growBucket(newBuckets[newIndex], false), value) // Add the value
 		}
 	}
 
-	h.Buckets = newBuckets // Update the buckets
-	h.Capacity = newCapacity // Update the capacity
+	if progress != nil {
+		progress(len(h.Buckets), len(h.Buckets))
+	}
+
+	for i, bucket := range h.Buckets {
+		if !h.isShared(i) {
+			h.releaseBucket(bucket) // Every element moved to the new buckets
+		}
+	}
+
+	if h.opts.SortedBuckets {
+		for _, bucket := range newBuckets {
+			slices.SortFunc(bucket, compareItems) // Merged from several old buckets unless the capacity doubled
+		}
+	}
+
+	h.Buckets = newBuckets         // Update the buckets
+	h.Capacity = newCapacity       // Update the capacity
+	h.bloom = bloom                // Update the summary bloom
+	h.shared = nil                 // The new buckets are not shared
+	h.growth = incrementalGrowth{} // Every element moved to its bucket
+	h.secondaryRebuild()           // Re-index the long chains
+	h.chainTagRebuild()            // Re-tag the long chains
+	h.generation++
+	return nil
+}
+
+// ResizeContext rehashes the set once to capacity rounded up to a power of two, like Reserve does for a
+// number of elements, abandoning the rehash if ctx is done first. A cancelled resize returns the error of ctx
+// and leaves the set in its previous, valid configuration, so shutting down need not wait for a resize of
+// a very large set. Resizes triggered by Add are not cancellable, calling ResizeContext when
+// WillResizeOnAdd reports true moves them under a context.
+func (h *HashSet) ResizeContext(ctx context.Context, capacity int) error {
+	h.checkMutable()
+
+	if capacity <= 0 || capacity > maxCapacity {
+		return wrapf(ErrInvalidOption, "hashset: capacity %d outside (0, %d]", capacity, maxCapacity)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if capacity = nextPowerOfTwo(capacity); capacity != h.Capacity {
+		return h.rehashContext(ctx, capacity)
+	}
+	return nil
+}
+
+// reserve grows the set once so that n elements fit under the load factor threshold.
+// A set with the BucketLimit option never resizes, nothing is reserved. Nor is it under the MaxMemory option,
+// where the reserved bucket array would count against the limit before any element is admitted, and n is
+// capped by the MaxDistinct option, the most elements the set can hold.
+func (h *HashSet) reserve(n int) {
+	if h.opts.BucketLimit > 0 || h.opts.MaxMemory > 0 {
+		return
+	}
+	if h.opts.MaxDistinct > 0 {
+		n = min(n, h.opts.MaxDistinct)
+	}
+	if capacity := capacityFor(n); capacity > h.Capacity {
+		h.rehash(capacity)
+	}
+}
+
+// Reserve grows the set once so that n elements fit without a resize.
+// It does nothing under the BucketLimit and MaxMemory options and reserves for at most MaxDistinct elements.
+func (h *HashSet) Reserve(n int) {
+	h.checkMutable()
+	h.reserve(n)
+}
+
+// WillResizeOnAdd reports whether adding one new element would cross the load factor threshold and resize the set.
+func (h *HashSet) WillResizeOnAdd() bool {
+	if h.Capacity >= maxCapacity {
+		return false // The set no longer resizes
+	}
+	return float64(h.Size+1)/float64(h.Capacity) > h.ResizeThreshold()
 }
 
 // Remove deletes an element from the set.
 func (h *HashSet) Remove(value []byte) {
-	index := h.hash(value, h.Capacity) // Compute the index
+	h.checkMutable()
+	if h.latency != nil {
+		defer h.latency.Remove.observe(time.Now())
+	}
+	value = h.key(value)      // Compute the stored form
+	digest := h.digest(value) // Compute the digest
+	if !h.bloom.mayContain(digest) {
+		h.deleted.add(value) // A delete is recorded even if the key lives in another level
+		return               // Definitely not present
+	}
+	index, i := h.locate(digest, value) // Compute the index
 
 	// Find the element and remove it
-	for i, item := range h.Buckets[index] {
-		if bytes.Equal(item.([]byte), value) { // Element found
-			h.Buckets[index] = append(h.Buckets[index][:i], h.Buckets[index][i+1:]...) // Remove the element
-			h.Size-- // Decrement the size
+	if i >= 0 { // Element found
+		h.notify(OpRemove, value)
+		if h.counts.get(value) > 1 {
+			h.counts.add(value, -1) // Drop one occurrence
 			return
 		}
+		h.removeAt(index, i) // Remove the element
+		h.Size--             // Decrement the size
+		h.splitBuckets(h.opts.IncrementalResize)
+		h.adapt(false)
 	}
+	h.deleted.add(value)
 }
 
-// Contains checks if an element is in the set.
+// IterRemove visits every element once and removes those for which fn returns true.
+// Elements are removed in place during the traversal without re-hashing them.
+func (h *HashSet) IterRemove(fn func(value []byte) bool) {
+	h.checkMutable()
+	if h.opts.Strategy == OpenAddressing || h.shared != nil {
+		h.iterRemoveCollected(fn) // Buckets cannot be compacted in place
+		return
+	}
+
+	for index, bucket := range h.Buckets {
+		kept := bucket[:0]
+		for _, item := range bucket {
+			if fn(item.([]byte)) {
+				h.notify(OpRemove, item.([]byte))
+				h.order.remove(item.([]byte))
+				h.access.remove(item.([]byte))
+				h.counts.remove(item.([]byte))
+				h.times.remove(item.([]byte))
+				h.seqs.remove(item.([]byte))
+				h.changes.remove(item.([]byte), h.generation+1)
+				h.originals.remove(item.([]byte))
+				h.largest.remove(item.([]byte))
+				h.lengths.remove(item.([]byte))
+				h.memory -= elementCost(item.([]byte))
+				h.toggleContent(item.([]byte))
+				h.Size-- // Decrement the size
+				h.generation++
+				continue
+			}
+			kept = append(kept, item)
+		}
+
+		if len(kept) != len(bucket) {
+			clear(bucket[len(kept):]) // Drop references to removed elements
+			h.Buckets[index] = kept
+			if len(kept) == 0 && h.recycles() {
+				h.releaseBucket(kept) // Return the emptied bucket to the pool
+				h.Buckets[index] = nil
+			}
+			h.secondaryRemove(index)
+			h.chainTagReindex(index)
+		}
+	}
+}
+
+// iterRemoveCollected is IterRemove collecting the elements to remove before removing any, for buckets
+// that cannot be compacted during the traversal. Under open addressing removal shifts elements back into
+// buckets already visited, and buckets shared with a clone must be copied before they change, see Clone.
+func (h *HashSet) iterRemoveCollected(fn func(value []byte) bool) {
+	var removed [][]byte
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			if fn(item.([]byte)) {
+				removed = append(removed, item.([]byte))
+			}
+		}
+	}
+
+	for _, value := range removed {
+		index, i := h.locate(h.digest(value), value)
+		h.notify(OpRemove, value)
+		h.removeAt(index, i)
+		h.Size-- // Decrement the size
+	}
+}
+
+// RemoveIfAndShrink removes the elements for which fn returns true, then shrinks the capacity
+// to fit the remaining elements under the load factor threshold in a single rehash.
+// It returns the number of elements removed and whether the capacity shrank.
+func (h *HashSet) RemoveIfAndShrink(fn func(value []byte) bool) (int, bool) {
+	before := h.Size
+	h.IterRemove(fn)
+	removed := before - h.Size
+
+	capacity := max(capacityFor(h.Size), initialCapacity)
+	if capacity >= h.Capacity {
+		return removed, false // Already fits
+	}
+
+	h.rehash(capacity)
+	return removed, true
+}
+
+// Canonicalize rewrites every element through fn and rebuilds the set from the results, merging the elements
+// that collapse to the same form, and returns the number of merges. fn receives elements in stored form and must
+// not retain or modify them. It is a one-shot cleanup: the set is rebuilt as by Clear and Add in ForEach order,
+// so state kept per element by the options, such as counts and insert times, starts over.
+func (h *HashSet) Canonicalize(fn func(value []byte) []byte) int {
+	h.checkMutable()
+
+	values := h.ToSlice()
+	h.Clear()
+	h.reserve(len(values))
+	for _, value := range values {
+		h.Add(fn(value))
+	}
+	return len(values) - h.Size
+}
+
+// RehashToLoadFactor rehashes once to the smallest power of two capacity holding the elements at or below target,
+// growing or shrinking the set. target must be in (0, 1). A target above the load factor threshold of 0.7
+// is kept until the next add crosses the threshold and doubles the capacity.
+func (h *HashSet) RehashToLoadFactor(target float64) error {
+	h.checkMutable()
+
+	if !(target > 0 && target < 1) {
+		return wrapf(ErrInvalidOption, "hashset: load factor %v outside (0, 1)", target)
+	}
+
+	if capacity := OptimalCapacity(h.Size, target); capacity != h.Capacity {
+		h.rehash(capacity)
+	}
+	return nil
+}
+
+// TrimToFit shrinks the set in a single rehash to the smallest power of two capacity holding the elements
+// at or below loadFactor, in (0, 1]. It is RemoveIfAndShrink without the removal and with a chosen tightness,
+// it never grows the set nor shrinks it below the initial capacity. A loadFactor above the resize threshold
+// is kept until the next add crosses the threshold and doubles the capacity.
+func (h *HashSet) TrimToFit(loadFactor float64) error {
+	h.checkMutable()
+
+	if !(loadFactor > 0 && loadFactor <= 1) {
+		return wrapf(ErrInvalidOption, "hashset: load factor %v outside (0, 1]", loadFactor)
+	}
+
+	if capacity := max(OptimalCapacity(h.Size, loadFactor), initialCapacity); capacity < h.Capacity {
+		h.rehash(capacity)
+	}
+	return nil
+}
+
+// Swap exchanges the contents and options of the two sets in O(1), for double buffered sets.
+// It panics if either set is frozen.
+func (h *HashSet) Swap(other *HashSet) {
+	h.checkMutable()
+	other.checkMutable()
+	if h == other {
+		return
+	}
+
+	*h, *other = *other, *h
+	generation := max(h.generation, other.generation) + 1 // Both sets changed
+	h.generation, other.generation = generation, generation
+}
+
+// Contains checks if an element is in the set. A nil set contains nothing.
 func (h *HashSet) Contains(value []byte) bool {
-	index := h.hash(value, h.Capacity) // Compute the index
-	for _, item := range h.Buckets[index] { // Check if the element exists
-		if bytes.Equal(item.([]byte), value) { // Element found
-			return true // Element exists
+	if h == nil {
+		return false
+	}
+	if h.latency != nil {
+		defer h.latency.Contains.observe(time.Now())
+	}
+	h.adapt(true)
+	if h.legacy != nil && h.legacy.Check(value) {
+		return true // Maybe a key of the migrated filter
+	}
+	stored := h.key(value) // Compute the stored form
+	if h.misses.has(stored) {
+		h.probes.observe(false, 0)
+		return h.promote(value) // Missed recently and not added since
+	}
+	found := h.has(stored)
+	if h.probes != nil {
+		h.probes.observe(found, h.comparisons(stored))
+	}
+	if !found {
+		h.misses.add(stored)
+		return h.promote(value)
+	}
+	if h.times.expired(stored) {
+		return h.promote(value)
+	}
+	h.access.hit(stored)
+	return true
+}
+
+// Len returns the number of elements in the set, 0 for a nil set.
+func (h *HashSet) Len() int {
+	if h == nil {
+		return 0
+	}
+	return h.Size
+}
+
+// IsEmpty reports whether the set has no elements, true for a nil set.
+func (h *HashSet) IsEmpty() bool {
+	return h.Len() == 0
+}
+
+// ContainsWithin checks if an element is in the set comparing at most maxProbes elements of its bucket.
+// exhausted is true when the budget ran out before the bucket was fully scanned, found is then false
+// and the answer unknown, letting callers with a latency budget fall back to an authoritative check.
+// Under the OpenAddressing strategy every probed bucket counts as one comparison.
+func (h *HashSet) ContainsWithin(value []byte, maxProbes int) (found bool, exhausted bool) {
+	value = h.key(value)      // Compute the stored form
+	digest := h.digest(value) // Compute the digest
+	if !h.bloom.mayContain(digest) {
+		return false, false // Definitely not present
+	}
+
+	index := h.home(digest)
+	var i int
+	if h.opts.Strategy == OpenAddressing {
+		_, i, exhausted = h.probeWithin(index, value, max(maxProbes, 0))
+	} else {
+		i, exhausted = h.findWithin(index, digest, value, max(maxProbes, 0))
+	}
+	if i < 0 {
+		return false, exhausted
+	}
+	h.access.hit(value)
+	return true, false
+}
+
+// ContainsStringNoAlloc checks if the bytes of s are in the set without copying them to a byte slice.
+// Contains does not retain or modify the value, so viewing the string's memory directly is safe.
+func (h *HashSet) ContainsStringNoAlloc(s string) bool {
+	return h.Contains(unsafe.Slice(unsafe.StringData(s), len(s)))
+}
+
+// has checks if a value in its stored form is in the set, without counting the lookup.
+func (h *HashSet) has(value []byte) bool {
+	if !h.lengths.mayContain(len(value)) {
+		return false // No member has this length
+	}
+	digest := h.digest(value) // Compute the digest
+	if !h.bloom.mayContain(digest) {
+		return false // Definitely not present
+	}
+	_, i := h.locate(digest, value) // Compute the index
+	return i >= 0                   // Check if the element exists
+}
+
+// Fingerprint returns a hash of the contents of the set, independent of insertion order, capacity and seed.
+// Sets with the same elements have the same fingerprint, sets with different elements almost certainly differ.
+// It XORs the murmur hash of every element with a hash of the size, computing it is O(n), or O(1) under the
+// IncrementalFingerprint option.
+func (h *HashSet) Fingerprint() uint64 {
+	fp := h.contents
+	if !h.opts.IncrementalFingerprint {
+		fp = 0
+		for _, bucket := range h.Buckets {
+			for _, item := range bucket {
+				fp ^= contentHash(item.([]byte))
+			}
+		}
+	}
+	return fp ^ hash64(binary.LittleEndian.AppendUint64(nil, uint64(h.Size)), contentSeed)
+}
+
+// contentHash returns the hash of an element combined by Fingerprint.
+func contentHash(value []byte) uint64 {
+	return hash64(value, contentSeed)
+}
+
+// toggleContent adds an element to the incremental fingerprint, or removes it again, XOR being its own inverse.
+func (h *HashSet) toggleContent(value []byte) {
+	if h.opts.IncrementalFingerprint {
+		h.contents ^= contentHash(value)
+	}
+}
+
+// ContainsPrefix checks if any element in the set starts with prefix.
+// The set is unordered so every bucket may have to be scanned.
+func (h *HashSet) ContainsPrefix(prefix []byte) bool {
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			if bytes.HasPrefix(item.([]byte), prefix) {
+				return true // Stop on the first match
+			}
 		}
 	}
 	return false
 }
 
-// Clear removes all elements from the set.
+// MembersWithPrefix returns all elements in the set that start with prefix.
+func (h *HashSet) MembersWithPrefix(prefix []byte) [][]byte {
+	members := make([][]byte, 0)
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			if bytes.HasPrefix(item.([]byte), prefix) {
+				members = append(members, item.([]byte))
+			}
+		}
+	}
+	return members
+}
+
+// BucketSizes returns the number of elements in each bucket, in bucket index order.
+// It is a histogram of the hash distribution, ready to plot.
+func (h *HashSet) BucketSizes() []int {
+	sizes := make([]int, len(h.Buckets))
+	for i, bucket := range h.Buckets {
+		sizes[i] = len(bucket)
+	}
+	return sizes
+}
+
+// Collisions returns, for every bucket holding more than one element, the elements sharing it.
+// It is a diagnostic tool for examining the hash and seed, it scans and copies every collided bucket.
+func (h *HashSet) Collisions() map[int][][]byte {
+	collisions := make(map[int][][]byte)
+	for index, bucket := range h.Buckets {
+		if len(bucket) < 2 {
+			continue
+		}
+
+		members := make([][]byte, len(bucket))
+		for i, item := range bucket {
+			members[i] = item.([]byte)
+		}
+		collisions[index] = members
+	}
+	return collisions
+}
+
+// Clear removes all elements from the set and returns it to the capacity it was created with.
+// The seed, hasher and options are kept, so the cleared set places elements exactly as before.
 func (h *HashSet) Clear() {
-	h.Buckets = make([][]interface{}, initialCapacity) // Reset the buckets
-	h.Size = 0 // Reset the size
-	h.Capacity = initialCapacity // Reset the capacity
+	h.checkMutable()
+	h.notify(OpClear, nil)
+	for i, bucket := range h.Buckets {
+		if !h.isShared(i) {
+			h.releaseBucket(bucket)
+		}
+	}
+	h.changes.removeAll(h.generation + 1) // Every member counts as removed
+	capacity := h.opts.initialCapacity()
+	h.Buckets = h.makeBuckets(capacity) // Reset the buckets
+	h.Size = 0                          // Reset the size
+	h.memory = 0                        // Reset the element memory
+	h.contents = 0                      // Reset the incremental fingerprint
+	h.Capacity = capacity               // Reset the capacity
+	h.shared = nil                      // Stop sharing buckets with clones
+	h.borrowed = nil                    // Stop referencing the decoded buffer
+	h.growth = incrementalGrowth{}      // Drop the resize in progress
+	h.secondary = nil                   // Reset the secondary index
+	h.tags = nil                        // Reset the chain tags
+	h.order.clear()                     // Reset the insertion order
+	h.access.clear()                    // Reset the lookup counts
+	h.counts.clear()                    // Reset the occurrence counts
+	h.times.clear()                     // Reset the insert times
+	h.deleted.clear()                   // Reset the tombstones
+	h.seqs.clear()                      // Reset the sequence numbers
+	h.originals.clear()                 // Reset the original forms
+	h.largest.clear()                   // Reset the largest element
+	h.lengths.clear()                   // Reset the member lengths
+	h.bloom.reset()                     // Reset the summary bloom
+	h.generation++
+}
+
+// ClearSecure overwrites every stored element with zeros, then removes all elements keeping the capacity.
+// Elements are stored by reference, so the caller's slices passed to Add are zeroed too.
+// The InsertionOrder option keeps copies of the elements that cannot be zeroed.
+func (h *HashSet) ClearSecure() {
+	h.checkMutable()
+	h.notify(OpClear, nil)
+
+	if h.originals != nil {
+		for _, original := range h.originals.values {
+			clear(original) // Zero the forms the elements were added in
+		}
+	}
+	for i, bucket := range h.Buckets {
+		for _, item := range bucket {
+			if !h.borrows(item.([]byte)) {
+				clear(item.([]byte)) // Zero the element bytes
+			}
+		}
+		if !h.isShared(i) {
+			clear(bucket)           // Drop the element references
+			h.releaseBucket(bucket) // Release the bucket
+		}
+		h.Buckets[i] = nil
+	}
+	h.shared = nil
+	h.borrowed = nil
+
+	h.Size = 0        // Reset the size
+	h.memory = 0      // Reset the element memory
+	h.contents = 0    // Reset the incremental fingerprint
+	h.secondary = nil // Reset the secondary index
+	h.tags = nil      // Reset the chain tags
+	h.order.clear()   // Reset the insertion order
+	h.access.clear()  // Reset the lookup counts
+	h.counts.clear()  // Reset the occurrence counts
+	h.times.clear()   // Reset the insert times
+	h.deleted.clear() // Reset the tombstones
+	h.seqs.clear()    // Reset the sequence numbers
+	h.changes.clear() // Drop the changes, they hold copies of the elements
+	h.largest.clear() // Reset the largest element
+	h.lengths.clear() // Reset the member lengths
+	h.bloom.reset()   // Reset the summary bloom
+	h.generation++
+}
+
+// Elements are stored as interface values, which gob only decodes when their concrete type is registered.
+// gob registers []byte itself, registering it here keeps decoding independent of that detail.
+func init() {
+	gob.Register([]byte(nil))
+}
+
+// gobHashSet is the gob encoding of a HashSet, its exported fields and the options it was created with.
+// HashSet implements encoding.BinaryMarshaler which gob would otherwise prefer over the field encoding.
+type gobHashSet struct {
+	Buckets  [][]interface{}
+	Size     int
+	Capacity int
+	Seed     uint64
+	Hasher   uint8
+	Strategy Strategy
+	Options  *Options // Persisted options, nil in payloads encoded before options were recorded, see persistedOptions
+
+	HasherName string // Name of a hasher registered by RegisterHasher, looked up instead of the ID when set
 }
 
 // Serialize encodes the HashSet into a byte slice.
+// The options the set was created with are recorded, see Deserialize. Hooks, the Fallback set, the Allocator and
+// the Hasher, recorded by ID and by name for a hasher registered under a name, are not. A set with
+// a Normalize function fails to encode unless it was selected by NormalizerID or NormalizerName.
 func (h *HashSet) Serialize() ([]byte, error) {
+	return h.AppendSerialize(nil)
+}
+
+// AppendSerialize appends the encoding of Serialize to dst and returns the extended slice.
+// Reusing dst across calls avoids allocating a buffer for every encoding once it is large enough.
+// On error dst is returned unchanged.
+func (h *HashSet) AppendSerialize(dst []byte) ([]byte, error) {
+	opts, err := h.persistedOptions()
+	if err != nil {
+		return dst, err
+	}
+
+	encoded := gobHashSet{
+		Buckets:  h.Buckets,
+		Size:     h.Size,
+		Capacity: h.Capacity,
+		Seed:     h.Seed,
+		Hasher:   h.Hasher,
+		Strategy: h.Strategy,
+		Options:  &opts,
+
+		HasherName: hasherName(h.Hasher),
+	}
+	if h.growth.half > 0 {
+		encoded.Buckets = h.settledBuckets() // The payload has no resize in progress to finish
+	}
+
 	// We just use gob to encode the HashSet
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
-	err := enc.Encode(h)
+	buf := bytes.NewBuffer(dst)
+	enc := gob.NewEncoder(buf)
+	err = enc.Encode(&encoded)
 	if err != nil {
-		return nil, err
+		return dst, err
 	}
 	return buf.Bytes(), nil
 }
 
+// persistedOptions returns the options Serialize records, without the hooks, the Fallback set and the Hasher.
+// A Normalize function not selected by NormalizerID or NormalizerName cannot be recorded, the decoded set would
+// compare values differently.
+func (h *HashSet) persistedOptions() (Options, error) {
+	opts := h.opts
+	if opts.Normalize != nil && opts.NormalizerID == 0 && opts.NormalizerName == "" {
+		return Options{}, wrapf(ErrInvalidOption, "hashset: cannot serialize a Normalize function, register it with RegisterNormalizer and select it by NormalizerName")
+	}
+
+	opts.Hasher, opts.Fallback, opts.Normalize = nil, nil, nil
+	opts.OnEvict, opts.OnMutate, opts.OnResizeProgress = nil, nil, nil
+	opts.Allocator = nil
+	return opts, nil
+}
+
 // Deserialize decodes the byte slice into a HashSet.
+// The set is restored with the options recorded by Serialize, the state they keep starting afresh: insertion order
+// follows the buckets, multiset counts, access counts and TTLs restart and original forms are lost. It fails if
+// the payload references a hasher or normalizer not registered in this build, see RegisterHasher, RegisterNormalizer
+// and RegisterNormalizerID. A hasher or normalizer recorded by name is looked up by name.
+// Members stored twice in the same bucket by a corrupt payload are collapsed, see DeserializeRepaired.
 func Deserialize(data []byte) (*HashSet, error) {
+	h, _, err := DeserializeRepaired(data)
+	return h, err
+}
+
+// DeserializeRepaired is Deserialize also returning the number of duplicate members collapsed, see Validate.
+func DeserializeRepaired(data []byte) (*HashSet, int, error) {
+	return deserialize(data, nil)
+}
+
+// deserialize is DeserializeRepaired decoding the buckets into the backing arrays of buckets where they fit.
+func deserialize(data []byte, buckets [][]interface{}) (h *HashSet, collapsed int, err error) {
+	// Malformed input must never crash the caller
+	defer func() {
+		if r := recover(); r != nil {
+			h, collapsed, err = nil, 0, wrapf(ErrCorrupt, "corrupt hashset: %v", r)
+		}
+	}()
+
 	// We just use gob to decode the byte slice
-	var h HashSet
+	g := gobHashSet{Buckets: buckets}
 	buf := bytes.NewBuffer(data)
 	dec := gob.NewDecoder(buf)
-	err := dec.Decode(&h)
+	err = dec.Decode(&g)
+	if err != nil {
+		return nil, 0, wrapf(ErrCorrupt, "corrupt hashset: %w", err)
+	}
+
+	h = &HashSet{Buckets: g.Buckets, Size: g.Size, Capacity: g.Capacity, Seed: g.Seed, Hasher: g.Hasher, Strategy: g.Strategy}
+	h.opts = Options{Strategy: h.Strategy, SortedBuckets: h.Strategy == SortedChaining} // Lookups follow the placement
+
+	// Elements placed by another hasher would silently go missing
+	if g.HasherName != "" {
+		if h.hasher, err = lookupNamedHasher(g.HasherName); err != nil {
+			return nil, 0, err
+		}
+		h.Hasher = h.hasher.ID() // The name identifies the hasher, its ID may differ in this build
+	} else if h.hasher, err = lookupHasher(h.Hasher); err != nil {
+		return nil, 0, err
+	}
+
+	// Sets encoded before the seed was persisted used the default seed
+	if h.Seed == 0 {
+		h.Seed = defaultSeed
+	}
+
+	if collapsed, err = h.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	if g.Options != nil {
+		if err = g.Options.checkDecodedSizes(len(data), h.Capacity); err != nil {
+			return nil, 0, err
+		}
+		if h, err = h.restoreOptions(*g.Options); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	h.recountMemory()
+	return h, collapsed, nil
+}
+
+// restoreOptions applies the options recorded by Serialize to the decoded set. Options keeping structures over
+// the elements rebuild the set, adding every element again so the structures hold them.
+func (h *HashSet) restoreOptions(opts Options) (*HashSet, error) {
+	// Elements normalized by another function would silently go missing
+	if _, ok := lookupNormalizer(opts.NormalizerID); !ok && opts.NormalizerID != 0 {
+		return nil, wrapf(ErrUnsupportedVersion, "hashset encoded with normalizer %d, which is not registered in this build", opts.NormalizerID)
+	}
+	if _, ok := lookupNamedNormalizer(opts.NormalizerName); !ok && opts.NormalizerName != "" {
+		return nil, wrapf(ErrUnsupportedVersion, "hashset encoded with normalizer %q, which is not registered in this build", opts.NormalizerName)
+	}
+
+	opts.Strategy = h.Strategy // Lookups follow the placement
+	if err := opts.validate(); err != nil {
+		return nil, wrapf(ErrCorrupt, "corrupt hashset: %w", err)
+	}
+	opts.Normalize = opts.selectedNormalizer()
+
+	if opts.RejectEmpty && h.has(nil) {
+		return nil, wrapf(ErrCorrupt, "corrupt hashset: empty member under the RejectEmpty option")
+	}
+	if opts.MaxValueLen > 0 {
+		if size := h.MaxMemberSize(); size > opts.MaxValueLen {
+			return nil, wrapf(ErrCorrupt, "corrupt hashset: member of %d bytes exceeds MaxValueLen %d", size, opts.MaxValueLen)
+		}
+	}
+
+	if !opts.tracksElements() {
+		h.applyOptions(opts)
+		h.secondaryRebuild() // Lookups of long chains use the indexes from the start
+		h.chainTagRebuild()
+		return h, nil
+	}
+
+	restored := newHashSet(h.Capacity, h.Seed)
+	restored.Hasher, restored.hasher = h.Hasher, h.hasher
+	restored.applyOptions(opts)
+	if opts.SlabBuckets {
+		restored.Buckets = restored.makeBuckets(restored.Capacity)
+	}
+
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			if _, err := restored.Add(item.([]byte)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return restored, nil
+}
+
+// DeserializeInto replaces the contents of the set with the set encoded by Serialize in data, reusing the
+// bucket array and the bucket backing arrays where the decoded buckets fit in them, for reload loops that
+// would otherwise allocate a set per payload. The set ends up like the one Deserialize returns, options
+// included. On error the set is left empty. Buckets shared with a clone are not reused.
+func (h *HashSet) DeserializeInto(data []byte) error {
+	h.checkMutable()
+
+	var buckets [][]interface{}
+	if h.shared == nil {
+		buckets = h.Buckets[:cap(h.Buckets)]
+		for i, bucket := range buckets {
+			clear(bucket) // Drop the element references the decoded buckets do not overwrite
+			buckets[i] = bucket[:0]
+		}
+	}
+
+	decoded, _, err := deserialize(data, buckets)
+	if err != nil {
+		h.Clear()
+		return err
+	}
+	generation := h.generation
+	*h = *decoded
+	h.generation = generation + 1
+	return nil
+}
+
+// DeserializeCompact is Deserialize ignoring the capacity recorded in data. The decoded set is rehashed to
+// the smallest capacity holding its elements under the load factor threshold, membership is unaffected.
+func DeserializeCompact(data []byte) (*HashSet, error) {
+	h, err := Deserialize(data)
 	if err != nil {
 		return nil, err
 	}
-	return &h, nil
+
+	if capacity := max(capacityFor(h.Size), initialCapacity); capacity != h.Capacity {
+		h.rehash(capacity)
+	}
+	return h, nil
+}
+
+// DeserializeAt decodes the set serialized in the length bytes of r starting at offset, one of several sets
+// packed in a shared file. The range is checked against the size of r when r reports it, like *os.File,
+// bytes.Reader and io.SectionReader do, and a range past the end of r fails without decoding.
+func DeserializeAt(r io.ReaderAt, offset, length int64) (*HashSet, error) {
+	if offset < 0 || length < 0 || offset > offset+length {
+		return nil, wrapf(ErrInvalidOption, "hashset: invalid range of %d bytes at offset %d", length, offset)
+	}
+
+	size := int64(-1)
+	switch r := r.(type) {
+	case interface{ Size() int64 }:
+		size = r.Size()
+	case *os.File:
+		info, err := r.Stat()
+		if err != nil {
+			return nil, err
+		}
+		size = info.Size()
+	}
+	if size >= 0 && offset+length > size {
+		return nil, wrapf(ErrInvalidOption, "hashset: range of %d bytes at offset %d exceeds the %d bytes of the reader", length, offset, size)
+	}
+
+	// The buffer grows with the bytes actually read, so a bogus length of a reader without a size is not allocated
+	data, err := io.ReadAll(io.NewSectionReader(r, offset, length))
+	if err != nil {
+		return nil, fmt.Errorf("hashset: reading %d bytes at offset %d: %w", length, offset, err)
+	}
+	if int64(len(data)) != length {
+		return nil, fmt.Errorf("hashset: range of %d bytes at offset %d ends after %d bytes", length, offset, len(data))
+	}
+	return Deserialize(data)
+}
+
+// Validate checks the structural invariants of the set and collapses the members stored twice in the same
+// bucket, which Remove would only delete once, decrementing Size for each. It returns the number of
+// duplicates collapsed. A set built through Add never holds duplicates, they come from corrupt payloads
+// or misuse of AddUnchecked.
+func (h *HashSet) Validate() (int, error) {
+	if h.Capacity <= 0 || h.Capacity > maxCapacity || h.Capacity&(h.Capacity-1) != 0 {
+		return 0, wrapf(ErrCorrupt, "corrupt hashset: invalid capacity %d", h.Capacity)
+	}
+
+	if len(h.Buckets) != h.Capacity {
+		return 0, wrapf(ErrCorrupt, "corrupt hashset: %d buckets for capacity %d", len(h.Buckets), h.Capacity)
+	}
+
+	if h.Strategy > OpenAddressing {
+		return 0, wrapf(ErrCorrupt, "corrupt hashset: unknown strategy %d", h.Strategy)
+	}
+
+	count := 0
+	for _, bucket := range h.Buckets {
+		if h.Strategy == OpenAddressing && len(bucket) > 1 {
+			return 0, wrapf(ErrCorrupt, "corrupt hashset: %d elements in an open addressing bucket", len(bucket))
+		}
+		for _, item := range bucket {
+			if _, ok := item.([]byte); !ok {
+				return 0, wrapf(ErrCorrupt, "corrupt hashset: unexpected element type %T", item)
+			}
+			count++
+		}
+	}
+
+	if count != h.Size {
+		return 0, wrapf(ErrCorrupt, "corrupt hashset: size %d does not match %d elements", h.Size, count)
+	}
+	return h.collapseDuplicates(), nil
+}
+
+// collapseDuplicates removes the repeated members of every bucket, keeping the first, and returns how many
+// were removed. Short buckets are compared pairwise, long ones through a map of the members seen.
+func (h *HashSet) collapseDuplicates() int {
+	collapsed := 0
+	for index, bucket := range h.Buckets {
+		var kept []interface{}
+		var seen map[string]struct{}
+		if len(bucket) > secondaryThreshold {
+			seen = make(map[string]struct{}, len(bucket))
+		}
+
+		for i, item := range bucket {
+			value := item.([]byte)
+			duplicate := false
+			if seen != nil {
+				_, duplicate = seen[string(value)]
+				seen[string(value)] = struct{}{}
+			} else {
+				duplicate = slices.ContainsFunc(bucket[:i], func(other interface{}) bool {
+					return bytes.Equal(other.([]byte), value)
+				})
+			}
+
+			if duplicate {
+				if kept == nil {
+					kept = slices.Clone(bucket[:i]) // Copied on the first duplicate, the bucket may be shared
+				}
+				collapsed++
+				continue
+			}
+			if kept != nil {
+				kept = append(kept, item)
+			}
+		}
+		if kept != nil {
+			h.Buckets[index] = kept
+			if h.shared != nil {
+				h.shared[index] = false
+			}
+		}
+	}
+
+	if collapsed > 0 {
+		h.Size -= collapsed
+		h.recountMemory()
+		h.secondaryRebuild()
+		h.chainTagRebuild()
+		h.generation++
+	}
+	return collapsed
 }
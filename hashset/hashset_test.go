@@ -31,10 +31,19 @@
 package hashset
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/guycipher/k4/pager"
+	"io"
+	"math/rand/v2"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestHashSet_Add(t *testing.T) {
@@ -111,6 +120,38 @@ func TestHashSet_Clear(t *testing.T) {
 	}
 }
 
+func TestHashSet_ClearPreservesConfiguration(t *testing.T) {
+	set := mustHashSet(t, Options{Capacity: 100, InsertionOrder: true})
+	set.Seed = 1234
+	set.RehashWith(FNVHasher)
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	set.Clear()
+	if set.Capacity != 128 {
+		t.Errorf("Expected the configured capacity 128 after clear, got %d", set.Capacity)
+	}
+	if set.Seed != 1234 || set.Hasher != FNVHasher.ID() {
+		t.Errorf("Expected the seed and hasher to be kept, got seed %d and hasher %d", set.Seed, set.Hasher)
+	}
+
+	// The cleared set places elements exactly like a fresh one with the same configuration
+	fresh := mustHashSet(t, Options{Capacity: 100, InsertionOrder: true})
+	fresh.Seed = 1234
+	fresh.RehashWith(FNVHasher)
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("again%d", i)))
+		fresh.Add([]byte(fmt.Sprintf("again%d", i)))
+	}
+	if !reflect.DeepEqual(set.Buckets, fresh.Buckets) {
+		t.Errorf("Expected the cleared set to match a fresh set with the same configuration")
+	}
+	if !reflect.DeepEqual(set.ToSlice(), fresh.ToSlice()) {
+		t.Errorf("Expected the cleared set to keep recording insertion order")
+	}
+}
+
 func TestHashSetAddCheckManyValues(t *testing.T) {
 	set := NewHashSet()
 
@@ -156,6 +197,221 @@ func TestHashSet_SerializeDeserialize(t *testing.T) {
 	}
 }
 
+func TestHashSet_AppendSerialize(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 50; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	serialized, err := set.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 0, 2*len(serialized))
+	for i := 0; i < 3; i++ {
+		buf, err = set.AppendSerialize(buf[:0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf, serialized) {
+			t.Errorf("Expected the same bytes as Serialize")
+		}
+	}
+
+	header := []byte("header")
+	appended, err := set.AppendSerialize(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(appended[:len(header)], header) || !bytes.Equal(appended[len(header):], serialized) {
+		t.Errorf("Expected the encoding after the existing bytes")
+	}
+	if reused, _ := set.AppendSerialize(buf[:0]); &reused[0] != &buf[0] {
+		t.Errorf("Expected the encoding to reuse a large enough buffer")
+	}
+}
+
+func TestHashSet_DeserializeAt(t *testing.T) {
+	a, b := NewHashSet(), NewHashSet()
+	a.Add([]byte("a"))
+	b.Add([]byte("b1"))
+	b.Add([]byte("b2"))
+	first, err := a.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed, err := b.AppendSerialize(append([]byte(nil), first...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "sets")
+	if err := os.WriteFile(path, packed, 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	offset, length := int64(len(first)), int64(len(packed)-len(first))
+	for _, r := range []io.ReaderAt{file, bytes.NewReader(packed)} {
+		set, err := DeserializeAt(r, offset, length)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if set.Size != 2 || !set.Contains([]byte("b1")) || set.Contains([]byte("a")) {
+			t.Errorf("Expected only the second set")
+		}
+
+		if _, err := DeserializeAt(r, offset, length+1); err == nil {
+			t.Errorf("Expected an error for a range past the end")
+		}
+		if _, err := DeserializeAt(r, -1, length); err == nil {
+			t.Errorf("Expected an error for a negative offset")
+		}
+	}
+}
+
+func TestHashSet_DeserializeInto(t *testing.T) {
+	source := NewHashSet()
+	for i := 0; i < 10; i++ {
+		source.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	data, err := source.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := NewHashSet()
+	for i := 0; i < 20; i++ {
+		set.Add([]byte(fmt.Sprintf("stale%d", i)))
+	}
+	buckets := set.Buckets
+	generation := set.Generation()
+
+	if err := set.DeserializeInto(data); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if &set.Buckets[0] != &buckets[0] {
+		t.Errorf("Expected the bucket array to be reused")
+	}
+	if set.Size != 10 || set.Contains([]byte("stale0")) || !set.Contains([]byte("test9")) {
+		t.Errorf("Expected only the decoded elements, got %d", set.Size)
+	}
+	if set.Generation() <= generation {
+		t.Errorf("Expected the generation to advance")
+	}
+	if err := set.SelfTest(); err != nil {
+		t.Errorf("Expected a consistent set, got %v", err)
+	}
+
+	if err := set.DeserializeInto([]byte("corrupt")); err == nil || set.Size != 0 {
+		t.Errorf("Expected an error and an empty set, got %v and %d elements", err, set.Size)
+	}
+}
+
+func TestHashSet_DeserializeCollapsesDuplicates(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("test1"))
+	set.Add([]byte("test2"))
+	set.AddUnchecked([]byte("test1")) // Stored twice in the same bucket
+	for i := 0; i < 20; i++ {
+		set.AddUnchecked([]byte("long")) // A long chain of duplicates
+	}
+
+	serialized, err := set.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repaired, collapsed, err := DeserializeRepaired(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if collapsed != 20 || repaired.Size != 3 {
+		t.Errorf("Expected 20 duplicates collapsed into 3 elements, got %d and %d", collapsed, repaired.Size)
+	}
+
+	repaired.Remove([]byte("test1"))
+	repaired.Remove([]byte("long"))
+	if repaired.Contains([]byte("test1")) || repaired.Contains([]byte("long")) || repaired.Size != 1 {
+		t.Errorf("Expected a single Remove to delete a collapsed element")
+	}
+
+	if collapsed, err := set.Validate(); err != nil || collapsed != 20 || set.Size != 3 {
+		t.Errorf("Expected Validate to collapse 20 duplicates, got %d, %v", collapsed, err)
+	}
+}
+
+func TestHashSet_DeserializeOptionSizes(t *testing.T) {
+	oversized := map[string]func(opts *Options){
+		"BloomBits":       func(opts *Options) { opts.BloomBits = 1 << 37 },
+		"ReservoirSample": func(opts *Options) { opts.MaxDistinct, opts.ReservoirSample = 1, 1<<36 },
+		"MissCache":       func(opts *Options) { opts.MissCache = 1 << 40 },
+		"Capacity":        func(opts *Options) { opts.Capacity = 1 << 40 },
+		"BucketHint":      func(opts *Options) { opts.BucketHint, opts.SlabBuckets = 1<<30, true },
+	}
+	for name, corrupt := range oversized {
+		set := NewHashSet()
+		set.Add([]byte("test"))
+		corrupt(&set.opts) // As a payload crafted to allocate without bound would record it
+		data, err := set.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Deserialize(data); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("Expected ErrCorrupt for an oversized %s, got %v", name, err)
+		}
+	}
+
+	// Sizes in proportion to the payload still decode
+	set := mustHashSet(t, Options{BloomBits: 1 << 20, MissCache: 1024, MaxDistinct: 100, ReservoirSample: 10, Capacity: 1024})
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Deserialize(data); err != nil {
+		t.Errorf("Expected sizes in proportion to decode, got %v", err)
+	}
+}
+
+func TestHashSet_DeserializeFreshProcess(t *testing.T) {
+	// In the child process nothing but Deserialize has touched gob
+	if path := os.Getenv("HASHSET_FRESH_PAYLOAD"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		set, err := Deserialize(data)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !set.Contains([]byte("test1")) {
+			t.Errorf("Expected deserialized set to contain test1")
+		}
+		return
+	}
+
+	set := NewHashSet()
+	set.Add([]byte("test1"))
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "set.gob")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHashSet_DeserializeFreshProcess$")
+	cmd.Env = append(os.Environ(), "HASHSET_FRESH_PAYLOAD="+path)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("Expected the fresh process to deserialize the set: %v\n%s", err, out)
+	}
+}
+
 func TestHashSet_SerializeDeserialize_Pager(t *testing.T) {
 	defer os.Remove("hashset.test")
 
@@ -205,3 +461,1240 @@ func TestHashSet_SerializeDeserialize_Pager(t *testing.T) {
 		t.Errorf("Expected deserialized set size to be %d, got %d", set.Size, deserializedSet.Size)
 	}
 }
+
+func TestHashSet_ContainsPrefix(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("users:1"))
+	set.Add([]byte("users:2"))
+	set.Add([]byte("orders:1"))
+
+	if !set.ContainsPrefix([]byte("users:")) {
+		t.Errorf("Expected set to contain prefix users:")
+	}
+
+	if set.ContainsPrefix([]byte("items:")) {
+		t.Errorf("Expected set to not contain prefix items:")
+	}
+}
+
+func TestHashSet_MembersWithPrefix(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("users:1"))
+	set.Add([]byte("users:2"))
+	set.Add([]byte("orders:1"))
+
+	members := set.MembersWithPrefix([]byte("users:"))
+	if len(members) != 2 {
+		t.Errorf("Expected 2 members with prefix users:, got %d", len(members))
+	}
+
+	members = set.MembersWithPrefix([]byte("items:"))
+	if len(members) != 0 {
+		t.Errorf("Expected 0 members with prefix items:, got %d", len(members))
+	}
+}
+
+func TestHashSet_GrowCapacity(t *testing.T) {
+	capacity, ok := growCapacity(initialCapacity)
+	if !ok || capacity != initialCapacity*2 {
+		t.Errorf("Expected capacity to grow to %d, got %d", initialCapacity*2, capacity)
+	}
+
+	capacity, ok = growCapacity(maxCapacity / 2)
+	if !ok || capacity != maxCapacity {
+		t.Errorf("Expected capacity to grow to %d, got %d", maxCapacity, capacity)
+	}
+
+	capacity, ok = growCapacity(maxCapacity)
+	if ok || capacity != maxCapacity {
+		t.Errorf("Expected capacity to stay at %d, got %d", maxCapacity, capacity)
+	}
+
+	if capacity*2 > 0 {
+		// Doubling past the maximum must overflow, otherwise the cap is too low
+		t.Errorf("Expected doubling the maximum capacity to overflow")
+	}
+}
+
+func TestHashSet_CapacityForMax(t *testing.T) {
+	if capacity := capacityFor(maxCapacity); capacity != maxCapacity {
+		t.Errorf("Expected capacity to be capped at %d, got %d", maxCapacity, capacity)
+	}
+}
+
+func TestNewHashSetKeyed(t *testing.T) {
+	a := NewHashSetKeyed([]byte("tenant1"))
+	b := NewHashSetKeyed([]byte("tenant1"))
+	c := NewHashSetKeyed([]byte("tenant2"))
+
+	if a.Seed != b.Seed {
+		t.Errorf("Expected sets with the same key to share a seed")
+	}
+
+	if a.Seed == c.Seed {
+		t.Errorf("Expected sets with different keys to have different seeds")
+	}
+
+	value := []byte("test")
+	a.Add(value)
+	if !a.Contains(value) {
+		t.Errorf("Expected set to contain %v", value)
+	}
+
+	serialized, err := a.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deserializedSet, err := Deserialize(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if deserializedSet.Seed != a.Seed {
+		t.Errorf("Expected deserialized seed to be %d, got %d", a.Seed, deserializedSet.Seed)
+	}
+
+	if !deserializedSet.Contains(value) {
+		t.Errorf("Expected deserialized set to contain %v", value)
+	}
+}
+
+func TestHashSet_IterRemove(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	visited := 0
+	set.IterRemove(func(value []byte) bool {
+		visited++
+		var i int
+		fmt.Sscanf(string(value), "test%d", &i)
+		return i%2 == 0
+	})
+
+	if visited != 1000 {
+		t.Errorf("Expected 1000 elements to be visited, got %d", visited)
+	}
+
+	if set.Size != 500 {
+		t.Errorf("Expected size to be 500, got %d", set.Size)
+	}
+
+	for i := 0; i < 1000; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		if set.Contains(value) != (i%2 == 1) {
+			t.Errorf("Unexpected membership for %v", value)
+		}
+	}
+}
+
+func TestHashSet_BucketHint(t *testing.T) {
+	set := mustHashSet(t, Options{BucketHint: 4})
+	value := []byte("test")
+
+	set.Add(value)
+	index := set.hash(value, set.Capacity)
+	if cap(set.Buckets[index]) != 4 {
+		t.Errorf("Expected bucket capacity to be 4, got %d", cap(set.Buckets[index]))
+	}
+
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	for _, bucket := range set.Buckets {
+		if bucket != nil && cap(bucket) < 4 {
+			t.Errorf("Expected resized bucket capacity to be at least 4, got %d", cap(bucket))
+		}
+	}
+
+	if !set.Contains(value) {
+		t.Errorf("Expected set to contain %v", value)
+	}
+}
+
+func TestHashSet_Generation(t *testing.T) {
+	set := NewHashSet()
+	value := []byte("test")
+
+	gen := set.Generation()
+	set.Add(value)
+	if set.Generation() <= gen {
+		t.Errorf("Expected generation to increase on Add")
+	}
+
+	gen = set.Generation()
+	set.Add(value) // Already present
+	if set.Generation() != gen {
+		t.Errorf("Expected generation to be unchanged when nothing was added")
+	}
+
+	gen = set.Generation()
+	set.Remove(value)
+	if set.Generation() <= gen {
+		t.Errorf("Expected generation to increase on Remove")
+	}
+
+	gen = set.Generation()
+	set.Clear()
+	if set.Generation() <= gen {
+		t.Errorf("Expected generation to increase on Clear")
+	}
+
+	gen = set.Generation()
+	set.resize()
+	if set.Generation() <= gen {
+		t.Errorf("Expected generation to increase on resize")
+	}
+}
+
+func TestHashSet_ContainsDigest(t *testing.T) {
+	sets := []*HashSet{NewHashSet(), NewHashSet(), NewHashSet()}
+	for i := 0; i < 1000; i++ {
+		sets[i%3].Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	value := []byte("test4")
+	digest := sets[0].Digest(value)
+
+	found := 0
+	for _, set := range sets {
+		if set.ContainsDigest(digest, value) {
+			found++
+		}
+	}
+
+	if found != 1 {
+		t.Errorf("Expected value to be found in exactly one set, got %d", found)
+	}
+
+	if !sets[1].ContainsDigest(digest, value) {
+		t.Errorf("Expected set 1 to contain %v", value)
+	}
+
+	if sets[1].ContainsDigest(digest, []byte("other")) {
+		t.Errorf("Expected a mismatched value to not be found")
+	}
+}
+
+func TestHashSet_ClearSecure(t *testing.T) {
+	set := NewHashSet()
+
+	values := make([][]byte, 0)
+	for i := 0; i < 100; i++ {
+		value := []byte(fmt.Sprintf("secret%d", i))
+		values = append(values, value)
+		set.Add(value)
+	}
+	capacity := set.Capacity
+
+	set.ClearSecure()
+
+	if set.Size != 0 {
+		t.Errorf("Expected size to be 0 after clear, got %d", set.Size)
+	}
+
+	if set.Capacity != capacity {
+		t.Errorf("Expected capacity to be preserved at %d, got %d", capacity, set.Capacity)
+	}
+
+	for _, value := range values {
+		for _, b := range value {
+			if b != 0 {
+				t.Fatalf("Expected stored bytes to be zeroed, got %v", value)
+			}
+		}
+	}
+
+	set.Add([]byte("test"))
+	if !set.Contains([]byte("test")) {
+		t.Errorf("Expected set to be usable after a secure clear")
+	}
+}
+
+func TestHashSet_OnMutate(t *testing.T) {
+	type entry struct {
+		op    Op
+		value string
+	}
+
+	log := make([]entry, 0)
+	set := mustHashSet(t, Options{OnMutate: func(op Op, value []byte) {
+		log = append(log, entry{op, string(value)})
+	}})
+
+	set.Add([]byte("a"))
+	set.Add([]byte("b"))
+	set.Add([]byte("a")) // Already present, nothing to log
+	set.Remove([]byte("a"))
+	set.Remove([]byte("c")) // Not present, nothing to log
+	set.Clear()
+	set.Add([]byte("d"))
+
+	expected := []entry{{OpAdd, "a"}, {OpAdd, "b"}, {OpRemove, "a"}, {OpClear, ""}, {OpAdd, "d"}}
+	if len(log) != len(expected) {
+		t.Fatalf("Expected %d logged mutations, got %d", len(expected), len(log))
+	}
+
+	for i := range expected {
+		if log[i] != expected[i] {
+			t.Errorf("Expected mutation %d to be %v, got %v", i, expected[i], log[i])
+		}
+	}
+
+	// Replaying the log recovers the set
+	replayed := NewHashSet()
+	for _, e := range log {
+		switch e.op {
+		case OpAdd:
+			replayed.Add([]byte(e.value))
+		case OpRemove:
+			replayed.Remove([]byte(e.value))
+		case OpClear:
+			replayed.Clear()
+		}
+	}
+
+	if replayed.Size != set.Size || !replayed.Contains([]byte("d")) {
+		t.Errorf("Expected replayed set to match the original")
+	}
+}
+
+func TestHashSet_OnMutateBeforeApply(t *testing.T) {
+	var set *HashSet
+	set = mustHashSet(t, Options{OnMutate: func(op Op, value []byte) {
+		if op == OpAdd && set.Contains(value) {
+			t.Errorf("Expected OnMutate to be called before the element is added")
+		}
+	}})
+
+	set.Add([]byte("test"))
+}
+
+func TestHashSet_Fingerprint(t *testing.T) {
+	a := NewHashSet()
+	b := NewHashSetKeyed([]byte("other seed"))
+
+	// Same content added in a different order, with different seeds and resize histories
+	for i := 0; i < 1000; i++ {
+		a.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	for i := 999; i >= 0; i-- {
+		b.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Errorf("Expected sets with the same content to have the same fingerprint")
+	}
+
+	b.Remove([]byte("test0"))
+	if a.Fingerprint() == b.Fingerprint() {
+		t.Errorf("Expected sets with different content to have different fingerprints")
+	}
+
+	if NewHashSet().Fingerprint() == a.Fingerprint() {
+		t.Errorf("Expected an empty set to differ from a populated one")
+	}
+}
+
+func TestHashSet_IncrementalFingerprint(t *testing.T) {
+	for _, opts := range []Options{{}, {BucketLimit: 3}, {Multiset: true}} {
+		opts.IncrementalFingerprint = true
+		set := mustHashSet(t, opts)
+		rng := rand.New(rand.NewPCG(1, 2))
+
+		// The running value must equal a full recomputation after every kind of mutation
+		check := func(step string) {
+			t.Helper()
+			full := *set
+			full.opts.IncrementalFingerprint = false
+			if got, want := set.Fingerprint(), full.Fingerprint(); got != want {
+				t.Fatalf("Expected fingerprint %x after %s, got %x", want, step, got)
+			}
+		}
+
+		for i := 0; i < 3000; i++ {
+			value := []byte(fmt.Sprintf("test%d", rng.IntN(500)))
+			if rng.IntN(3) == 0 {
+				set.Remove(value)
+			} else {
+				set.Add(value)
+			}
+		}
+		check("adds and removes")
+
+		set.IterRemove(func(value []byte) bool { return value[len(value)-1] == '7' })
+		check("IterRemove")
+
+		clone := set.Clone()
+		clone.Add([]byte("clone only"))
+		check("adding to a clone")
+
+		data, err := set.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded, err := Deserialize(data); err != nil || decoded.Fingerprint() != set.Fingerprint() {
+			t.Errorf("Expected the decoded set to keep the fingerprint, got %v", err)
+		}
+
+		set.Clear()
+		check("Clear")
+		if set.Fingerprint() != NewHashSet().Fingerprint() {
+			t.Errorf("Expected a cleared set to fingerprint like an empty one")
+		}
+	}
+}
+
+func TestOptimalCapacity(t *testing.T) {
+	tests := []struct {
+		n          int
+		loadFactor float64
+		want       int
+	}{
+		{0, 0.7, 1},
+		{1, 0.7, 2},
+		{7, 0.7, 16},
+		{700, 0.7, 1024},
+		{716, 0.7, 1024},
+		{717, 0.7, 2048},
+		{512, 0.5, 1024},
+		{513, 0.5, 2048},
+		{1000, 1, 1024},
+		{700, 0, 1024}, // Invalid load factor falls back to the default
+	}
+
+	for _, tt := range tests {
+		if got := OptimalCapacity(tt.n, tt.loadFactor); got != tt.want {
+			t.Errorf("OptimalCapacity(%d, %v) = %d, want %d", tt.n, tt.loadFactor, got, tt.want)
+		}
+	}
+}
+
+func TestNewHashSetWithCapacity(t *testing.T) {
+	n := 5000
+	set := NewHashSetWithCapacity(OptimalCapacity(n, loadFactorThreshold))
+	capacity := set.Capacity
+
+	gen := set.Generation()
+	for i := 0; i < n; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	if set.Capacity != capacity {
+		t.Errorf("Expected no resize at capacity %d, got %d", capacity, set.Capacity)
+	}
+
+	// Every add bumps the generation once, a resize would add one more
+	if set.Generation()-gen != uint64(n) {
+		t.Errorf("Expected %d mutations, got %d", n, set.Generation()-gen)
+	}
+
+	if NewHashSetWithCapacity(100).Capacity != 128 {
+		t.Errorf("Expected capacity to be rounded up to 128")
+	}
+}
+
+func TestHashSet_RehashDeterministic(t *testing.T) {
+	build := func() *HashSet {
+		set := NewHashSet()
+		for i := 0; i < 500; i++ {
+			set.Add([]byte(fmt.Sprintf("test%d", i)))
+			if i%3 == 0 {
+				set.Remove([]byte(fmt.Sprintf("test%d", i/2)))
+			}
+		}
+		return set
+	}
+
+	a, err := build().Serialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+
+	b, err := build().Serialize()
+	if err != nil {
+		t.Fatalf("Failed to serialize: %v", err)
+	}
+
+	if !bytes.Equal(a, b) {
+		t.Errorf("Expected identical histories to serialize identically")
+	}
+}
+
+func TestHashSet_RehashPreservesBucketOrder(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 200; i++ {
+		set.insert(0, []byte(fmt.Sprintf("test%d", i))) // Force one long chain
+	}
+	set.Size = 200
+
+	before := make(map[string]int)
+	for i, item := range set.Buckets[0] {
+		before[string(item.([]byte))] = i
+	}
+
+	set.rehash(set.Capacity * 4)
+
+	for index, bucket := range set.Buckets {
+		for i := 1; i < len(bucket); i++ {
+			if before[string(bucket[i-1].([]byte))] > before[string(bucket[i].([]byte))] {
+				t.Errorf("Expected bucket %d to keep the relative order of its elements", index)
+			}
+		}
+	}
+}
+
+func TestHashSet_ContainsStringNoAlloc(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("test"))
+
+	if !set.ContainsStringNoAlloc("test") {
+		t.Errorf("Expected set to contain test")
+	}
+
+	if set.ContainsStringNoAlloc("missing") || set.ContainsStringNoAlloc("") {
+		t.Errorf("Expected set to not contain missing or the empty string")
+	}
+
+	key := fmt.Sprintf("test%d", 1) // Not a constant, so the conversion would allocate
+	set.Add([]byte(key))
+	if allocs := testing.AllocsPerRun(100, func() { set.ContainsStringNoAlloc(key) }); allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+}
+
+func TestHashSet_RemoveIfAndShrink(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 10000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	removed, shrunk := set.RemoveIfAndShrink(func(value []byte) bool {
+		return !bytes.HasPrefix(value, []byte("test1"))
+	})
+
+	if removed != 10000-1111 {
+		t.Errorf("Expected %d elements removed, got %d", 10000-1111, removed)
+	}
+
+	if !shrunk || set.Capacity != capacityFor(1111) {
+		t.Errorf("Expected capacity to shrink to %d, got %d (%v)", capacityFor(1111), set.Capacity, shrunk)
+	}
+
+	for i := 0; i < 10000; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		if set.Contains(value) != bytes.HasPrefix(value, []byte("test1")) {
+			t.Errorf("Unexpected membership of %s after shrink", value)
+		}
+	}
+
+	// Nothing left to shrink
+	if removed, shrunk := set.RemoveIfAndShrink(func([]byte) bool { return false }); removed != 0 || shrunk {
+		t.Errorf("Expected no removal and no shrink, got %d %v", removed, shrunk)
+	}
+}
+
+func TestHashSet_WillResizeOnAdd(t *testing.T) {
+	set := NewHashSet()
+
+	for i := 0; ; i++ {
+		want := set.WillResizeOnAdd()
+		capacity := set.Capacity
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+
+		if resized := set.Capacity != capacity; resized != want {
+			t.Fatalf("Expected WillResizeOnAdd to be %v before add %d, the set resized: %v", want, i, resized)
+		}
+		if want {
+			break
+		}
+	}
+
+	set.Reserve(set.Size + 100)
+	if set.WillResizeOnAdd() {
+		t.Errorf("Expected no resize to be imminent after Reserve")
+	}
+}
+
+func TestHashSet_Collisions(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 20; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	collided := 0
+	for index, members := range set.Collisions() {
+		if len(members) < 2 {
+			t.Errorf("Expected bucket %d to hold at least 2 members, got %d", index, len(members))
+		}
+		for _, member := range members {
+			if set.hash(member, set.Capacity) != index {
+				t.Errorf("Expected %s to belong to bucket %d", member, index)
+			}
+		}
+		collided += len(members)
+	}
+
+	// Every element not in a collided bucket is alone in its bucket
+	alone := 0
+	for _, bucket := range set.Buckets {
+		if len(bucket) == 1 {
+			alone++
+		}
+	}
+
+	if collided+alone != set.Size {
+		t.Errorf("Expected %d collided and %d lone elements to add up to %d", collided, alone, set.Size)
+	}
+}
+
+func TestHashSet_AddUnchecked(t *testing.T) {
+	set := NewHashSet()
+	set.Reserve(1000)
+	capacity := set.Capacity
+
+	for i := 0; i < 1000; i++ {
+		if err := set.AddUnchecked([]byte(fmt.Sprintf("test%d", i))); err != nil {
+			t.Fatalf("Failed to add: %v", err)
+		}
+	}
+
+	if set.Size != 1000 || set.Capacity != capacity {
+		t.Errorf("Expected 1000 elements without a resize, got %d elements and capacity %d", set.Size, set.Capacity)
+	}
+
+	for i := 0; i < 1000; i++ {
+		if !set.Contains([]byte(fmt.Sprintf("test%d", i))) {
+			t.Errorf("Expected set to contain test%d", i)
+		}
+	}
+}
+
+func BenchmarkHashSet_AddUnchecked(b *testing.B) {
+	values := make([][]byte, 10000)
+	for i := range values {
+		values[i] = []byte(fmt.Sprintf("test%d", i))
+	}
+
+	b.Run("Add", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			set := NewHashSet()
+			set.Reserve(len(values))
+			for _, value := range values {
+				set.Add(value)
+			}
+		}
+	})
+
+	b.Run("AddUnchecked", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			set := NewHashSet()
+			set.Reserve(len(values))
+			for _, value := range values {
+				set.AddUnchecked(value)
+			}
+		}
+	})
+}
+
+func TestNewHashSetForTest(t *testing.T) {
+	a := NewHashSetForTest(7, 100)
+	b := NewHashSetForTest(7, 100)
+	if a.Seed != 7 || a.Capacity != 128 {
+		t.Errorf("Expected seed 7 and capacity 128, got %d and %d", a.Seed, a.Capacity)
+	}
+
+	for i := 0; i < 50; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		a.Add(value)
+		b.Add(value)
+	}
+
+	// Identical seeds and capacities place every element identically
+	for index := range a.Buckets {
+		if len(a.Buckets[index]) != len(b.Buckets[index]) {
+			t.Errorf("Expected bucket %d to hold the same elements", index)
+		}
+	}
+
+	if NewHashSetForTest(0, 0).Seed != defaultSeed {
+		t.Errorf("Expected a zero seed to be replaced by the default seed")
+	}
+}
+
+func TestHashSet_ContainsWithin(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 5; i++ {
+		set.insert(0, []byte(fmt.Sprintf("test%d", i))) // Force one chain
+	}
+	set.Size = 5
+
+	// Place a lookup in bucket 0 by searching for a value hashing there
+	var miss []byte
+	for i := 0; miss == nil; i++ {
+		if value := []byte(fmt.Sprintf("miss%d", i)); set.hash(value, set.Capacity) == 0 {
+			miss = value
+		}
+	}
+
+	if found, exhausted := set.ContainsWithin(miss, 2); found || !exhausted {
+		t.Errorf("Expected the budget to be exhausted, got %v %v", found, exhausted)
+	}
+
+	if found, exhausted := set.ContainsWithin(miss, 5); found || exhausted {
+		t.Errorf("Expected an authoritative miss with a sufficient budget, got %v %v", found, exhausted)
+	}
+}
+
+func TestHashSet_ContainsWithinFound(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("test"))
+
+	if found, exhausted := set.ContainsWithin([]byte("test"), 1); !found || exhausted {
+		t.Errorf("Expected test to be found within 1 probe, got %v %v", found, exhausted)
+	}
+
+	if found, exhausted := set.ContainsWithin([]byte("test"), 0); found || !exhausted {
+		t.Errorf("Expected a zero budget to be exhausted, got %v %v", found, exhausted)
+	}
+}
+
+func TestHashSet_TrimToFit(t *testing.T) {
+	set := NewHashSetWithCapacity(8192)
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	if err := set.TrimToFit(0.5); err != nil || set.Capacity != 2048 {
+		t.Errorf("Expected capacity 2048 for a load factor of at most 0.5, got %d and %v", set.Capacity, err)
+	}
+	if err := set.TrimToFit(0.25); err != nil || set.Capacity != 2048 {
+		t.Errorf("Expected TrimToFit never to grow the set, got %d and %v", set.Capacity, err)
+	}
+	if err := set.TrimToFit(1); err != nil || set.Capacity != 1024 {
+		t.Errorf("Expected capacity 1024 for a load factor of at most 1, got %d and %v", set.Capacity, err)
+	}
+	for i := 0; i < 1000; i++ {
+		if !set.Contains([]byte(fmt.Sprintf("test%d", i))) {
+			t.Errorf("Expected set to contain test%d", i)
+		}
+	}
+
+	if err := NewHashSet().TrimToFit(1); err != nil {
+		t.Errorf("Expected no error for an empty set, got %v", err)
+	}
+	for _, loadFactor := range []float64{0, -0.5, 1.5} {
+		if err := set.TrimToFit(loadFactor); err == nil {
+			t.Errorf("Expected load factor %v to be rejected", loadFactor)
+		}
+	}
+}
+
+func TestHashSet_ResizeContext(t *testing.T) {
+	set := mustHashSet(t, Options{BloomBits: 1 << 16})
+	for i := 0; i < 3*rehashCheckInterval; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	capacity, generation := set.Capacity, set.Generation()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := set.ResizeContext(ctx, 4*capacity); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled resize, got %v", err)
+	}
+	if err := set.rehashContext(ctx, 4*capacity); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the rehash to stop midway, got %v", err)
+	}
+	if set.Capacity != capacity || set.Generation() != generation {
+		t.Errorf("Expected a cancelled resize to leave the set as it was")
+	}
+	for i := 0; i < 3*rehashCheckInterval; i++ {
+		if !set.Contains([]byte(fmt.Sprintf("test%d", i))) {
+			t.Fatalf("Expected test%d to survive the cancelled resize", i)
+		}
+	}
+
+	if err := set.ResizeContext(context.Background(), 3*capacity); err != nil || set.Capacity != 4*capacity {
+		t.Errorf("Expected a resize to %d buckets, got %d and %v", 4*capacity, set.Capacity, err)
+	}
+	if err := set.SelfTest(); err != nil {
+		t.Errorf("Expected a consistent set, got %v", err)
+	}
+	if err := set.ResizeContext(context.Background(), 0); err == nil {
+		t.Errorf("Expected an error for a capacity of 0")
+	}
+}
+
+func TestHashSet_OnResizeProgress(t *testing.T) {
+	var calls [][2]int
+	set := mustHashSet(t, Options{Capacity: 2 * progressInterval, OnResizeProgress: func(migrated, total int) {
+		calls = append(calls, [2]int{migrated, total})
+	}})
+	set.Add([]byte("test"))
+	set.Reserve(4 * progressInterval)
+
+	want := [][2]int{{progressInterval, 2 * progressInterval}, {2 * progressInterval, 2 * progressInterval}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("Expected progress %v, got %v", want, calls)
+	}
+	if set.Capacity != 8*progressInterval || !set.Contains([]byte("test")) {
+		t.Errorf("Expected the resize to complete")
+	}
+}
+
+func TestHashSet_RehashToLoadFactor(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	if err := set.RehashToLoadFactor(0.25); err != nil {
+		t.Fatalf("Failed to rehash: %v", err)
+	}
+
+	if set.Capacity != 4096 {
+		t.Errorf("Expected capacity 4096 for a load factor of at most 0.25, got %d", set.Capacity)
+	}
+
+	if err := set.RehashToLoadFactor(0.5); err != nil {
+		t.Fatalf("Failed to rehash: %v", err)
+	}
+
+	if set.Capacity != 2048 {
+		t.Errorf("Expected capacity 2048 for a load factor of at most 0.5, got %d", set.Capacity)
+	}
+
+	for i := 0; i < 1000; i++ {
+		if !set.Contains([]byte(fmt.Sprintf("test%d", i))) {
+			t.Errorf("Expected set to contain test%d", i)
+		}
+	}
+
+	for _, target := range []float64{0, 1, -0.5, 1.5} {
+		if err := set.RehashToLoadFactor(target); err == nil {
+			t.Errorf("Expected load factor %v to be rejected", target)
+		}
+	}
+}
+
+func TestHashSet_BucketSizes(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 20; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	sizes := set.BucketSizes()
+	if len(sizes) != set.Capacity {
+		t.Fatalf("Expected %d bucket sizes, got %d", set.Capacity, len(sizes))
+	}
+
+	total := 0
+	for i, size := range sizes {
+		if size != len(set.Buckets[i]) {
+			t.Errorf("Expected bucket %d size to be %d, got %d", i, len(set.Buckets[i]), size)
+		}
+		total += size
+	}
+
+	if total != set.Size {
+		t.Errorf("Expected bucket sizes to add up to %d, got %d", set.Size, total)
+	}
+}
+
+func TestHashSet_Intern(t *testing.T) {
+	set := NewHashSet()
+
+	first := []byte("test")
+	if got := set.Intern(first); &got[0] != &first[0] {
+		t.Errorf("Expected a new value to be stored and returned as is")
+	}
+
+	second := []byte("test")
+	if got := set.Intern(second); &got[0] != &first[0] {
+		t.Errorf("Expected the stored instance to be returned for an equal value")
+	}
+
+	if set.Size != 1 {
+		t.Errorf("Expected set size to be 1, got %d", set.Size)
+	}
+
+	// Fingerprinted values cannot be shared
+	fingerprinted := mustHashSet(t, Options{FingerprintThreshold: 16})
+	long := bytes.Repeat([]byte("x"), 64)
+	fingerprinted.Intern(long)
+	if got := fingerprinted.Intern(bytes.Clone(long)); !bytes.Equal(got, long) {
+		t.Errorf("Expected a fingerprinted value to be returned as is")
+	}
+}
+
+func TestHashSet_AddLocate(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		capacity := set.Capacity
+
+		bucket, pos, added := set.AddLocate(value)
+		if !added {
+			t.Fatalf("Expected %s to be added", value)
+		}
+		if i > 0 && set.Capacity != capacity && bucket != set.hash(value, set.Capacity) {
+			t.Errorf("Expected the placement of %s after the resize", value)
+		}
+		if !bytes.Equal(set.Buckets[bucket][pos].([]byte), value) {
+			t.Fatalf("Expected %s at bucket %d position %d", value, bucket, pos)
+		}
+	}
+
+	bucket, pos, added := set.AddLocate([]byte("test10"))
+	if added || !bytes.Equal(set.Buckets[bucket][pos].([]byte), []byte("test10")) {
+		t.Errorf("Expected the placement of a present element without adding it")
+	}
+
+	limited := mustHashSet(t, Options{MaxDistinct: 1})
+	limited.AddLocate([]byte("a"))
+	if bucket, pos, added := limited.AddLocate([]byte("b")); bucket != -1 || pos != -1 || added {
+		t.Errorf("Expected -1, -1, false for a refused value, got %d, %d, %v", bucket, pos, added)
+	}
+}
+
+func TestHashSet_Canonicalize(t *testing.T) {
+	set := NewHashSet()
+	for _, value := range []string{"Key", "key", "KEY ", "other", "Other\t"} {
+		set.Add([]byte(value))
+	}
+
+	merges := set.Canonicalize(func(value []byte) []byte {
+		return bytes.ToLower(bytes.TrimRight(value, " \t"))
+	})
+	if merges != 3 {
+		t.Errorf("Expected 3 merges, got %d", merges)
+	}
+	if set.Size != 2 || !set.Contains([]byte("key")) || !set.Contains([]byte("other")) {
+		t.Errorf("Expected only the canonical forms key and other, got %q", set.ToSlice())
+	}
+	if set.Contains([]byte("Key")) {
+		t.Errorf("Expected the original forms to be gone")
+	}
+}
+
+func TestHashSet_DeserializeCompact(t *testing.T) {
+	set := NewHashSetWithCapacity(1 << 12)
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compact, err := DeserializeCompact(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compact.Capacity != 256 || compact.Size != 100 {
+		t.Errorf("Expected 100 elements in 256 buckets, got %d in %d", compact.Size, compact.Capacity)
+	}
+	for i := 0; i < 100; i++ {
+		if !compact.Contains([]byte(fmt.Sprintf("test%d", i))) {
+			t.Errorf("Expected compact set to contain test%d", i)
+		}
+	}
+}
+
+func TestHashSet_NewHashSetWithOptionsValidation(t *testing.T) {
+	invalid := map[string]Options{
+		"negative capacity":         {Capacity: -1},
+		"capacity too large":        {Capacity: maxCapacity + 1},
+		"negative ttl":              {TTL: -1},
+		"negative miss cache":       {MissCache: -1},
+		"evict without limit":       {OnEvict: func(value []byte) {}},
+		"sample without distinct":   {ReservoirSample: 10},
+		"sorted and secondary":      {SortedBuckets: true, SecondaryHashing: true},
+		"conflicting hasher":        {Hasher: conflictingHasher{}},
+		"incremental and probing":   {IncrementalResize: 1, Strategy: OpenAddressing},
+		"incremental and secondary": {IncrementalResize: 1, SecondaryHashing: true},
+		"horizon and limit":         {ResizeHorizon: 1, BucketLimit: 2},
+		"adaptive and limit":        {AdaptiveLoadFactor: true, BucketLimit: 2},
+		"tags and secondary":        {ChainFingerprints: true, SecondaryHashing: true},
+		"tags and probing":          {ChainFingerprints: true, Strategy: OpenAddressing},
+	}
+	for name, opts := range invalid {
+		set, err := NewHashSetWithOptions(opts)
+		if err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+		if set != nil {
+			t.Errorf("Expected no set for %s", name)
+		}
+	}
+
+	set, err := NewHashSetWithOptions(Options{Capacity: 64, BucketLimit: 2, OnEvict: func(value []byte) {}, Hasher: FNVHasher})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(set.Buckets) != 64 {
+		t.Errorf("Expected 64 buckets, got %d", len(set.Buckets))
+	}
+}
+
+// conflictingHasher claims the ID of the built-in FNV hasher.
+type conflictingHasher struct{}
+
+func (conflictingHasher) ID() uint8 { return FNVHasher.ID() }
+
+func (conflictingHasher) Hash64(data []byte, seed uint64) uint64 { return seed }
+
+func TestHashSet_NilReceiver(t *testing.T) {
+	var set *HashSet
+	if set.Contains([]byte("test")) || set.ContainsStringNoAlloc("test") {
+		t.Errorf("Expected a nil set to contain nothing")
+	}
+	if set.Len() != 0 || !set.IsEmpty() {
+		t.Errorf("Expected a nil set to be empty, got %d", set.Len())
+	}
+	if set.ToSlice() != nil {
+		t.Errorf("Expected no elements from a nil set")
+	}
+
+	empty := NewHashSet()
+	if empty.Len() != 0 || !empty.IsEmpty() {
+		t.Errorf("Expected a new set to be empty")
+	}
+	empty.Add([]byte("test"))
+	if empty.IsEmpty() {
+		t.Errorf("Expected a set with an element not to be empty")
+	}
+}
+
+func TestHashSet_Swap(t *testing.T) {
+	active := mustHashSet(t, Options{InsertionOrder: true})
+	standby := NewHashSet()
+	for i := 0; i < 100; i++ {
+		active.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	standby.Add([]byte("standby"))
+	generation := active.Generation()
+
+	active.Swap(standby)
+	if active.Size != 1 || !active.Contains([]byte("standby")) || active.ToOrderedSlice() != nil {
+		t.Errorf("Expected the active set to hold the standby contents and options")
+	}
+	if standby.Size != 100 || !standby.Contains([]byte("test50")) || len(standby.ToOrderedSlice()) != 100 {
+		t.Errorf("Expected the standby set to hold the active contents and options")
+	}
+	if active.Generation() <= generation || standby.Generation() <= generation {
+		t.Errorf("Expected swapping to bump both generations")
+	}
+}
+
+// mustHashSet creates a set configured by opts, failing the test if they are invalid.
+func mustHashSet(t testing.TB, opts Options) *HashSet {
+	t.Helper()
+	set, err := NewHashSetWithOptions(opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return set
+}
+
+func TestHashSet_SerializeOptions(t *testing.T) {
+	opts := Options{Capacity: 64, Multiset: true, TTL: time.Hour, FingerprintThreshold: 32, Strategy: SortedChaining, MaxDistinct: 100}
+	set := mustHashSet(t, opts)
+	for i := 0; i < 50; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := decoded.opts
+	if got.Capacity != 64 || !got.Multiset || got.TTL != time.Hour || got.FingerprintThreshold != 32 || got.Strategy != SortedChaining || got.MaxDistinct != 100 {
+		t.Errorf("Expected the options to survive the round trip, got %+v", got)
+	}
+	if decoded.Count([]byte("test7")) != 1 || !decoded.Contains([]byte("test49")) {
+		t.Errorf("Expected the decoded multiset to count every element once")
+	}
+
+	// Payloads placed by a hasher this build does not have are rejected
+	custom := mustHashSet(t, Options{Hasher: moduloHasher{}})
+	custom.Add([]byte("test"))
+	data, err = custom.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Deserialize(data); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion for an unregistered hasher, got %v", err)
+	}
+}
+
+func TestHashSet_EmptyValue(t *testing.T) {
+	set := NewHashSet()
+	if added, err := set.Add([]byte{}); !added || err != nil {
+		t.Fatalf("Expected the empty value to be added, got %v, %v", added, err)
+	}
+	if added, _ := set.Add(nil); added {
+		t.Errorf("Expected nil to be the empty value already present")
+	}
+	if !set.Contains(nil) || !set.Contains([]byte{}) || set.Size != 1 {
+		t.Errorf("Expected one empty member found as nil and as an empty slice")
+	}
+
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unmarshaled := NewHashSet()
+	if err := unmarshaled.UnmarshalBinary(encoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Contains(nil) || !unmarshaled.Contains([]byte{}) {
+		t.Errorf("Expected the empty member to survive both encodings")
+	}
+
+	set.Remove(nil)
+	if set.Contains([]byte{}) || set.Size != 0 {
+		t.Errorf("Expected removing nil to remove the empty member")
+	}
+
+	rejecting := mustHashSet(t, Options{RejectEmpty: true})
+	if added, err := rejecting.Add([]byte{}); added || !errors.Is(err, ErrEmptyValue) {
+		t.Errorf("Expected ErrEmptyValue, got %v, %v", added, err)
+	}
+	if err := rejecting.AddUnchecked(nil); !errors.Is(err, ErrEmptyValue) {
+		t.Errorf("Expected AddUnchecked to refuse nil, got %v", err)
+	}
+	if rejecting.Contains(nil) || rejecting.Size != 0 {
+		t.Errorf("Expected the empty value never to be a member")
+	}
+
+	// A payload holding the empty member does not decode under RejectEmpty
+	decoded.opts.RejectEmpty = true
+	data, err = decoded.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Deserialize(data); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt for an empty member under RejectEmpty, got %v", err)
+	}
+}
+
+func TestHashSet_CapacityPowerOfTwo(t *testing.T) {
+	check := func(op string, set *HashSet) {
+		t.Helper()
+		if c := set.Capacity; c <= 0 || c&(c-1) != 0 || len(set.Buckets) != c {
+			t.Errorf("Expected a power of two capacity after %s, got %d with %d buckets", op, c, len(set.Buckets))
+		}
+	}
+
+	check("NewHashSetWithCapacity", NewHashSetWithCapacity(100))
+	check("NewHashSetForTest", NewHashSetForTest(1, 33))
+	check("NewHashSetFixed", NewHashSetFixed(10, [][]byte{[]byte("a")}))
+	check("BuildExact", BuildExact([][]byte{[]byte("a"), []byte("b"), []byte("c")}))
+
+	set := mustHashSet(t, Options{Capacity: 5})
+	check("NewHashSetWithOptions", set)
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("value%d", i)))
+	}
+	check("Add", set)
+	set.Reserve(3000)
+	check("Reserve", set)
+	if err := set.ResizeContext(context.Background(), 5000); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	check("ResizeContext", set)
+	if err := set.RehashToLoadFactor(0.3); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	check("RehashToLoadFactor", set)
+	set.RemoveIfAndShrink(func(value []byte) bool { return bytes.HasSuffix(value, []byte("7")) })
+	check("RemoveIfAndShrink", set)
+	if err := set.TrimToFit(0.9); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	check("TrimToFit", set)
+	check("Clone", set.Clone())
+
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	decoded, err := Deserialize(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	check("Deserialize", decoded)
+	compact, err := DeserializeCompact(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	check("DeserializeCompact", compact)
+
+	set.Clear()
+	check("Clear", set)
+
+	odd := NewHashSet()
+	odd.Capacity, odd.Buckets = 5, make([][]interface{}, 5) // Hand-constructed
+	if _, err := odd.Validate(); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected a hand-constructed capacity of 5 to be corrupt, got %v", err)
+	}
+}
+
+func TestHashSet_MaxValueLen(t *testing.T) {
+	if _, err := NewHashSetWithOptions(Options{MaxValueLen: -1}); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption for a negative MaxValueLen, got %v", err)
+	}
+
+	set := mustHashSet(t, Options{MaxValueLen: 8})
+	if added, err := set.Add([]byte("12345678")); !added || err != nil {
+		t.Errorf("Expected a value of the maximum length to be added, got %v, %v", added, err)
+	}
+	if added, err := set.Add([]byte("123456789")); added || !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("Expected ErrValueTooLarge, got %v, %v", added, err)
+	}
+	if err := set.TryAdd(make([]byte, 1<<20)); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("Expected TryAdd to refuse a large value, got %v", err)
+	}
+	if err := set.AddUnchecked([]byte("123456789")); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("Expected AddUnchecked to refuse a large value, got %v", err)
+	}
+	if set.Size != 1 || set.Contains([]byte("123456789")) {
+		t.Errorf("Expected only the value within the limit to be a member")
+	}
+
+	// A fingerprinted value counts as its 16 bytes
+	fingerprinted := mustHashSet(t, Options{MaxValueLen: 16, FingerprintThreshold: 16})
+	if added, err := fingerprinted.Add(make([]byte, 1<<10)); !added || err != nil {
+		t.Errorf("Expected a fingerprinted value to fit, got %v, %v", added, err)
+	}
+
+	// A payload holding a longer member does not decode under MaxValueLen
+	plain := NewHashSet()
+	plain.Add([]byte("123456789"))
+	plain.opts.MaxValueLen = 8
+	data, err := plain.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Deserialize(data); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt for a member over MaxValueLen, got %v", err)
+	}
+}
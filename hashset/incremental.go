@@ -0,0 +1,138 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import "slices"
+
+// incrementalGrowth is a doubling of the bucket array in progress, for the IncrementalResize option.
+// The array already has the doubled capacity, but the lower buckets from next up to half still hold
+// the elements that belong in their upper partner, the bucket half positions above.
+type incrementalGrowth struct {
+	half int // Capacity before the doubling, zero if no doubling is in progress
+	next int // Next lower bucket to split
+}
+
+// home returns the bucket holding the elements with digest.
+// During an incremental resize it is the lower bucket for the elements it has not handed over yet.
+func (h *HashSet) home(digest uint64) int {
+	index := digestIndex(digest, h.Capacity)
+	if g := h.growth; g.half > 0 && index >= g.half && index-g.half >= g.next {
+		return index - g.half // Not split yet
+	}
+	return index
+}
+
+// growIncrementally doubles the capacity without moving any element, they are moved by splitBuckets.
+// It reports false if the resize has to rehash the set at once, the doubled capacity must keep every
+// element in its bucket or in the bucket half the old capacity above it.
+func (h *HashSet) growIncrementally(newCapacity int) bool {
+	if h.opts.IncrementalResize <= 0 || newCapacity != h.Capacity*2 {
+		return false
+	}
+	h.finishGrowth() // A set still splitting when it fills up again finishes the previous resize first
+
+	buckets := make([][]interface{}, newCapacity)
+	copy(buckets, h.Buckets)
+	if h.shared != nil {
+		h.shared = append(h.shared, make([]bool, h.Capacity)...) // The upper buckets are new
+	}
+	h.growth = incrementalGrowth{half: h.Capacity}
+	h.Buckets = buckets
+	h.Capacity = newCapacity
+	h.generation++
+	return true
+}
+
+// splitBuckets does the bounded work of an incremental resize, splitting up to n lower buckets.
+func (h *HashSet) splitBuckets(n int) {
+	for ; n > 0 && h.growth.half > 0; n-- {
+		h.splitBucket()
+	}
+}
+
+// finishGrowth splits the remaining buckets of an incremental resize.
+func (h *HashSet) finishGrowth() {
+	h.splitBuckets(h.growth.half - h.growth.next)
+}
+
+// splitBucket moves the elements of the next lower bucket that belong to its upper partner.
+// Both halves keep the relative order of the elements, so sorted buckets stay sorted.
+func (h *HashSet) splitBucket() {
+	index := h.growth.next
+	if len(h.Buckets[index]) > 0 {
+		h.own(index)
+		bucket := h.Buckets[index]
+		kept := bucket[:0]
+		upper := index + h.growth.half
+		for _, item := range bucket {
+			if h.hash(item.([]byte), h.Capacity) == index {
+				kept = append(kept, item)
+				continue
+			}
+			if h.Buckets[upper] == nil {
+				h.Buckets[upper] = h.newBucket()
+			}
+			h.Buckets[upper] = append(h.growBucket(h.Buckets[upper], h.isShared(upper)), item)
+		}
+		clear(bucket[len(kept):]) // Drop the references to the moved elements
+		h.Buckets[index] = kept
+	}
+
+	h.growth.next++
+	if progress := h.opts.OnResizeProgress; progress != nil && (h.growth.next%progressInterval == 0 || h.growth.next == h.growth.half) {
+		progress(h.growth.next, h.growth.half)
+	}
+	if h.growth.next == h.growth.half {
+		h.growth = incrementalGrowth{} // Every element is in its bucket
+	}
+}
+
+// settledBuckets returns the buckets as they are once an incremental resize in progress completes,
+// for readers that must see every element in its final bucket without modifying the set.
+func (h *HashSet) settledBuckets() [][]interface{} {
+	if h.growth.half == 0 {
+		return h.Buckets
+	}
+
+	buckets := slices.Clone(h.Buckets)
+	for index := h.growth.next; index < h.growth.half; index++ {
+		var kept, moved []interface{}
+		for _, item := range h.Buckets[index] {
+			if h.hash(item.([]byte), h.Capacity) == index {
+				kept = append(kept, item)
+			} else {
+				moved = append(moved, item)
+			}
+		}
+		buckets[index], buckets[index+h.growth.half] = kept, moved
+	}
+	return buckets
+}
@@ -0,0 +1,145 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_IncrementalResize(t *testing.T) {
+	for _, opts := range []Options{
+		{IncrementalResize: 1},
+		{IncrementalResize: 1, SortedBuckets: true},
+		{IncrementalResize: 4, BucketHint: 2},
+	} {
+		set := mustHashSet(t, opts)
+		capacity := set.Capacity
+		splitting := false
+		removed := make(map[int]bool)
+		for i := 0; i < 2000; i++ {
+			set.Add([]byte(fmt.Sprintf("test%d", i)))
+			if set.growth.half > 0 {
+				splitting = true
+			}
+			if i%97 == 0 {
+				set.Remove([]byte(fmt.Sprintf("test%d", i/2)))
+				removed[i/2] = true
+				if err := set.SelfTest(); err != nil {
+					t.Fatalf("Expected a consistent set during the resize with %+v, got %v", opts, err)
+				}
+			}
+		}
+		if !splitting || set.Capacity <= capacity {
+			t.Errorf("Expected the set to resize incrementally with %+v", opts)
+		}
+
+		for i := 0; i < 2000; i++ {
+			if set.Contains([]byte(fmt.Sprintf("test%d", i))) == removed[i] {
+				t.Errorf("Expected test%d to be present %v with %+v", i, !removed[i], opts)
+			}
+		}
+	}
+}
+
+func TestHashSet_IncrementalResizeBoundsWork(t *testing.T) {
+	set := mustHashSet(t, Options{IncrementalResize: 2})
+	for !set.WillResizeOnAdd() {
+		set.Add([]byte(fmt.Sprintf("test%d", set.Size)))
+	}
+
+	capacity := set.Capacity
+	set.Add([]byte("trigger"))
+	if set.Capacity != capacity*2 || set.growth.half != capacity || set.growth.next != 2 {
+		t.Errorf("Expected the resize to double the capacity and split 2 buckets, got capacity %d and %+v", set.Capacity, set.growth)
+	}
+	for i := 0; i < set.Size-1; i++ {
+		if !set.Contains([]byte(fmt.Sprintf("test%d", i))) {
+			t.Errorf("Expected test%d to be found during the resize", i)
+		}
+	}
+}
+
+func TestHashSet_IncrementalResizeSnapshots(t *testing.T) {
+	set := mustHashSet(t, Options{IncrementalResize: 1})
+	for !set.WillResizeOnAdd() {
+		set.Add([]byte(fmt.Sprintf("test%d", set.Size)))
+	}
+	set.Add([]byte("trigger"))
+	if set.growth.half == 0 {
+		t.Fatalf("Expected a resize in progress")
+	}
+
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	decoded, err := Deserialize(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	clone := set.Clone()
+	clone.Add([]byte("clone"))
+	set.Remove([]byte("trigger"))
+
+	for _, s := range []*HashSet{decoded, clone} {
+		if err := s.SelfTest(); err != nil {
+			t.Errorf("Expected a consistent copy, got %v", err)
+		}
+		if !s.Contains([]byte("trigger")) {
+			t.Errorf("Expected the copy to keep the element added before it")
+		}
+		for i := 0; i < set.Size; i++ {
+			if !s.Contains([]byte(fmt.Sprintf("test%d", i))) {
+				t.Errorf("Expected test%d in the copy", i)
+			}
+		}
+	}
+	if set.Contains([]byte("clone")) || set.Contains([]byte("trigger")) {
+		t.Errorf("Expected the original to only see its own mutations")
+	}
+
+	set.Freeze()
+	if set.growth.half != 0 || set.SelfTest() != nil {
+		t.Errorf("Expected Freeze to finish the resize")
+	}
+}
+
+func BenchmarkHashSet_IncrementalResize(b *testing.B) {
+	for _, budget := range []int{0, 4} {
+		b.Run(fmt.Sprintf("budget=%d", budget), func(b *testing.B) {
+			set := mustHashSet(b, Options{IncrementalResize: budget})
+			for i := 0; i < b.N; i++ {
+				set.Add([]byte(fmt.Sprintf("test%d", i)))
+			}
+		})
+	}
+}
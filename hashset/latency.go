@@ -0,0 +1,103 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"math/bits"
+	"time"
+)
+
+const latencyBuckets = 40 // power of two nanosecond buckets, the last one is open ended
+
+// LatencyHistogram is a histogram of operation latencies.
+// Bucket i counts the operations that took [2^i, 2^(i+1)) nanoseconds, bucket 0 also counts those under 1ns.
+type LatencyHistogram struct {
+	Buckets [latencyBuckets]uint64 // Operation counts per latency bucket
+	Count   uint64                 // Number of operations
+	Total   time.Duration          // Sum of the latencies
+}
+
+// LatencyStats holds the latency histograms of the profiled operations.
+type LatencyStats struct {
+	Name     string           // Name of the set, see the Name option
+	Add      LatencyHistogram // Latencies of Add
+	Remove   LatencyHistogram // Latencies of Remove
+	Contains LatencyHistogram // Latencies of Contains
+}
+
+// observe records the latency of an operation started at start.
+func (l *LatencyHistogram) observe(start time.Time) {
+	d := time.Since(start)
+	i := 0
+	if d > 0 {
+		i = min(bits.Len64(uint64(d))-1, latencyBuckets-1)
+	}
+	l.Buckets[i]++
+	l.Count++
+	l.Total += d
+}
+
+// Mean returns the average latency, or 0 if no operation was recorded.
+func (l *LatencyHistogram) Mean() time.Duration {
+	if l.Count == 0 {
+		return 0
+	}
+	return l.Total / time.Duration(l.Count)
+}
+
+// Quantile returns an upper bound of the latency under which a fraction q of the operations completed,
+// the exclusive upper limit of the bucket the quantile falls in. It returns 0 if no operation was recorded.
+func (l *LatencyHistogram) Quantile(q float64) time.Duration {
+	if l.Count == 0 {
+		return 0
+	}
+
+	target := uint64(q * float64(l.Count))
+	seen := uint64(0)
+	for i, n := range l.Buckets {
+		seen += n
+		if seen > target || i == latencyBuckets-1 {
+			return time.Duration(1) << (i + 1)
+		}
+	}
+	return 0
+}
+
+// LatencyStats returns a copy of the latency histograms recorded with the Profiling option.
+// It returns zero histograms if the set was not created with the Profiling option.
+func (h *HashSet) LatencyStats() LatencyStats {
+	if h.latency == nil {
+		return LatencyStats{Name: h.opts.Name}
+	}
+	stats := *h.latency
+	stats.Name = h.opts.Name
+	return stats
+}
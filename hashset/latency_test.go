@@ -0,0 +1,88 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHashSet_LatencyStats(t *testing.T) {
+	set := mustHashSet(t, Options{Profiling: true})
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+		set.Contains([]byte(fmt.Sprintf("test%d", i)))
+	}
+	set.Remove([]byte("test0"))
+
+	stats := set.LatencyStats()
+	if stats.Add.Count != 100 || stats.Contains.Count != 100 || stats.Remove.Count != 1 {
+		t.Errorf("Expected 100, 100 and 1 recorded operations, got %d %d %d",
+			stats.Add.Count, stats.Contains.Count, stats.Remove.Count)
+	}
+
+	if stats.Add.Quantile(0.99) < stats.Add.Quantile(0.5) {
+		t.Errorf("Expected p99 to be at least p50")
+	}
+
+	if stats.Add.Mean() > stats.Add.Total {
+		t.Errorf("Expected the mean to be no larger than the total, got %v", stats.Add.Mean())
+	}
+}
+
+func TestHashSet_LatencyStatsDisabled(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("test"))
+
+	if stats := set.LatencyStats(); stats.Add.Count != 0 {
+		t.Errorf("Expected no recorded operations without Profiling, got %d", stats.Add.Count)
+	}
+}
+
+func TestLatencyHistogram_Quantile(t *testing.T) {
+	var l LatencyHistogram
+	if l.Quantile(0.5) != 0 {
+		t.Errorf("Expected an empty histogram quantile to be 0")
+	}
+
+	l.Buckets[3] = 90 // [8ns, 16ns)
+	l.Buckets[10] = 10
+	l.Count = 100
+
+	if q := l.Quantile(0.5); q != 16*time.Nanosecond {
+		t.Errorf("Expected p50 to be 16ns, got %v", q)
+	}
+
+	if q := l.Quantile(0.95); q != 2048*time.Nanosecond {
+		t.Errorf("Expected p95 to be 2048ns, got %v", q)
+	}
+}
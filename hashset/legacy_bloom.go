@@ -0,0 +1,80 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//go:build !nomurmur
+
+package hashset
+
+import (
+	"encoding/binary"
+
+	"github.com/guycipher/k4/bloomfilter"
+)
+
+// maxLegacyHashes bounds the hash functions of a filter FromBloomFilter accepts. Filters use a handful, a larger
+// count is corrupt and would allocate the per function state of the filter and slow down every lookup.
+const maxLegacyHashes = 64
+
+// FromBloomFilter creates an empty set answering Contains from a filter serialized by the K4 bloomfilter package,
+// migrating an SSTable written before the hashset replaced the bloom filter.
+//
+// A bloom filter stores no keys, so the members cannot be recovered. The set starts empty and Contains reports
+// a value present if it was added to the set or the filter may contain it, so lookups keep the guarantee of the
+// filter: false positives at its rate, no false negatives. Consequently Remove cannot delete a key of the filter,
+// Size, ForEach and the serialized forms only cover the values added since, and the filter is not serialized.
+//
+// The migration path is to open old SSTables through FromBloomFilter, and to rebuild each with an exact set of its
+// keys, NewHashSet and Add, the next time it is rewritten, such as on compaction. The rebuilt SSTable needs no
+// filter, DropLegacy switches a set to exact answers once it holds every key. It is not available when built
+// with the nomurmur tag, as the filter hashes with murmur.
+func FromBloomFilter(data []byte) (*HashSet, error) {
+	if len(data) < 8 {
+		return nil, wrapf(ErrCorrupt, "corrupt bloom filter: %d bytes are too short", len(data))
+	}
+
+	size := binary.LittleEndian.Uint32(data)
+	hashes := int32(binary.LittleEndian.Uint32(data[4:]))
+	if size == 0 || hashes <= 0 || hashes > maxLegacyHashes {
+		return nil, wrapf(ErrCorrupt, "corrupt bloom filter: %d bits and %d hash functions", size, hashes)
+	}
+	if uint64(len(data)-8) < (uint64(size)+7)/8 {
+		return nil, wrapf(ErrCorrupt, "corrupt bloom filter: %d bits exceed the %d bytes of data", size, len(data)-8)
+	}
+
+	filter, err := bloomfilter.Deserialize(data)
+	if err != nil {
+		return nil, err
+	}
+
+	h := NewHashSet()
+	h.legacy = filter
+	return h, nil
+}
@@ -0,0 +1,115 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+
+//go:build !nomurmur
+
+package hashset
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/guycipher/k4/bloomfilter"
+)
+
+func TestFromBloomFilter(t *testing.T) {
+	filter := bloomfilter.NewBloomFilter(100000, 4)
+	for i := 0; i < 1000; i++ {
+		filter.Add([]byte(fmt.Sprintf("old%d", i)))
+	}
+	data, err := filter.Serialize()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	set, err := FromBloomFilter(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !set.Legacy() || set.Size != 0 {
+		t.Fatalf("Expected an empty set answering from the legacy filter")
+	}
+
+	// No false negatives for the keys of the filter
+	for i := 0; i < 1000; i++ {
+		if !set.Contains([]byte(fmt.Sprintf("old%d", i))) {
+			t.Errorf("Expected old%d to be reported present", i)
+		}
+	}
+
+	set.Add([]byte("new"))
+	if !set.Contains([]byte("new")) {
+		t.Errorf("Expected added values to be found")
+	}
+
+	// Rebuilt with the exact keys, the filter is dropped
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("old%d", i)))
+	}
+	set.DropLegacy()
+	if set.Legacy() || !set.Contains([]byte("old10")) || set.Contains([]byte("never")) {
+		t.Errorf("Expected exact answers after DropLegacy")
+	}
+}
+
+func TestFromBloomFilterCorrupt(t *testing.T) {
+	hashes := make([]byte, 24) // A hash function count in the hundreds of millions
+	binary.LittleEndian.PutUint32(hashes, 64)
+	binary.LittleEndian.PutUint32(hashes[4:], 0x28000000)
+
+	for _, data := range [][]byte{nil, make([]byte, 8), {0xff, 0xff, 0, 0, 1, 0, 0, 0}, hashes} {
+		if _, err := FromBloomFilter(data); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("Expected ErrCorrupt for the corrupt filter %x, got %v", data, err)
+		}
+	}
+}
+
+func FuzzFromBloomFilter(f *testing.F) {
+	filter := bloomfilter.NewBloomFilter(64, 4)
+	filter.Add([]byte("test"))
+	data, _ := filter.Serialize()
+	f.Add(data)
+
+	hashes := make([]byte, 24)
+	binary.LittleEndian.PutUint32(hashes, 64)
+	binary.LittleEndian.PutUint32(hashes[4:], 0x28000000)
+	f.Add(hashes)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		set, err := FromBloomFilter(data)
+		if err != nil {
+			return
+		}
+		set.Contains([]byte("test"))
+	})
+}
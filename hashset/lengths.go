@@ -0,0 +1,82 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+const lengthFilterSize = 256 // Lengths counted apart, longer members share the last count
+
+// lengthFilter counts the members of every length, for the LengthFilter option.
+// A nil lengthFilter counts nothing and rules nothing out.
+type lengthFilter struct {
+	counts [lengthFilterSize]int // Members of each length, the last entry counts every longer member too
+}
+
+// lengthSlot returns the count a member of length n is tallied in.
+func lengthSlot(n int) int {
+	return min(n, lengthFilterSize-1)
+}
+
+// clone returns an independent copy of the filter.
+func (f *lengthFilter) clone() *lengthFilter {
+	if f == nil {
+		return nil
+	}
+	c := *f
+	return &c
+}
+
+// add counts value.
+func (f *lengthFilter) add(value []byte) {
+	if f == nil {
+		return
+	}
+	f.counts[lengthSlot(len(value))]++
+}
+
+// remove stops counting value.
+func (f *lengthFilter) remove(value []byte) {
+	if f == nil {
+		return
+	}
+	f.counts[lengthSlot(len(value))]--
+}
+
+// clear forgets every count.
+func (f *lengthFilter) clear() {
+	if f == nil {
+		return
+	}
+	f.counts = [lengthFilterSize]int{}
+}
+
+// mayContain reports whether a member of length n may be present, false when no member has that length.
+func (f *lengthFilter) mayContain(n int) bool {
+	return f == nil || f.counts[lengthSlot(n)] > 0
+}
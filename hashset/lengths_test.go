@@ -0,0 +1,89 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHashSet_LengthFilter(t *testing.T) {
+	set := mustHashSet(t, Options{LengthFilter: true, Profiling: true})
+	set.Add([]byte("abc"))
+	set.Add([]byte("xyz"))
+	set.Add(bytes.Repeat([]byte("l"), 300))
+
+	if set.Contains([]byte("abcd")) {
+		t.Errorf("Expected a value of an absent length not to be found")
+	}
+	if set.probes.Misses.Buckets[0] != 1 {
+		t.Errorf("Expected the absent length to be ruled out without comparing, got %v", set.probes.Misses.Buckets[:2])
+	}
+	if !set.Contains([]byte("abc")) || !set.Contains(bytes.Repeat([]byte("l"), 300)) {
+		t.Errorf("Expected the members to be found")
+	}
+	if set.Contains(bytes.Repeat([]byte("l"), 400)) {
+		t.Errorf("Expected a long value sharing the last count to be looked up and not found")
+	}
+
+	set.Remove([]byte("abc"))
+	if !set.Contains([]byte("xyz")) {
+		t.Errorf("Expected a member of a length still present to be found")
+	}
+	set.Remove([]byte("xyz"))
+	if set.lengths.mayContain(3) {
+		t.Errorf("Expected the length to be dropped with its last member")
+	}
+
+	set.Add([]byte("abc"))
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Contains([]byte("abc")) || decoded.lengths.counts[3] != 1 || decoded.lengths.counts[lengthFilterSize-1] != 1 {
+		t.Errorf("Expected a decoded set to count the lengths of its members, got %v", decoded.lengths.counts[:4])
+	}
+
+	clone := set.Clone()
+	clone.IterRemove(func(value []byte) bool { return true })
+	if clone.lengths.mayContain(3) || !set.Contains([]byte("abc")) {
+		t.Errorf("Expected a clone to count lengths independently")
+	}
+
+	set.Clear()
+	if set.lengths.mayContain(3) {
+		t.Errorf("Expected Clear to reset the lengths")
+	}
+}
@@ -0,0 +1,103 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/guycipher/k4/pager"
+)
+
+// Load decodes a set serialized in any of the formats holding a HashSet, detected from the magic of data:
+// those of MarshalBinary, SerializeMembers, SerializeFrontCoded, SerializeChecked, a SerializeSplit written as
+// a single part, and Serialize, which has no magic and is tried last. The members are copied out of data.
+// A checked payload with a bucket failing verification is rejected rather than returned partially.
+// The formats of other types, ShardedHashSet, Uint64Set, FrozenSet, WriteFrozen, WriteBlocks and SerializeDiff,
+// fail with ErrUnsupportedVersion naming the function reading them. Data in no known format fails with
+// ErrUnsupportedVersion too, also matching ErrCorrupt as it may be a damaged Serialize payload. The text of
+// DumpText has no magic to tell it from other data and is read by LoadText.
+func Load(data []byte) (*HashSet, error) {
+	if len(data) >= pager.HEADER_SIZE+len(blocksMagic) && string(data[pager.HEADER_SIZE:][:len(blocksMagic)]) == blocksMagic {
+		return nil, wrapf(ErrUnsupportedVersion, "hashset: a WriteBlocks payload is read through its pages, not loaded")
+	}
+
+	var magic string
+	if len(data) >= 4 {
+		magic = string(data[:4])
+	}
+
+	switch magic {
+	case binaryMagic:
+		h := NewHashSet()
+		if err := h.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		return h, nil
+	case membersMagic:
+		return DeserializeMembers(data)
+	case frontCodedMagic:
+		return DeserializeFrontCoded(bytes.NewReader(data))
+	case checkedMagic:
+		h, failed, err := DeserializeChecked(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if len(failed) > 0 {
+			return nil, wrapf(ErrChecksumMismatch, "corrupt hashset: %d buckets fail verification, see DeserializeChecked", len(failed))
+		}
+		return h, nil
+	case splitMagic:
+		return DeserializeSplit(func(part int) (io.ReadCloser, error) {
+			if part > 0 {
+				return nil, wrapf(ErrInvalidOption, "hashset: split set has more than one part, see DeserializeSplit")
+			}
+			return io.NopCloser(bytes.NewReader(data)), nil
+		})
+	case shardedMagic:
+		return nil, wrapf(ErrUnsupportedVersion, "hashset: a sharded set is loaded by DeserializeSharded")
+	case uint64SetMagic:
+		return nil, wrapf(ErrUnsupportedVersion, "hashset: a uint64 set is loaded by DeserializeUint64Set")
+	case mphMagic:
+		return nil, wrapf(ErrUnsupportedVersion, "hashset: a frozen set is opened by OpenFrozenSet")
+	case frozenMagic:
+		return nil, wrapf(ErrUnsupportedVersion, "hashset: a frozen file is opened by OpenFrozen")
+	case diffMagic:
+		return nil, wrapf(ErrUnsupportedVersion, "hashset: a diff holds no set, it is applied by ApplyDiff")
+	}
+
+	h, err := Deserialize(data)
+	if errors.Is(err, ErrCorrupt) {
+		return nil, wrapf(ErrUnsupportedVersion, "hashset: unrecognized encoding %q, not a set format: %w", data[:min(len(data), 4)], err)
+	}
+	return h, err
+}
@@ -0,0 +1,111 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	encodings := map[string]func() ([]byte, error){
+		"binary":  set.MarshalBinary,
+		"members": set.SerializeMembers,
+		"gob":     set.Serialize,
+		"frontcoded": func() ([]byte, error) {
+			buf := &bytes.Buffer{}
+			err := set.SerializeFrontCoded(buf)
+			return buf.Bytes(), err
+		},
+		"checked": func() ([]byte, error) {
+			buf := &bytes.Buffer{}
+			err := set.SerializeChecked(buf)
+			return buf.Bytes(), err
+		},
+		"split": func() ([]byte, error) {
+			parts := splitParts(t, set, 1<<20)
+			if len(parts) != 1 {
+				return nil, fmt.Errorf("expected a single part, got %d", len(parts))
+			}
+			return parts[0], nil
+		},
+	}
+
+	for name, encode := range encodings {
+		data, err := encode()
+		if err != nil {
+			t.Fatalf("Failed to encode %s: %v", name, err)
+		}
+
+		loaded, err := Load(data)
+		if err != nil {
+			t.Errorf("Expected %s to load, got %v", name, err)
+			continue
+		}
+		if loaded.Size != set.Size || loaded.Fingerprint() != set.Fingerprint() {
+			t.Errorf("Expected %s to load the members, got %d of %d", name, loaded.Size, set.Size)
+		}
+	}
+}
+
+func TestLoadUnsupported(t *testing.T) {
+	sharded, err := NewShardedHashSet(4).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(sharded); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion for a sharded set, got %v", err)
+	}
+
+	for _, data := range [][]byte{nil, []byte("K4"), []byte("not a set at all")} {
+		if _, err := Load(data); !errors.Is(err, ErrUnsupportedVersion) {
+			t.Errorf("Expected ErrUnsupportedVersion for %q, got %v", data, err)
+		}
+	}
+
+	set := NewHashSet()
+	set.Add([]byte("test"))
+	data, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 0xff
+	if _, err := Load(data); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt for a damaged binary payload, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+// largestMember tracks the length of the largest element, for the TrackMaxMemberSize option.
+// Removing an element of the largest length marks it dirty and the next query rescans the set.
+// A nil largestMember tracks nothing.
+type largestMember struct {
+	size  int  // Length of the largest element, unless dirty
+	dirty bool // Whether an element of the largest length was removed since the last scan
+}
+
+// add records value as an element.
+func (l *largestMember) add(value []byte) {
+	if l != nil && !l.dirty {
+		l.size = max(l.size, len(value))
+	}
+}
+
+// remove records the removal of value, which may have been the largest element.
+func (l *largestMember) remove(value []byte) {
+	if l != nil && len(value) == l.size {
+		l.dirty = true
+	}
+}
+
+// clear forgets every element.
+func (l *largestMember) clear() {
+	if l != nil {
+		*l = largestMember{}
+	}
+}
+
+// MaxMemberSize returns the length of the largest element in stored form, see the FingerprintThreshold option,
+// or 0 if the set is empty. With the TrackMaxMemberSize option it is O(1), except for the first call after
+// the removal of a largest element which rescans the set. Without the option every call scans the set.
+func (h *HashSet) MaxMemberSize() int {
+	if h.largest != nil && !h.largest.dirty {
+		return h.largest.size
+	}
+
+	size := 0
+	h.ForEach(func(value []byte) bool {
+		size = max(size, len(value))
+		return true
+	})
+
+	if h.largest != nil {
+		*h.largest = largestMember{size: size}
+	}
+	return size
+}
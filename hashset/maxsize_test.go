@@ -0,0 +1,68 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHashSet_MaxMemberSize(t *testing.T) {
+	for _, track := range []bool{false, true} {
+		set := mustHashSet(t, Options{TrackMaxMemberSize: track})
+		if set.MaxMemberSize() != 0 {
+			t.Errorf("Expected 0 for an empty set")
+		}
+
+		for _, n := range []int{3, 10, 7, 10} {
+			set.Add(bytes.Repeat([]byte{byte(n)}, n))
+		}
+		if got := set.MaxMemberSize(); got != 10 {
+			t.Errorf("Expected the largest member to be 10 bytes, got %d", got)
+		}
+
+		// Removing the largest member rescans on the next query
+		set.Remove(bytes.Repeat([]byte{10}, 10))
+		if got := set.MaxMemberSize(); got != 7 {
+			t.Errorf("Expected the largest member to be 7 bytes after the removal, got %d", got)
+		}
+		set.Add(bytes.Repeat([]byte{12}, 12))
+		set.IterRemove(func(value []byte) bool { return len(value) == 7 })
+		if got := set.MaxMemberSize(); got != 12 {
+			t.Errorf("Expected the largest member to be 12 bytes, got %d", got)
+		}
+
+		set.Clear()
+		if got := set.MaxMemberSize(); got != 0 {
+			t.Errorf("Expected 0 after clear, got %d", got)
+		}
+	}
+}
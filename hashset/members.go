@@ -0,0 +1,145 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// Members format
+//
+//	magic    [4]byte "K4HM"
+//	version  uint8
+//	count    uvarint
+//	members  count x (uvarint length, bytes), sorted by bytes.Compare without duplicates
+//	checksum uint32  little endian crc32 (IEEE) of everything before it
+//
+// Only the membership is stored, no capacity, seed or bucket layout, so any set implementation can load it.
+const membersMagic = "K4HM"
+const membersVersion = 1
+
+// SerializeMembers encodes the sorted members of the set and nothing else, see the members format.
+// It is smaller than Serialize, which also stores empty buckets and the type of every element.
+func (h *HashSet) SerializeMembers() ([]byte, error) {
+	members := h.ToSortedSlice()
+
+	size := len(membersMagic) + 1 + binary.MaxVarintLen64 + 4
+	for _, member := range members {
+		size += binary.MaxVarintLen64 + len(member)
+	}
+
+	data := make([]byte, 0, size)
+	data = append(append(data, membersMagic...), membersVersion)
+	data = binary.AppendUvarint(data, uint64(len(members)))
+	for _, member := range members {
+		data = binary.AppendUvarint(data, uint64(len(member)))
+		data = append(data, member...)
+	}
+	return binary.LittleEndian.AppendUint32(data, crc32.ChecksumIEEE(data)), nil
+}
+
+// SnapshotCanonical writes the members format of the set to w, canonical bytes for content addressed snapshots:
+// sets with the same members write the same bytes whatever their seed, capacity, options or history.
+// The format records no capacity, so nothing needs trimming, DeserializeMembers sizes the decoded set to fit
+// its members as TrimToFit would. The set is only read.
+func (h *HashSet) SnapshotCanonical(w io.Writer) error {
+	members := h.ToSortedSlice()
+
+	crc := crc32.NewIEEE()
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+	header := append([]byte(membersMagic), membersVersion)
+	if _, err := bw.Write(binary.AppendUvarint(header, uint64(len(members)))); err != nil {
+		return err
+	}
+	for _, member := range members {
+		if err := writeBytes(bw, member); err != nil {
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := w.Write(binary.LittleEndian.AppendUint32(nil, crc.Sum32()))
+	return err
+}
+
+// DeserializeMembers decodes members encoded by SerializeMembers into a new set.
+// The capacity is the smallest that holds the members under the load factor threshold, so no resize takes place.
+func DeserializeMembers(data []byte) (*HashSet, error) {
+	if len(data) < len(membersMagic)+1+4 {
+		return nil, wrapf(ErrCorrupt, "corrupt hashset: %d bytes are too short", len(data))
+	}
+	if !bytes.Equal(data[:len(membersMagic)], []byte(membersMagic)) {
+		return nil, wrapf(ErrCorrupt, "corrupt hashset: invalid magic")
+	}
+	if version := data[len(membersMagic)]; version != membersVersion {
+		return nil, wrapf(ErrUnsupportedVersion, "unsupported members hashset version %d", version)
+	}
+
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return nil, wrapf(ErrChecksumMismatch, "corrupt hashset: checksum mismatch")
+	}
+	body = body[len(membersMagic)+1:]
+
+	count, n := binary.Uvarint(body)
+	if n <= 0 || count > uint64(len(body)) { // Every member takes at least its length byte
+		return nil, wrapf(ErrCorrupt, "corrupt hashset: invalid member count")
+	}
+	body = body[n:]
+
+	h := newHashSet(capacityFor(int(count)), defaultSeed)
+	var prev []byte
+	for i := uint64(0); i < count; i++ {
+		length, n := binary.Uvarint(body)
+		if n <= 0 || length > uint64(len(body)-n) {
+			return nil, wrapf(ErrCorrupt, "corrupt hashset: member %d is truncated", i)
+		}
+		member := bytes.Clone(body[n : n+int(length)])
+		body = body[n+int(length):]
+
+		if i > 0 && bytes.Compare(prev, member) >= 0 {
+			return nil, wrapf(ErrCorrupt, "corrupt hashset: member %d is out of order", i)
+		}
+		h.Add(member)
+		prev = member
+	}
+
+	if len(body) != 0 {
+		return nil, wrapf(ErrCorrupt, "corrupt hashset: %d trailing bytes", len(body))
+	}
+	return h, nil
+}
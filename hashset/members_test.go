@@ -0,0 +1,140 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"testing"
+)
+
+func TestHashSet_SerializeMembers(t *testing.T) {
+	set := NewHashSetWithCapacity(4096) // A sparse layout that must not be carried over
+	set.Add([]byte(""))
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("member%d", i)))
+	}
+
+	data, err := set.SerializeMembers()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	gob, _ := set.Serialize()
+	if len(data) >= len(gob) {
+		t.Errorf("Expected the members dump to be smaller than the gob dump, got %d and %d bytes", len(data), len(gob))
+	}
+
+	decoded, err := DeserializeMembers(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decoded.Size != 101 {
+		t.Errorf("Expected 101 members, got %d", decoded.Size)
+	}
+	if decoded.Capacity != capacityFor(101) {
+		t.Errorf("Expected the optimal capacity %d, got %d", capacityFor(101), decoded.Capacity)
+	}
+	if !decoded.Contains([]byte("")) {
+		t.Errorf("Expected the empty member to be decoded")
+	}
+	for i := 0; i < 100; i++ {
+		if !decoded.Contains([]byte(fmt.Sprintf("member%d", i))) {
+			t.Errorf("Expected member%d to be decoded", i)
+		}
+	}
+}
+
+func TestDeserializeMembersCorrupt(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("a"))
+	set.Add([]byte("b"))
+	data, _ := set.SerializeMembers()
+
+	flipped := append([]byte(nil), data...)
+	flipped[len(flipped)-6] ^= 0xff
+	if _, err := DeserializeMembers(flipped); err == nil {
+		t.Errorf("Expected an error for a checksum mismatch")
+	}
+	if _, err := DeserializeMembers(data[:6]); err == nil {
+		t.Errorf("Expected an error for truncated data")
+	}
+
+	// Members out of order are rejected even with a valid checksum
+	unsorted := []byte(membersMagic + "\x01\x02\x01b\x01a")
+	unsorted = binary.LittleEndian.AppendUint32(unsorted, crc32.ChecksumIEEE(unsorted))
+	if _, err := DeserializeMembers(unsorted); err == nil {
+		t.Errorf("Expected an error for unsorted members")
+	}
+}
+
+func TestHashSet_SnapshotCanonical(t *testing.T) {
+	a := NewHashSet()
+	b := mustHashSet(t, Options{Capacity: 4096, InsertionOrder: true})
+	b.Seed = 99
+	for i := 0; i < 500; i++ {
+		a.Add([]byte(fmt.Sprintf("test%d", i)))
+		b.Add([]byte(fmt.Sprintf("test%d", 499-i)))
+	}
+	b.Add([]byte("removed"))
+	b.Remove([]byte("removed"))
+
+	var snapA, snapB bytes.Buffer
+	if err := a.SnapshotCanonical(&snapA); err != nil {
+		t.Fatal(err)
+	}
+	generation := b.Generation()
+	if err := b.SnapshotCanonical(&snapB); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(snapA.Bytes(), snapB.Bytes()) {
+		t.Errorf("Expected sets with the same members to snapshot identically")
+	}
+	if b.Generation() != generation || b.Capacity != 4096 {
+		t.Errorf("Expected the snapshot not to modify the set")
+	}
+
+	data, err := a.SerializeMembers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(snapA.Bytes(), data) {
+		t.Errorf("Expected the snapshot to be the members format")
+	}
+	decoded, err := DeserializeMembers(snapB.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Size != 500 || decoded.Capacity != capacityFor(500) {
+		t.Errorf("Expected 500 members at the fitting capacity, got %d in %d buckets", decoded.Size, decoded.Capacity)
+	}
+}
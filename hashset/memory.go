@@ -0,0 +1,133 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"errors"
+	"runtime"
+)
+
+// ErrCapacityExceeded is returned by Add when a new element would take the set over its MaxMemory or MaxDistinct
+// limit, or finds no free bucket left in a full open addressing set.
+var ErrCapacityExceeded = errors.New("hashset: capacity exceeded")
+
+// ErrEmptyValue is returned by Add for an empty value under the RejectEmpty option.
+var ErrEmptyValue = errors.New("hashset: empty value")
+
+// ErrValueTooLarge is returned by Add for a value longer than the MaxValueLen option.
+var ErrValueTooLarge = errors.New("hashset: value too large")
+
+const bucketOverhead = 24       // slice header of a bucket
+const elementOverhead = 16 + 24 // interface in the bucket and the slice header it points to
+
+// elementCost estimates the memory held by a stored element.
+func elementCost(value []byte) int {
+	return elementOverhead + len(value)
+}
+
+// MemoryUsage estimates the memory held by the set in bytes.
+// It is cheap to call, the element total is kept up to date as elements are added and removed.
+func (h *HashSet) MemoryUsage() int {
+	return h.Capacity*bucketOverhead + h.memory
+}
+
+// recountMemory recomputes the element total, the incremental fingerprint and the length counts from the buckets.
+func (h *HashSet) recountMemory() {
+	h.memory = 0
+	h.contents = 0
+	h.lengths.clear()
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			h.memory += elementCost(item.([]byte))
+			h.toggleContent(item.([]byte))
+			h.lengths.add(item.([]byte))
+		}
+	}
+}
+
+// admit returns ErrCapacityExceeded if a new value would exceed the MaxMemory or MaxDistinct option or find
+// a full open addressing set, ErrEmptyValue if it is empty under the RejectEmpty option and ErrValueTooLarge
+// if it is longer than MaxValueLen.
+// A value refused by MaxDistinct is offered to the reservoir sample.
+func (h *HashSet) admit(value []byte) error {
+	if len(value) == 0 && h.opts.RejectEmpty {
+		return ErrEmptyValue
+	}
+	if h.opts.MaxValueLen > 0 && len(value) > h.opts.MaxValueLen {
+		return ErrValueTooLarge
+	}
+
+	if h.exceedsMemory(value) || h.full() {
+		return ErrCapacityExceeded
+	}
+
+	if h.opts.MaxDistinct > 0 && h.Size >= h.opts.MaxDistinct {
+		h.reservoir.offer(value)
+		return ErrCapacityExceeded
+	}
+	return nil
+}
+
+// exceedsMemory reports whether adding value would take the set over its MaxMemory limit.
+// The growth of the bucket array a resize triggered by the add would cause counts towards the limit.
+func (h *HashSet) exceedsMemory(value []byte) bool {
+	if h.opts.MaxMemory <= 0 {
+		return false
+	}
+
+	capacity := h.Capacity
+	if float64(h.Size+1)/float64(capacity) > h.ResizeThreshold() {
+		capacity, _ = growCapacity(capacity)
+	}
+
+	return capacity*bucketOverhead+h.memory+elementCost(value) > h.opts.MaxMemory
+}
+
+const warmupStride = 4096 // bytes between the reads of a long member, one per page
+
+// Warmup touches every bucket and member in one linear pass, faulting the pages of a set loaded from disk
+// or decoded by DeserializeZeroCopy from a memory-mapped file into memory ahead of the first lookups.
+// It only reads, so it may run concurrently with other readers, and returns the number of members touched.
+func (h *HashSet) Warmup() int {
+	touched := 0
+	var sum byte
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			value := item.([]byte)
+			for i := 0; i < len(value); i += warmupStride {
+				sum += value[i]
+			}
+			touched++
+		}
+	}
+	runtime.KeepAlive(sum) // Keeps the reads from being optimized away
+	return touched
+}
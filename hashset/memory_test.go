@@ -0,0 +1,182 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_MemoryUsage(t *testing.T) {
+	set := NewHashSet()
+	empty := set.MemoryUsage()
+
+	set.Add([]byte("test"))
+	if got := set.MemoryUsage(); got != empty+elementCost([]byte("test")) {
+		t.Errorf("Expected memory usage to grow by %d, got %d", elementCost([]byte("test")), got-empty)
+	}
+
+	set.Remove([]byte("test"))
+	if got := set.MemoryUsage(); got != empty {
+		t.Errorf("Expected memory usage to be %d after remove, got %d", empty, got)
+	}
+
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	// The running total must match a recount
+	running := set.MemoryUsage()
+	set.recountMemory()
+	if set.MemoryUsage() != running {
+		t.Errorf("Expected running memory usage %d to match the recount %d", running, set.MemoryUsage())
+	}
+}
+
+func TestHashSet_MaxMemory(t *testing.T) {
+	limit := 4096
+	set := mustHashSet(t, Options{MaxMemory: limit})
+
+	added := 0
+	var err error
+	for i := 0; err == nil; i++ {
+		var ok bool
+		ok, err = set.Add([]byte(fmt.Sprintf("test%d", i)))
+		if ok {
+			added++
+		}
+	}
+
+	if !errors.Is(err, ErrCapacityExceeded) {
+		t.Fatalf("Expected ErrCapacityExceeded, got %v", err)
+	}
+
+	if set.MemoryUsage() > limit {
+		t.Errorf("Expected memory usage to stay under %d, got %d", limit, set.MemoryUsage())
+	}
+
+	if set.Size != added {
+		t.Errorf("Expected existing members to be kept, got %d of %d", set.Size, added)
+	}
+
+	// Re-adding an existing member is not refused
+	if ok, err := set.Add([]byte("test0")); ok || err != nil {
+		t.Errorf("Expected existing member to be reported as present, got %v %v", ok, err)
+	}
+
+	// Removing frees room for a new member
+	set.Remove([]byte("test0"))
+	if ok, err := set.Add([]byte("new")); !ok || err != nil {
+		t.Errorf("Expected add after remove to succeed, got %v %v", ok, err)
+	}
+}
+
+func TestHashSet_MaxMemoryBatch(t *testing.T) {
+	values := make([][]byte, 5000)
+	for i := range values {
+		values[i] = []byte(fmt.Sprintf("test%05d", i)) // Sorted for AddSorted
+	}
+
+	// Adding one value at a time is the reference the batch paths must match
+	limit := 20000
+	serial := mustHashSet(t, Options{MaxMemory: limit})
+	for _, value := range values {
+		serial.Add(value)
+	}
+
+	inserts := map[string]func(set *HashSet) int{
+		"AddAll":         func(set *HashSet) int { return set.AddAll(values) },
+		"AddAllParallel": func(set *HashSet) int { return set.AddAllParallel(values, 4) },
+		"AddSorted":      func(set *HashSet) int { return set.AddSorted(values) },
+	}
+	for name, insert := range inserts {
+		set := mustHashSet(t, Options{MaxMemory: limit})
+		if added := insert(set); added != serial.Size {
+			t.Errorf("Expected %s to add %d values as Add does, got %d", name, serial.Size, added)
+		}
+		if set.MemoryUsage() > limit {
+			t.Errorf("Expected %s to stay under %d bytes, got %d", name, limit, set.MemoryUsage())
+		}
+
+		set = mustHashSet(t, Options{MaxDistinct: 100})
+		if added := insert(set); added != 100 || set.Capacity != capacityFor(100) {
+			t.Errorf("Expected %s to add 100 values at capacity %d, got %d at %d", name, capacityFor(100), added, set.Capacity)
+		}
+	}
+}
+
+func TestHashSet_TryAdd(t *testing.T) {
+	set := mustHashSet(t, Options{MaxMemory: 2048})
+
+	var err error
+	for i := 0; err == nil; i++ {
+		err = set.TryAdd([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	if !errors.Is(err, ErrCapacityExceeded) {
+		t.Errorf("Expected ErrCapacityExceeded, got %v", err)
+	}
+
+	if err := NewHashSet().TryAdd([]byte("test")); err != nil {
+		t.Errorf("Expected an unlimited set to accept the value, got %v", err)
+	}
+}
+
+func TestHashSet_Warmup(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	set.Add(make([]byte, 3*warmupStride)) // Spans several pages
+	set.Add(nil)
+
+	data, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DeserializeZeroCopy(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Warmup only reads, so it runs alongside lookups
+	done := make(chan int)
+	go func() { done <- decoded.Warmup() }()
+	for i := 0; i < 1000; i++ {
+		if !decoded.Contains([]byte(fmt.Sprintf("test%d", i))) {
+			t.Errorf("Expected test%d to be found", i)
+		}
+	}
+	if touched := <-done; touched != 1002 {
+		t.Errorf("Expected 1002 members touched, got %d", touched)
+	}
+}
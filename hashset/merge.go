@@ -0,0 +1,127 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// MergeSerialized returns the union of sets in the binary format of MarshalBinary, read from readers.
+// Members are streamed out of each input into the result, no input is held in memory as a whole.
+// An input failing its checksum fails the merge, after its members were read.
+func MergeSerialized(readers ...io.Reader) (*HashSet, error) {
+	h := NewHashSet()
+	for i, r := range readers {
+		if _, err := streamBinary(newChecksumReader(r), func(value []byte) {
+			h.Add(value)
+		}); err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+	}
+	return h, nil
+}
+
+// RemoveSerialized removes from the set every member of a set in the binary format of MarshalBinary, read from r,
+// and returns the number of elements removed. Members are streamed out of r one at a time, so subtracting a large
+// set on disk costs memory for a single member. An input failing its checksum returns the error after its members
+// were removed, the removals are not undone.
+func (h *HashSet) RemoveSerialized(r io.Reader) (int, error) {
+	h.checkMutable()
+
+	before := h.Size
+	_, err := streamBinary(newChecksumReader(r), func(value []byte) {
+		h.Remove(value)
+	})
+	return before - h.Size, err
+}
+
+// streamBinary reads a set in the binary format from br and calls fn with every member.
+// It returns the header fields of the set once the checksum is verified.
+func streamBinary(br *checksumReader, fn func(value []byte)) (SetInfo, error) {
+	var info SetInfo
+
+	header := make([]byte, len(binaryMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return info, err
+	}
+
+	if !bytes.Equal(header[:len(binaryMagic)], []byte(binaryMagic)) {
+		return info, wrapf(ErrCorrupt, "corrupt hashset: invalid magic")
+	}
+
+	// Version 1 has no hasher byte and is always murmur
+	info.Version, info.Hasher = header[len(binaryMagic)], hasherMurmur
+	switch info.Version {
+	case 1:
+	case binaryVersion:
+		id, err := br.ReadByte()
+		if err != nil {
+			return info, err
+		}
+		info.Hasher = id
+	default:
+		return info, wrapf(ErrUnsupportedVersion, "unsupported hashset version %d", info.Version)
+	}
+
+	var seed [8]byte
+	if _, err := io.ReadFull(br, seed[:]); err != nil {
+		return info, err
+	}
+	info.Seed = binary.LittleEndian.Uint64(seed[:])
+
+	capacity, err := binary.ReadUvarint(br)
+	if err != nil {
+		return info, err
+	}
+
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return info, err
+	}
+
+	if capacity > maxCapacity || size > maxCapacity {
+		return info, wrapf(ErrCorrupt, "corrupt hashset: invalid capacity %d for size %d", capacity, size)
+	}
+	info.Capacity, info.Size = int(capacity), int(size)
+
+	for i := uint64(0); i < size; i++ {
+		value, err := readBytes(br)
+		if err != nil {
+			return info, err
+		}
+		fn(value)
+	}
+
+	return info, br.verify()
+}
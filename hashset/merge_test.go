@@ -0,0 +1,129 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestMergeSerialized(t *testing.T) {
+	readers := make([]io.Reader, 3)
+	for i := range readers {
+		set := NewHashSet()
+		for j := 0; j < 100; j++ {
+			set.Add([]byte(fmt.Sprintf("test%d", j+i*50))) // Overlaps the next set by half
+		}
+
+		data, err := set.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to marshal: %v", err)
+		}
+		readers[i] = bytes.NewReader(data)
+	}
+
+	merged, err := MergeSerialized(readers...)
+	if err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+
+	if merged.Size != 200 {
+		t.Errorf("Expected merged size to be 200, got %d", merged.Size)
+	}
+
+	for j := 0; j < 200; j++ {
+		if !merged.Contains([]byte(fmt.Sprintf("test%d", j))) {
+			t.Errorf("Expected merged set to contain test%d", j)
+		}
+	}
+}
+
+func TestMergeSerializedCorrupt(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("test"))
+
+	data, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	corrupt := bytes.Clone(data)
+	corrupt[len(corrupt)-6] ^= 0xff // Inside the member bytes
+
+	if _, err := MergeSerialized(bytes.NewReader(data), bytes.NewReader(corrupt)); err == nil {
+		t.Errorf("Expected a corrupt input to fail the merge")
+	}
+
+	if _, err := MergeSerialized(bytes.NewReader(data[:len(data)-1])); err == nil {
+		t.Errorf("Expected a truncated input to fail the merge")
+	}
+
+	if merged, err := MergeSerialized(); err != nil || merged.Size != 0 {
+		t.Errorf("Expected merging nothing to return an empty set, got %v", err)
+	}
+}
+
+func TestHashSet_RemoveSerialized(t *testing.T) {
+	live := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		live.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	compacted := NewHashSet()
+	for i := 500; i < 1500; i++ {
+		compacted.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	data, err := compacted.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := live.RemoveSerialized(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if removed != 500 || live.Size != 500 {
+		t.Errorf("Expected 500 elements removed and 500 left, got %d and %d", removed, live.Size)
+	}
+	if !live.Contains([]byte("test499")) || live.Contains([]byte("test500")) {
+		t.Errorf("Expected only the members of the input to be removed")
+	}
+
+	data[len(data)-1] ^= 0xff
+	if _, err := live.RemoveSerialized(bytes.NewReader(data)); err == nil {
+		t.Errorf("Expected an error for a corrupt checksum")
+	}
+	if _, err := live.RemoveSerialized(io.LimitReader(bytes.NewReader(data), 20)); err == nil {
+		t.Errorf("Expected an error for a truncated input")
+	}
+}
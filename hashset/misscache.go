@@ -0,0 +1,93 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import "container/list"
+
+// missCache remembers the most recent values Contains found absent, evicting the least recently used.
+// A nil missCache remembers nothing.
+type missCache struct {
+	limit   int                      // Number of misses remembered
+	entries *list.List               // Missed values, most recently used first
+	index   map[string]*list.Element // Entry lookup by value
+}
+
+// newMissCache creates a missCache remembering up to limit misses, or nil if limit is not positive.
+func newMissCache(limit int) *missCache {
+	if limit <= 0 {
+		return nil
+	}
+	return &missCache{
+		limit:   limit,
+		entries: list.New(),
+		index:   make(map[string]*list.Element, limit),
+	}
+}
+
+// has reports whether value is a remembered miss, marking it recently used.
+func (c *missCache) has(value []byte) bool {
+	if c == nil {
+		return false
+	}
+
+	e, ok := c.index[string(value)]
+	if ok {
+		c.entries.MoveToFront(e)
+	}
+	return ok
+}
+
+// add remembers value as a miss, evicting the least recently used miss when full.
+func (c *missCache) add(value []byte) {
+	if c == nil {
+		return
+	}
+
+	if c.entries.Len() >= c.limit {
+		oldest := c.entries.Back()
+		c.entries.Remove(oldest)
+		delete(c.index, oldest.Value.(string))
+	}
+	key := string(value)
+	c.index[key] = c.entries.PushFront(key)
+}
+
+// remove forgets value, it is called when value is added to the set.
+func (c *missCache) remove(value []byte) {
+	if c == nil {
+		return
+	}
+
+	if e, ok := c.index[string(value)]; ok {
+		c.entries.Remove(e)
+		delete(c.index, string(value))
+	}
+}
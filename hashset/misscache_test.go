@@ -0,0 +1,91 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_MissCache(t *testing.T) {
+	set := mustHashSet(t, Options{MissCache: 2})
+	set.Add([]byte("present"))
+
+	if set.Contains([]byte("absent")) {
+		t.Fatalf("Expected absent not to be found")
+	}
+	if !set.misses.has([]byte("absent")) {
+		t.Errorf("Expected the miss to be remembered")
+	}
+	if !set.Contains([]byte("present")) || set.misses.has([]byte("present")) {
+		t.Errorf("Expected hits not to be remembered as misses")
+	}
+
+	// Adding a remembered miss forgets it
+	set.Add([]byte("absent"))
+	if set.misses.has([]byte("absent")) {
+		t.Errorf("Expected the miss to be forgotten once added")
+	}
+	if !set.Contains([]byte("absent")) {
+		t.Errorf("Expected absent to be found once added")
+	}
+}
+
+func TestHashSet_MissCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	set := mustHashSet(t, Options{MissCache: 2})
+
+	set.Contains([]byte("a"))
+	set.Contains([]byte("b"))
+	set.Contains([]byte("a")) // a is now the most recently used
+	set.Contains([]byte("c")) // evicts b
+
+	for value, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if set.misses.has([]byte(value)) != want {
+			t.Errorf("Expected %s remembered to be %v", value, want)
+		}
+	}
+	if set.misses.entries.Len() != 2 {
+		t.Errorf("Expected 2 remembered misses, got %d", set.misses.entries.Len())
+	}
+}
+
+func TestHashSet_MissCacheAcrossResize(t *testing.T) {
+	set := mustHashSet(t, Options{MissCache: 16})
+	for i := 0; i < 100; i++ {
+		set.Contains([]byte(fmt.Sprintf("value%d", i)))
+		set.Add([]byte(fmt.Sprintf("value%d", i)))
+	}
+	for i := 0; i < 100; i++ {
+		if !set.Contains([]byte(fmt.Sprintf("value%d", i))) {
+			t.Errorf("Expected value%d to be found", i)
+		}
+	}
+}
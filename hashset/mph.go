@@ -0,0 +1,316 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"slices"
+)
+
+// Minimal perfect hash format
+//
+//	magic     [4]byte "K4MP"
+//	version   uint8
+//	hasher    uint8   hasher of the build, which computes the digests
+//	threshold uint64  little endian FingerprintThreshold of the set, long values are looked up by fingerprint
+//	seed      uint64  little endian seed of the digests
+//	count     uint64  little endian number of members, and of slots
+//	buckets   uint64  little endian number of displacement buckets
+//	checksum  uint32  little endian crc32 (IEEE) of the header fields above
+//	displace  buckets x uint32 little endian displacement of each bucket
+//	offsets   (count + 1) x uint64 little endian, the member in slot i spans [offsets[i], offsets[i+1]) of the data
+//	data      members in slot order, concatenated
+//
+// A member is hashed once to a digest. The digest picks a bucket, whose displacement picks the slot of the member
+// among count slots, so a lookup reads one displacement, two offsets and compares one member.
+const mphMagic = "K4MP"
+const mphVersion = 1
+const mphHeaderLen = len(mphMagic) + 2 + 8 + 8 + 8 + 8 + 4 // magic, version, hasher, threshold, seed, count, buckets and checksum
+
+const mphBucketSize = 4                        // Members per displacement bucket on average
+const mphMaxDisplacement = 1 << 20             // Displacements tried for a bucket before picking another seed
+const mphAttempts = 16                         // Seeds tried before the build fails
+const mphSeed = 0x51afd7ed558ccd5b             // Seed of the first attempt
+const mphSeedStep = 0x9e3779b97f4a7c15         // Added to the seed on every further attempt
+const mphDisplacementStep = 0xc2b2ae3d27d4eb4f // Spreads consecutive displacements over the digest space
+
+// FrozenSet is a read-only set placing its members with a minimal perfect hash, see HashSet.BuildMPH.
+// Every member has a slot of its own, so Contains hashes once and compares one member, without chains.
+// A FrozenSet reads all of its state from its encoding, which may be a memory mapped file, see OpenFrozenSet.
+// It is safe for concurrent use.
+type FrozenSet struct {
+	encoded   []byte // Encoding the lookups read from, never written to
+	threshold int    // FingerprintThreshold of the set the members come from
+	seed      uint64 // Seed of the digests
+	count     int    // Number of members and slots
+	buckets   int    // Number of displacement buckets
+	displace  []byte // Displacement of each bucket
+	offsets   []byte // Spans of the members in slot order
+	data      []byte // Member bytes
+}
+
+// mphSlot returns the slot of a digest among count slots, for the displacement of its bucket.
+func mphSlot(digest uint64, displacement uint32, count int) int {
+	// splitmix64 finalizer
+	digest += uint64(displacement) * mphDisplacementStep
+	digest ^= digest >> 30
+	digest *= 0xbf58476d1ce4e5b9
+	digest ^= digest >> 27
+	digest *= 0x94d049bb133111eb
+	digest ^= digest >> 31
+	return int(digest % uint64(count))
+}
+
+// BuildMPH builds a FrozenSet of the members with a minimal perfect hash, trading build time for lookups of
+// a single hash and comparison. Members are kept in stored form, see the FingerprintThreshold option.
+// Building takes expected time linear in the size. It fails with ErrInvalidOption in the vanishingly unlikely
+// case that no seed tried separates the members, which only happens if digests collide for every seed.
+func (h *HashSet) BuildMPH() (*FrozenSet, error) {
+	members := make([][]byte, 0, h.Size)
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			members = append(members, item.([]byte))
+		}
+	}
+
+	buckets := max(1, (len(members)+mphBucketSize-1)/mphBucketSize)
+	for attempt := uint64(0); attempt < mphAttempts; attempt++ {
+		seed := mphSeed + attempt*mphSeedStep
+		if slots, displace, ok := placeMPH(members, seed, buckets); ok {
+			return newFrozenSet(encodeMPH(slots, displace, seed, h.opts.FingerprintThreshold))
+		}
+	}
+	return nil, wrapf(ErrInvalidOption, "hashset: no minimal perfect hash found for %d members", len(members))
+}
+
+// placeMPH assigns every member a distinct slot, placing the largest buckets first while most slots are free.
+// It returns the members in slot order and the displacement of every bucket, or false if a bucket could not be placed.
+func placeMPH(members [][]byte, seed uint64, buckets int) ([][]byte, []uint32, bool) {
+	digests := make([]uint64, len(members))
+	groups := make([][]int, buckets)
+	for i, member := range members {
+		digests[i] = hash64(member, seed)
+		b := digestIndex(digests[i], buckets)
+		groups[b] = append(groups[b], i)
+	}
+
+	order := make([]int, buckets)
+	for b := range order {
+		order[b] = b
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return len(groups[b]) - len(groups[a]) // Largest first
+	})
+
+	slots := make([][]byte, len(members))
+	taken := make([]bool, len(members))
+	displace := make([]uint32, buckets)
+	var placed []int
+	for _, b := range order {
+		if len(groups[b]) == 0 {
+			break // Only empty buckets are left
+		}
+
+		found := false
+		for d := uint32(0); d < mphMaxDisplacement && !found; d++ {
+			placed = placed[:0]
+			found = true
+			for _, i := range groups[b] {
+				slot := mphSlot(digests[i], d, len(members))
+				if taken[slot] {
+					found = false
+					break
+				}
+				taken[slot] = true // Claimed so another member of the bucket cannot take it
+				placed = append(placed, slot)
+			}
+			if !found {
+				for _, slot := range placed {
+					taken[slot] = false
+				}
+				continue
+			}
+
+			displace[b] = d
+			for k, i := range groups[b] {
+				slots[placed[k]] = members[i]
+			}
+		}
+		if !found {
+			return nil, nil, false
+		}
+	}
+	return slots, displace, true
+}
+
+// encodeMPH encodes the members in slot order in the minimal perfect hash format.
+func encodeMPH(slots [][]byte, displace []uint32, seed uint64, threshold int) []byte {
+	size := mphHeaderLen + 4*len(displace) + 8*(len(slots)+1)
+	for _, member := range slots {
+		size += len(member)
+	}
+
+	buf := make([]byte, 0, size)
+	buf = append(buf, mphMagic...)
+	buf = append(buf, mphVersion, hasherID) // Digests use the hasher of the build
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(max(threshold, 0)))
+	buf = binary.LittleEndian.AppendUint64(buf, seed)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(slots)))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(displace)))
+	buf = binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+
+	for _, d := range displace {
+		buf = binary.LittleEndian.AppendUint32(buf, d)
+	}
+	offset := uint64(0)
+	buf = binary.LittleEndian.AppendUint64(buf, offset)
+	for _, member := range slots {
+		offset += uint64(len(member))
+		buf = binary.LittleEndian.AppendUint64(buf, offset)
+	}
+	for _, member := range slots {
+		buf = append(buf, member...)
+	}
+	return buf
+}
+
+// OpenFrozenSet returns the FrozenSet encoded in data, written by FrozenSet.WriteTo. The set reads from data without
+// copying it, so data can be a memory mapped file, and it must not be modified while the set is in use.
+// The header and the member offsets are checked, which reads the offsets once.
+func OpenFrozenSet(data []byte) (*FrozenSet, error) {
+	return newFrozenSet(data)
+}
+
+// newFrozenSet checks the encoding in data and returns the FrozenSet reading from it.
+func newFrozenSet(data []byte) (*FrozenSet, error) {
+	if len(data) < mphHeaderLen {
+		return nil, wrapf(ErrCorrupt, "corrupt frozen set: %d bytes is shorter than the header", len(data))
+	}
+
+	if !bytes.Equal(data[:len(mphMagic)], []byte(mphMagic)) {
+		return nil, wrapf(ErrCorrupt, "corrupt frozen set: invalid magic")
+	}
+
+	if version := data[len(mphMagic)]; version != mphVersion {
+		return nil, wrapf(ErrUnsupportedVersion, "unsupported frozen set version %d", version)
+	}
+
+	body := data[:mphHeaderLen-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(body):]) {
+		return nil, wrapf(ErrChecksumMismatch, "corrupt frozen set: checksum mismatch")
+	}
+
+	if err := checkHasher(data[len(mphMagic)+1]); err != nil {
+		return nil, err
+	}
+
+	threshold := binary.LittleEndian.Uint64(data[len(mphMagic)+2:])
+	seed := binary.LittleEndian.Uint64(data[len(mphMagic)+10:])
+	count := binary.LittleEndian.Uint64(data[len(mphMagic)+18:])
+	buckets := binary.LittleEndian.Uint64(data[len(mphMagic)+26:])
+
+	if threshold > uint64(maxCapacity) {
+		return nil, wrapf(ErrCorrupt, "corrupt frozen set: invalid fingerprint threshold %d", threshold)
+	}
+
+	// The displacements and offsets must fit in the data
+	rest := uint64(len(data) - mphHeaderLen)
+	if buckets == 0 || buckets > rest/4 || count >= (rest-4*buckets)/8 {
+		return nil, wrapf(ErrCorrupt, "corrupt frozen set: %d members in %d buckets exceed the data", count, buckets)
+	}
+
+	f := &FrozenSet{
+		encoded:   data,
+		threshold: int(threshold),
+		seed:      seed,
+		count:     int(count),
+		buckets:   int(buckets),
+	}
+	f.displace = data[mphHeaderLen : mphHeaderLen+4*f.buckets]
+	f.offsets = data[mphHeaderLen+4*f.buckets : mphHeaderLen+4*f.buckets+8*(f.count+1)]
+	f.data = data[mphHeaderLen+4*f.buckets+8*(f.count+1):]
+
+	// Offsets must grow from 0 to the end of the data, so lookups never read out of range
+	previous := uint64(0)
+	for i := 0; i <= f.count; i++ {
+		offset := binary.LittleEndian.Uint64(f.offsets[8*i:])
+		if offset < previous || (i == 0 && offset != 0) {
+			return nil, wrapf(ErrCorrupt, "corrupt frozen set: offset %d of member %d out of order", offset, i)
+		}
+		previous = offset
+	}
+	if previous != uint64(len(f.data)) {
+		return nil, wrapf(ErrCorrupt, "corrupt frozen set: members span %d of %d data bytes", previous, len(f.data))
+	}
+	return f, nil
+}
+
+// Len returns the number of members.
+func (f *FrozenSet) Len() int {
+	return f.count
+}
+
+// Contains checks if an element is in the set, hashing it once and comparing it with the one member in its slot.
+func (f *FrozenSet) Contains(value []byte) bool {
+	if f.count == 0 {
+		return false
+	}
+	value = storedKey(value, f.threshold)
+
+	digest := hash64(value, f.seed)
+	b := digestIndex(digest, f.buckets)
+	slot := mphSlot(digest, binary.LittleEndian.Uint32(f.displace[4*b:]), f.count)
+
+	start := binary.LittleEndian.Uint64(f.offsets[8*slot:])
+	end := binary.LittleEndian.Uint64(f.offsets[8*slot+8:])
+	return bytes.Equal(f.data[start:end], value)
+}
+
+// ForEach calls fn for every member in slot order, stopping when fn returns false.
+// The members share the encoding and must not be modified.
+func (f *FrozenSet) ForEach(fn func(value []byte) bool) {
+	for slot := 0; slot < f.count; slot++ {
+		start := binary.LittleEndian.Uint64(f.offsets[8*slot:])
+		end := binary.LittleEndian.Uint64(f.offsets[8*slot+8:])
+		if !fn(f.data[start:end:end]) {
+			return
+		}
+	}
+}
+
+// WriteTo writes the encoding of the set to w, for OpenFrozenSet to map it back.
+func (f *FrozenSet) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(f.encoded)
+	return int64(n), err
+}
@@ -0,0 +1,148 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_BuildMPH(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 10000; i++ {
+		set.Add([]byte(fmt.Sprintf("key%d", i)))
+	}
+
+	frozen, err := set.BuildMPH()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if frozen.Len() != 10000 {
+		t.Errorf("Expected 10000 members, got %d", frozen.Len())
+	}
+	for i := 0; i < 10000; i++ {
+		if !frozen.Contains([]byte(fmt.Sprintf("key%d", i))) {
+			t.Fatalf("Expected key%d to be found", i)
+		}
+		if frozen.Contains([]byte(fmt.Sprintf("absent%d", i))) {
+			t.Fatalf("Expected absent%d not to be found", i)
+		}
+	}
+
+	seen := 0
+	frozen.ForEach(func(value []byte) bool {
+		if !set.Contains(value) {
+			t.Errorf("Expected %q to be a member of the source set", value)
+		}
+		seen++
+		return true
+	})
+	if seen != 10000 {
+		t.Errorf("Expected ForEach to visit 10000 members, got %d", seen)
+	}
+
+	var buf bytes.Buffer
+	if _, err := frozen.WriteTo(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	opened, err := OpenFrozenSet(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if opened.Len() != 10000 || !opened.Contains([]byte("key42")) || opened.Contains([]byte("key10000")) {
+		t.Errorf("Expected the opened set to answer like the built one")
+	}
+}
+
+func TestHashSet_BuildMPHSmall(t *testing.T) {
+	empty, err := NewHashSet().BuildMPH()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if empty.Len() != 0 || empty.Contains(nil) {
+		t.Errorf("Expected an empty frozen set")
+	}
+
+	set := mustHashSet(t, Options{FingerprintThreshold: 16})
+	long := bytes.Repeat([]byte("x"), 100)
+	set.Add(long)
+	set.Add(nil)
+	frozen, err := set.BuildMPH()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !frozen.Contains(long) || !frozen.Contains([]byte{}) || frozen.Contains([]byte("x")) {
+		t.Errorf("Expected long values to be looked up by fingerprint and the empty member to be found")
+	}
+}
+
+func TestOpenFrozenSetCorrupt(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("key%d", i)))
+	}
+	frozen, err := set.BuildMPH()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	frozen.WriteTo(&buf)
+	data := buf.Bytes()
+
+	if _, err := OpenFrozenSet(data[:mphHeaderLen-1]); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt for a truncated header, got %v", err)
+	}
+	if _, err := OpenFrozenSet(data[:len(data)-1]); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt for truncated data, got %v", err)
+	}
+
+	tampered := bytes.Clone(data)
+	tampered[len(mphMagic)+18]++ // Count
+	if _, err := OpenFrozenSet(tampered); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch for a modified header, got %v", err)
+	}
+
+	tampered = bytes.Clone(data)
+	offsets := mphHeaderLen + 4*frozen.buckets
+	binary.LittleEndian.PutUint64(tampered[offsets+8:], 1<<40)
+	if _, err := OpenFrozenSet(tampered); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt for an out of range offset, got %v", err)
+	}
+
+	tampered = bytes.Clone(data)
+	tampered[len(mphMagic)] = 99
+	if _, err := OpenFrozenSet(tampered); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion, got %v", err)
+	}
+}
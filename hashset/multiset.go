@@ -0,0 +1,158 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import "maps"
+
+// multisetCounts holds the occurrence counts of the elements of a multiset.
+// A nil multisetCounts counts every element once.
+type multisetCounts struct {
+	counts map[string]uint64 // Occurrences of each element
+}
+
+// newMultisetCounts creates an empty multisetCounts.
+func newMultisetCounts() *multisetCounts {
+	return &multisetCounts{
+		counts: make(map[string]uint64),
+	}
+}
+
+// clone returns an independent copy of the counts.
+func (m *multisetCounts) clone() *multisetCounts {
+	if m == nil {
+		return nil
+	}
+	return &multisetCounts{counts: maps.Clone(m.counts)}
+}
+
+// get returns the occurrences of a present value.
+func (m *multisetCounts) get(value []byte) uint64 {
+	if m == nil {
+		return 1
+	}
+	return m.counts[string(value)]
+}
+
+// add changes the occurrences of a present value by n.
+func (m *multisetCounts) add(value []byte, n int64) {
+	if m == nil {
+		return
+	}
+	m.counts[string(value)] = uint64(int64(m.counts[string(value)]) + n)
+}
+
+// remove forgets the count of value.
+func (m *multisetCounts) remove(value []byte) {
+	if m == nil {
+		return
+	}
+	delete(m.counts, string(value))
+}
+
+// clear forgets every count.
+func (m *multisetCounts) clear() {
+	if m == nil {
+		return
+	}
+	clear(m.counts)
+}
+
+// Count returns the number of occurrences of value, 0 if it is not in the set.
+// Without the Multiset option every element occurs once.
+func (h *HashSet) Count(value []byte) uint64 {
+	value = h.key(value)
+	if !h.has(value) {
+		return 0
+	}
+	return h.counts.get(value)
+}
+
+// MergeCounts adds the occurrences of every element of other to the set, which must have the Multiset option.
+// Elements of other occur once each if other is not a multiset. OnMutate is called once per element.
+func (h *HashSet) MergeCounts(other *HashSet) {
+	h.checkMutable()
+	h.checkMultiset("MergeCounts")
+
+	for _, bucket := range other.Buckets {
+		for _, item := range bucket {
+			n := other.counts.get(item.([]byte))
+			value := h.key(item.([]byte)) // Stored form in this set
+
+			if h.has(value) {
+				h.notify(OpAdd, value)
+				h.counts.add(value, int64(n))
+				continue
+			}
+
+			h.Add(value)
+			h.counts.add(value, int64(n)-1) // Add counted one occurrence
+		}
+	}
+}
+
+// SubtractCounts subtracts the occurrences of every element of other from the set, which must have the
+// Multiset option. Counts are clamped at zero, elements whose count reaches zero are removed.
+// Elements of other occur once each if other is not a multiset. OnMutate is called once per element.
+func (h *HashSet) SubtractCounts(other *HashSet) {
+	h.checkMutable()
+	h.checkMultiset("SubtractCounts")
+
+	if other == h {
+		h.Clear() // Every count reaches zero
+		return
+	}
+
+	for _, bucket := range other.Buckets {
+		for _, item := range bucket {
+			n := other.counts.get(item.([]byte))
+			value := h.key(item.([]byte)) // Stored form in this set
+			if !h.has(value) {
+				continue
+			}
+
+			if n < h.counts.get(value) {
+				h.notify(OpRemove, value)
+				h.counts.add(value, -int64(n))
+				continue
+			}
+
+			h.counts.add(value, 1-int64(h.counts.get(value))) // Leave one occurrence for Remove to drop
+			h.Remove(value)
+		}
+	}
+}
+
+// checkMultiset panics if the set was not created with the Multiset option.
+func (h *HashSet) checkMultiset(method string) {
+	if h.counts == nil {
+		panic("hashset: " + method + " requires the Multiset option")
+	}
+}
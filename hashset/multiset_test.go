@@ -0,0 +1,123 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import "testing"
+
+func TestHashSet_Multiset(t *testing.T) {
+	set := mustHashSet(t, Options{Multiset: true})
+	set.Add([]byte("a"))
+	set.Add([]byte("a"))
+	set.Add([]byte("b"))
+
+	if set.Count([]byte("a")) != 2 || set.Count([]byte("b")) != 1 || set.Count([]byte("c")) != 0 {
+		t.Errorf("Expected counts 2, 1 and 0, got %d %d %d",
+			set.Count([]byte("a")), set.Count([]byte("b")), set.Count([]byte("c")))
+	}
+
+	if set.Size != 2 {
+		t.Errorf("Expected 2 distinct elements, got %d", set.Size)
+	}
+
+	set.Remove([]byte("a"))
+	if !set.Contains([]byte("a")) || set.Count([]byte("a")) != 1 {
+		t.Errorf("Expected one occurrence of a to remain")
+	}
+
+	set.Remove([]byte("a"))
+	if set.Contains([]byte("a")) {
+		t.Errorf("Expected a to leave the set with its last occurrence")
+	}
+}
+
+func TestHashSet_MergeCounts(t *testing.T) {
+	a := mustHashSet(t, Options{Multiset: true})
+	a.Add([]byte("x"))
+	a.Add([]byte("x"))
+
+	b := mustHashSet(t, Options{Multiset: true})
+	for i := 0; i < 3; i++ {
+		b.Add([]byte("x"))
+	}
+	b.Add([]byte("y"))
+
+	a.MergeCounts(b)
+	if a.Count([]byte("x")) != 5 || a.Count([]byte("y")) != 1 {
+		t.Errorf("Expected counts 5 and 1, got %d %d", a.Count([]byte("x")), a.Count([]byte("y")))
+	}
+
+	// A plain set counts every element once
+	plain := NewHashSet()
+	plain.Add([]byte("y"))
+	a.MergeCounts(plain)
+	if a.Count([]byte("y")) != 2 {
+		t.Errorf("Expected count 2, got %d", a.Count([]byte("y")))
+	}
+}
+
+func TestHashSet_SubtractCounts(t *testing.T) {
+	a := mustHashSet(t, Options{Multiset: true})
+	for i := 0; i < 5; i++ {
+		a.Add([]byte("x"))
+	}
+	a.Add([]byte("y"))
+
+	b := mustHashSet(t, Options{Multiset: true})
+	b.Add([]byte("x"))
+	b.Add([]byte("x"))
+	for i := 0; i < 3; i++ {
+		b.Add([]byte("y")) // More than a holds, clamped at zero
+	}
+	b.Add([]byte("z"))
+
+	a.SubtractCounts(b)
+	if a.Count([]byte("x")) != 3 {
+		t.Errorf("Expected count 3, got %d", a.Count([]byte("x")))
+	}
+
+	if a.Contains([]byte("y")) || a.Size != 1 {
+		t.Errorf("Expected y to be removed once its count reached zero")
+	}
+
+	a.SubtractCounts(a)
+	if a.Size != 0 {
+		t.Errorf("Expected subtracting a set from itself to empty it")
+	}
+}
+
+func TestHashSet_MergeCountsRequiresMultiset(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected MergeCounts to panic without Multiset")
+		}
+	}()
+	NewHashSet().MergeCounts(NewHashSet())
+}
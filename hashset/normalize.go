@@ -0,0 +1,179 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"maps"
+	"sync"
+)
+
+var normalizersLock sync.RWMutex                              // Guards normalizers and namedNormalizers
+var normalizers = map[uint8]func(value []byte) []byte{}       // Normalize functions sets can select, by ID
+var namedNormalizers = map[string]func(value []byte) []byte{} // Normalize functions sets can select, by name
+
+// RegisterNormalizerID makes normalize available as the Normalize function of the sets created or decoded with
+// the NormalizerID option id. It returns an error if id is 0 or another normalizer is already registered under it.
+func RegisterNormalizerID(id uint8, normalize func(value []byte) []byte) error {
+	if id == 0 || normalize == nil {
+		return wrapf(ErrInvalidOption, "hashset: normalizers need a nonzero ID and a function")
+	}
+
+	normalizersLock.Lock()
+	defer normalizersLock.Unlock()
+
+	if _, ok := normalizers[id]; ok {
+		return wrapf(ErrInvalidOption, "hashset normalizer %d is already registered", id)
+	}
+	normalizers[id] = normalize
+	return nil
+}
+
+// RegisterNormalizer makes normalize available as the Normalize function of the sets created or decoded
+// with the NormalizerName option name. Names do not collide the way one byte IDs of independent packages can.
+// It returns an error if name is empty or another normalizer is already registered under it.
+func RegisterNormalizer(name string, normalize func(value []byte) []byte) error {
+	if name == "" || normalize == nil {
+		return wrapf(ErrInvalidOption, "hashset: named normalizers need a name and a function")
+	}
+
+	normalizersLock.Lock()
+	defer normalizersLock.Unlock()
+
+	if _, ok := namedNormalizers[name]; ok {
+		return wrapf(ErrInvalidOption, "hashset normalizer %q is already registered", name)
+	}
+	namedNormalizers[name] = normalize
+	return nil
+}
+
+// lookupNamedNormalizer returns the normalizer registered under name.
+func lookupNamedNormalizer(name string) (func(value []byte) []byte, bool) {
+	normalizersLock.RLock()
+	defer normalizersLock.RUnlock()
+
+	normalize, ok := namedNormalizers[name]
+	return normalize, ok
+}
+
+// selectedNormalizer returns the registered normalizer the NormalizerID or NormalizerName option selects,
+// or nil if neither is set.
+func (opts Options) selectedNormalizer() func(value []byte) []byte {
+	if opts.NormalizerName != "" {
+		normalize, _ := lookupNamedNormalizer(opts.NormalizerName)
+		return normalize
+	}
+	normalize, _ := lookupNormalizer(opts.NormalizerID)
+	return normalize
+}
+
+// lookupNormalizer returns the normalizer registered under id.
+func lookupNormalizer(id uint8) (func(value []byte) []byte, bool) {
+	normalizersLock.RLock()
+	defer normalizersLock.RUnlock()
+
+	normalize, ok := normalizers[id]
+	return normalize, ok
+}
+
+// originalForms keeps the form every element was first added in, for the Normalize option.
+// A nil originalForms keeps nothing.
+type originalForms struct {
+	values map[string][]byte // Original forms by stored form
+}
+
+// newOriginalForms creates an empty originalForms.
+func newOriginalForms() *originalForms {
+	return &originalForms{
+		values: make(map[string][]byte),
+	}
+}
+
+// clone returns an independent copy of the original forms, sharing their bytes.
+func (o *originalForms) clone() *originalForms {
+	if o == nil {
+		return nil
+	}
+	return &originalForms{values: maps.Clone(o.values)}
+}
+
+// add records original as the form the element stored as value was added in.
+func (o *originalForms) add(value, original []byte) {
+	if o == nil {
+		return
+	}
+	o.values[string(value)] = original
+}
+
+// get returns the form the element stored as value was added in.
+func (o *originalForms) get(value []byte) ([]byte, bool) {
+	if o == nil {
+		return nil, false
+	}
+	original, ok := o.values[string(value)]
+	return original, ok
+}
+
+// remove forgets the original form of value.
+func (o *originalForms) remove(value []byte) {
+	if o == nil {
+		return
+	}
+	delete(o.values, string(value))
+}
+
+// clear forgets every original form.
+func (o *originalForms) clear() {
+	if o == nil {
+		return
+	}
+	clear(o.values)
+}
+
+// Get returns the stored element matching value and whether there is one.
+// Under the Normalize option it is the element as it was first added, so Get("FOO") returns "Foo" under
+// a lowercasing normalizer. Otherwise it is the stored instance, or the fingerprint of a long value,
+// see the FingerprintThreshold option. Expired elements are absent, see the TTL option.
+func (h *HashSet) Get(value []byte) ([]byte, bool) {
+	stored := h.key(value)     // Compute the stored form
+	digest := h.digest(stored) // Compute the digest
+	if !h.bloom.mayContain(digest) || h.times.expired(stored) {
+		return nil, false
+	}
+
+	index, i := h.locate(digest, stored)
+	if i < 0 {
+		return nil, false
+	}
+	if original, ok := h.originals.get(stored); ok {
+		return original, true
+	}
+	return h.Buckets[index][i].([]byte), true
+}
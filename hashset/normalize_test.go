@@ -0,0 +1,191 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestHashSet_Get(t *testing.T) {
+	set := NewHashSet()
+	value := []byte("stored")
+	set.Add(value)
+
+	got, ok := set.Get([]byte("stored"))
+	if !ok || &got[0] != &value[0] {
+		t.Errorf("Expected Get to return the stored instance")
+	}
+	if _, ok := set.Get([]byte("missing")); ok {
+		t.Errorf("Expected Get to miss an absent value")
+	}
+}
+
+func TestHashSet_Normalize(t *testing.T) {
+	set := mustHashSet(t, Options{Normalize: bytes.ToLower})
+	set.Add([]byte("Foo"))
+
+	if ok, _ := set.Add([]byte("FOO")); ok {
+		t.Errorf("Expected FOO to match the normalized Foo")
+	}
+	if !set.Contains([]byte("fOo")) {
+		t.Errorf("Expected lookups to be normalized")
+	}
+
+	got, ok := set.Get([]byte("FOO"))
+	if !ok || string(got) != "Foo" {
+		t.Errorf("Expected Get to return the original Foo, got %q", got)
+	}
+
+	set.Remove([]byte("FOO"))
+	if set.Contains([]byte("foo")) || set.Size != 0 {
+		t.Errorf("Expected removal to be normalized")
+	}
+	if _, ok := set.originals.get([]byte("foo")); ok {
+		t.Errorf("Expected the original form to be forgotten on removal")
+	}
+
+	// Clear keeps the normalizer
+	set.Add([]byte("Bar"))
+	set.Clear()
+	set.Add([]byte("BAZ"))
+	if got, _ := set.Get([]byte("baz")); string(got) != "BAZ" {
+		t.Errorf("Expected the normalizer to survive Clear, got %q", got)
+	}
+}
+
+func TestHashSet_NormalizerID(t *testing.T) {
+	if err := RegisterNormalizerID(100, bytes.ToLower); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		normalizersLock.Lock()
+		delete(normalizers, 100)
+		normalizersLock.Unlock()
+	}()
+	if err := RegisterNormalizerID(100, bytes.ToUpper); err == nil {
+		t.Errorf("Expected an error registering a taken ID")
+	}
+	if err := RegisterNormalizerID(0, bytes.ToUpper); err == nil {
+		t.Errorf("Expected an error registering ID 0")
+	}
+
+	set := mustHashSet(t, Options{NormalizerID: 100, Name: "users", InsertionOrder: true, BloomBits: 1024})
+	for _, value := range []string{"Charlie", "alice", "BOB"} {
+		set.Add([]byte(value))
+	}
+
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !decoded.Contains([]byte("ALICE")) || !decoded.Contains([]byte("bob")) {
+		t.Errorf("Expected the decoded set to normalize lookups")
+	}
+	if decoded.Name() != "users" || decoded.opts.NormalizerID != 100 || decoded.opts.BloomBits != 1024 {
+		t.Errorf("Expected the decoded set to keep its options, got %+v", decoded.opts)
+	}
+	if got := decoded.ToSlice(); len(got) != 3 {
+		t.Errorf("Expected the insertion order to hold 3 elements, got %d", len(got))
+	}
+
+	// A payload referencing a normalizer this build does not have must not decode
+	normalizersLock.Lock()
+	delete(normalizers, 100)
+	normalizersLock.Unlock()
+	if _, err := Deserialize(data); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion for an unregistered normalizer, got %v", err)
+	}
+	if _, err := NewHashSetWithOptions(Options{NormalizerID: 100}); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption for an unregistered normalizer, got %v", err)
+	}
+
+	// A Normalize function cannot be recorded
+	unregistered := mustHashSet(t, Options{Normalize: bytes.ToLower})
+	if _, err := unregistered.Serialize(); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption serializing a Normalize function, got %v", err)
+	}
+}
+
+func TestHashSet_NormalizerName(t *testing.T) {
+	if err := RegisterNormalizer("test/lower", bytes.ToLower); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		normalizersLock.Lock()
+		delete(namedNormalizers, "test/lower")
+		normalizersLock.Unlock()
+	}()
+	if err := RegisterNormalizer("test/lower", bytes.ToUpper); err == nil {
+		t.Errorf("Expected an error registering a taken name")
+	}
+	if err := RegisterNormalizer("", bytes.ToUpper); err == nil {
+		t.Errorf("Expected an error registering an empty name")
+	}
+
+	set := mustHashSet(t, Options{NormalizerName: "test/lower"})
+	set.Add([]byte("Alice"))
+	if !set.Contains([]byte("ALICE")) {
+		t.Errorf("Expected the named normalizer to apply to lookups")
+	}
+
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Contains([]byte("alice")) || decoded.opts.NormalizerName != "test/lower" {
+		t.Errorf("Expected the decoded set to normalize lookups, got %+v", decoded.opts)
+	}
+
+	// A payload referencing a name this build does not have must not decode
+	normalizersLock.Lock()
+	delete(namedNormalizers, "test/lower")
+	normalizersLock.Unlock()
+	if _, err := Deserialize(data); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion for an unregistered normalizer, got %v", err)
+	}
+	if _, err := NewHashSetWithOptions(Options{NormalizerName: "test/lower"}); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption for an unregistered normalizer, got %v", err)
+	}
+	if _, err := NewHashSetWithOptions(Options{NormalizerName: "test/lower", Normalize: bytes.ToLower}); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption combining NormalizerName and Normalize, got %v", err)
+	}
+}
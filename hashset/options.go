@@ -0,0 +1,394 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import "time"
+
+// Op is a kind of mutation reported to the OnMutate option.
+type Op int
+
+const (
+	OpAdd Op = iota
+	OpRemove
+	OpClear
+)
+
+// Options configures a HashSet created with NewHashSetWithOptions.
+type Options struct {
+	Capacity int // Initial capacity, rounded up to a power of two. Defaults to 32
+
+	// Name labels the set in String, Stats and LatencyStats to tell apart the many sets of a process,
+	// for instance one per column family. It is not serialized. Defaults to "", unnamed
+	Name string
+
+	// Hasher places the elements in buckets, for instance MurmurX86_32Hasher to agree with an
+	// implementation of the reference MurmurHash3_x86_32. Decoding serialized sets requires it to be
+	// registered, see RegisterHasher. Defaults to the hasher of the build
+	Hasher Hasher
+
+	// BucketHint is the capacity each bucket is allocated with on its first insert.
+	// It avoids the first append growths of dense sets at the cost of memory in sparse ones. Defaults to 0
+	BucketHint int
+
+	// PoolBuckets returns the backing slices of emptied buckets to a package wide sync.Pool and draws new
+	// buckets from it, cutting allocations under heavy Clear and Remove churn.
+	// Slices returned by ToSlice and friends are unaffected, but bucket slices read directly from Buckets
+	// must not be retained across mutations.
+	PoolBuckets bool
+
+	// SlabBuckets carves every bucket of BucketHint elements, at least one, out of a single backing array
+	// allocated with the bucket array, so creating, resizing and clearing the set take O(1) allocations
+	// instead of one per bucket. A bucket outgrowing its share moves to an allocation of its own.
+	// It excludes PoolBuckets. Defaults to false
+	SlabBuckets bool
+
+	// Allocator allocates and frees the backing slices of the buckets, so the memory of the buckets can be
+	// accounted for, see Allocator. Full buckets grow by moving to an allocation twice as large. It excludes
+	// PoolBuckets and SlabBuckets. Defaults to nil, buckets come from the runtime
+	Allocator Allocator
+
+	// MaxMemory limits the estimated memory of the set in bytes, see MemoryUsage.
+	// Add refuses new elements with ErrCapacityExceeded once the limit would be exceeded. Defaults to 0, unlimited
+	MaxMemory int
+
+	// CountAccess counts the lookups of every element that Contains answers true, see TopK.
+	// Counting turns Contains into a write, it must not be called concurrently. Defaults to false
+	CountAccess bool
+
+	// Profiling records latency histograms of Add, Remove and Contains, see LatencyStats,
+	// and the number of elements every Contains compares, see ProbeStats.
+	// Timing every operation is costly and turns Contains into a write. Defaults to false
+	Profiling bool
+
+	// BucketLimit bounds every bucket to this many elements. Adding to a full bucket evicts its oldest element
+	// instead of growing it, and the set never resizes, so it holds at most Capacity x BucketLimit elements.
+	// Suits approximate recent-keys filters where losing old elements is acceptable. Defaults to 0, unbounded
+	BucketLimit int
+
+	// OnEvict is called with every element evicted by the BucketLimit option, after it is removed. Optional
+	OnEvict func(value []byte)
+
+	// Multiset counts the occurrences of every element, see Count. Adding an element already present
+	// counts one more occurrence, removing it drops one, and it leaves the set when none are left.
+	// Counts live in memory only, serialized sets hold every element once. Defaults to false
+	Multiset bool
+
+	// TTL expires elements this long after they were added, adding an element again refreshes it.
+	// Contains and ContainsAge report expired elements absent, Expire removes them.
+	// Insert times live in memory only. Defaults to 0, elements never expire
+	TTL time.Duration
+
+	// MaxDistinct limits the number of elements. Add refuses new elements with ErrCapacityExceeded
+	// once the limit is reached, keeping a uniform random sample of the refused values, see Reservoir.
+	// Defaults to 0, unlimited
+	MaxDistinct int
+
+	// ReservoirSample is the size of the sample of values refused by MaxDistinct. Defaults to 0, no sample
+	ReservoirSample int
+
+	// Tombstones records every key passed to Remove as a tombstone, whether or not it was present, so the set
+	// can carry LSM deletes. Tombstoned keys are absent to Contains, adding a key again drops its tombstone.
+	// Tombstones lists them for a compaction and PurgeTombstones drops them once merged. Clear drops them too.
+	// Tombstones live in memory only. Defaults to false
+	Tombstones bool
+
+	// Sequences stamps every change to the elements with a sequence number, so ForEachAsOf can visit the set
+	// as it was at an earlier one. Changes are numbered from 1, AddSeq and RemoveSeq take the sequence numbers
+	// of the LSM tree instead. Removed elements are kept for the older views until PurgeSequences drops them.
+	// Sequence numbers live in memory only. Defaults to false
+	Sequences bool
+
+	// TrackChanges records the generation at which every element was added or removed, see Generation, so
+	// MembersSince and RemovedSince list the changes since an earlier generation for incremental replication.
+	// Removals are kept until PurgeChanges drops them. ClearSecure drops every change, replicas then have to be
+	// resynchronized from a full copy. Changes live in memory only. Defaults to false
+	TrackChanges bool
+
+	// LengthFilter counts the elements of every length up to 255 bytes, so Contains returns false without hashing
+	// a value when no element has its length. Longer elements share one count. It pays off for sets whose lengths
+	// cluster, lengths are measured in stored form, see FingerprintThreshold. Defaults to false
+	LengthFilter bool
+
+	// BloomBits is the size in bits of a summary bloom filter of the elements, rounded up to a multiple of 64.
+	// Contains returns false without scanning a bucket when the filter rules the value out.
+	// Bits of removed elements stay set until the next resize rebuilds the filter. Defaults to 0, disabled
+	BloomBits int
+
+	// MissCache remembers this many of the most recent values Contains found absent, so repeated lookups of
+	// the same absent values skip the bucket scan. Unlike BloomBits the answer is exact, adding a value forgets it.
+	// Remembering misses turns Contains into a write. Defaults to 0, disabled
+	MissCache int
+
+	// Fallback is a colder set Contains consults on a miss, a value found there is promoted into this set by Add,
+	// so promotions are subject to the BucketLimit, MaxDistinct and MaxMemory options. A promotion refused by
+	// them still answers true. Contains of a set with a fallback is a write. Defaults to nil, no fallback
+	Fallback *HashSet
+
+	// InsertionOrder records the order elements were first added in, so ToSlice and ForEach
+	// return them chronologically instead of in bucket order.
+	InsertionOrder bool
+
+	// FingerprintThreshold stores elements longer than this many bytes as a fixed 16 byte murmur
+	// fingerprint instead of the full value, bounding the memory and comparison cost of huge values.
+	// Two distinct long values, or a long value and a 16 byte value equal to its fingerprint,
+	// are treated as equal with a probability of about 2^-128 per pair.
+	// Fingerprinted elements cannot be recovered, ForEach and ToSlice return their fingerprint.
+	// Thresholds below 16 are raised to 16. Defaults to 0, storing every value in full
+	FingerprintThreshold int
+
+	// RejectEmpty makes Add refuse the empty value, nil or of zero length, with ErrEmptyValue, so it is never
+	// a member. A value its Normalize function maps to the empty value is refused too. Defaults to false,
+	// storing the empty value as one member, see Add
+	RejectEmpty bool
+
+	// MaxValueLen makes Add refuse values longer than this many bytes with ErrValueTooLarge, guarding the set
+	// against a huge key inserted by mistake. The stored form is measured, after Normalize, and a value stored
+	// as a fingerprint counts as the 16 bytes it takes, see FingerprintThreshold. Defaults to 0, no limit
+	MaxValueLen int
+
+	// Normalize maps every value to the form it is hashed, compared and stored in, for instance bytes.ToLower
+	// for case insensitive sets. It must be deterministic and idempotent and must not modify its argument.
+	// ForEach and the other iterations return the normalized forms, Get returns the form an element was
+	// first added in. Original forms live in memory only. Defaults to nil, values are used as is
+	Normalize func(value []byte) []byte
+
+	// NormalizerID selects the Normalize function registered under this ID by RegisterNormalizerID. Unlike a
+	// Normalize function set directly it is recorded by Serialize, so the decoded set compares values the same way.
+	// It excludes Normalize. Defaults to 0, selecting none
+	NormalizerID uint8
+
+	// NormalizerName selects the Normalize function registered under this name by RegisterNormalizer.
+	// It is recorded by Serialize as NormalizerID is. It excludes Normalize and NormalizerID. Defaults to "", none
+	NormalizerName string
+
+	// OnMutate is called synchronously before every mutation is applied, with the element in its stored form.
+	// Clear reports OpClear with a nil value. Logging the calls to a write-ahead log and replaying them
+	// through Add, Remove and Clear recovers the set. For ConcurrentHashSet it is called under the writer lock.
+	OnMutate func(op Op, value []byte)
+
+	// SortedBuckets keeps the elements of every bucket ordered by bytes.Compare, so lookups binary search
+	// long chains instead of scanning them, at the cost of inserts shifting the elements after the new one.
+	// Buckets then no longer keep insertion order, so BucketLimit evicts the smallest element of a full bucket
+	// rather than the oldest. It excludes SecondaryHashing. Defaults to false, appending new elements.
+	SortedBuckets bool
+
+	// TrackMaxMemberSize keeps the length of the largest element up to date for MaxMemberSize,
+	// at the cost of a rescan on the next query after a largest element is removed. Defaults to false
+	TrackMaxMemberSize bool
+
+	// RecomputeCapacity makes UnmarshalBinary size the set for the member count of the payload, ignoring
+	// the capacity it records, so sets loaded from different sources end up with the same capacity for
+	// the same members. Defaults to false, keeping the recorded capacity unless it is absurdly sparse
+	RecomputeCapacity bool
+
+	// Strategy is how elements hashing to the same bucket are stored, see Strategy. Defaults to SeparateChaining
+	Strategy Strategy
+
+	// ResizeHorizon resizes the set ahead of the load factor threshold when the recent rate of new elements
+	// predicts crossing it within this long, so a burst of inserts does not pay for the resize midway.
+	// A burst doubles the set at most once per quarter of the horizon, the prediction is a heuristic that
+	// trades memory for steadier latency. ResizeIfPredicted applies it from idle periods.
+	// It excludes BucketLimit, which never resizes. Defaults to 0, resizing at the threshold only
+	ResizeHorizon time.Duration
+
+	// AdaptiveLoadFactor moves the load factor threshold with the mix of operations, see ResizeThreshold:
+	// down to 0.5 while at least 90% of the operations are lookups, rehashing at once to cut collisions,
+	// and up to 0.9 while at most half of them are, cutting resizes. The mix is sampled every 4096
+	// operations. Counting turns Contains into a write, it must not be called concurrently.
+	// It excludes BucketLimit, which never resizes. Defaults to false, keeping the threshold at 0.7
+	AdaptiveLoadFactor bool
+
+	// OnResizeProgress is called during resizes with the number of buckets of the old bucket array migrated
+	// so far out of its total, every 16384 buckets and once more when all of them are, for progress reports
+	// on very large sets. An incremental resize reports the buckets split. A HashSet is mid-resize during
+	// the call and must not be used by it. ConcurrentHashSet calls it from its background resize outside of
+	// any lock. Defaults to nil, reporting nothing
+	OnResizeProgress func(migrated, total int)
+
+	// IncrementalResize spreads a resize over the following mutations: the bucket array doubles at once, but
+	// each Add of a new element and each Remove of a present one only moves the elements of this many old
+	// buckets to their new ones, so no single mutation pays for rehashing the whole set. Lookups meanwhile
+	// find the elements of the old buckets not moved yet where they are. A value of 2 or more always finishes
+	// a resize before the next one starts. It excludes the OpenAddressing strategy and SecondaryHashing.
+	// Defaults to 0, rehashing every element when the load factor threshold is crossed
+	IncrementalResize int
+
+	// SecondaryHashing splits long bucket chains by a second, independent hash so lookups
+	// only compare against the elements sharing both hashes. It costs an index entry per element in long chains.
+	SecondaryHashing bool
+
+	// IncrementalFingerprint keeps the combination of element hashes Fingerprint computes up to date as elements
+	// are added and removed, so Fingerprint is O(1) for cheap change detection, at the cost of hashing every
+	// added and removed element once more. Defaults to false, computing Fingerprint in O(n)
+	IncrementalFingerprint bool
+
+	// ChainFingerprints keeps a 32 bit tag of every element in long bucket chains, the upper half of its hash,
+	// so lookups compare integers and only compare the bytes of the elements whose tag matches. It costs 4 bytes
+	// per element in long chains. Tags are not serialized, decoding recomputes them. It excludes SecondaryHashing,
+	// SortedBuckets, IncrementalResize and the OpenAddressing strategy. Defaults to false, comparing every element
+	ChainFingerprints bool
+}
+
+// decodedSizeFloor is the size of an allocating option, in elements or entries, that a payload of any length
+// may restore, see checkDecodedSizes.
+const decodedSizeFloor = 1 << 16
+
+// checkDecodedSizes returns ErrCorrupt if an option sizing a structure the set allocates up front is out of
+// proportion to a payload of the given length holding capacity buckets. Options decoded from untrusted data
+// must not allocate memory without bound: running out of it cannot be recovered from.
+func (opts Options) checkDecodedSizes(payload, capacity int) error {
+	limit := max(decodedSizeFloor, payload, capacity)
+	for _, o := range []struct {
+		name  string
+		value int
+		limit int
+	}{
+		{"Capacity", opts.Capacity, limit},
+		{"BucketHint", opts.BucketHint, max(64, limit/max(capacity, 1))}, // Slab buckets allocate the hint per bucket
+		{"BloomBits", opts.BloomBits, 256 * limit},
+		{"ReservoirSample", opts.ReservoirSample, limit},
+		{"MissCache", opts.MissCache, limit},
+	} {
+		if o.value > o.limit {
+			return wrapf(ErrCorrupt, "corrupt hashset: %s %d exceeds %d for a payload of %d bytes", o.name, o.value, o.limit, payload)
+		}
+	}
+	return nil
+}
+
+// validate returns an error describing the first invalid option or combination of options.
+func (opts Options) validate() error {
+	// Sizes and limits default to zero, they can never be negative
+	for _, o := range []struct {
+		name  string
+		value int
+	}{
+		{"Capacity", opts.Capacity},
+		{"BucketHint", opts.BucketHint},
+		{"MaxMemory", opts.MaxMemory},
+		{"BucketLimit", opts.BucketLimit},
+		{"MaxDistinct", opts.MaxDistinct},
+		{"ReservoirSample", opts.ReservoirSample},
+		{"BloomBits", opts.BloomBits},
+		{"FingerprintThreshold", opts.FingerprintThreshold},
+		{"MissCache", opts.MissCache},
+		{"IncrementalResize", opts.IncrementalResize},
+	} {
+		if o.value < 0 {
+			return wrapf(ErrInvalidOption, "hashset: negative %s %d", o.name, o.value)
+		}
+	}
+
+	if opts.Capacity > maxCapacity {
+		return wrapf(ErrInvalidOption, "hashset: Capacity %d exceeds the maximum of %d", opts.Capacity, maxCapacity)
+	}
+	if opts.MaxValueLen < 0 {
+		return wrapf(ErrInvalidOption, "hashset: negative MaxValueLen %d", opts.MaxValueLen)
+	}
+	if opts.TTL < 0 {
+		return wrapf(ErrInvalidOption, "hashset: negative TTL %v", opts.TTL)
+	}
+	if opts.ResizeHorizon < 0 {
+		return wrapf(ErrInvalidOption, "hashset: negative ResizeHorizon %v", opts.ResizeHorizon)
+	}
+
+	// Options that refine another one
+	if opts.OnEvict != nil && opts.BucketLimit == 0 {
+		return wrapf(ErrInvalidOption, "hashset: OnEvict requires the BucketLimit option")
+	}
+	if opts.AdaptiveLoadFactor && opts.BucketLimit > 0 {
+		return wrapf(ErrInvalidOption, "hashset: AdaptiveLoadFactor and BucketLimit are exclusive, bounded sets never resize")
+	}
+	if opts.ResizeHorizon > 0 && opts.BucketLimit > 0 {
+		return wrapf(ErrInvalidOption, "hashset: ResizeHorizon and BucketLimit are exclusive, bounded sets never resize")
+	}
+	if opts.ReservoirSample > 0 && opts.MaxDistinct == 0 {
+		return wrapf(ErrInvalidOption, "hashset: ReservoirSample requires the MaxDistinct option")
+	}
+	if opts.NormalizerID != 0 {
+		if opts.Normalize != nil {
+			return wrapf(ErrInvalidOption, "hashset: Normalize and NormalizerID are exclusive, NormalizerID selects the Normalize function")
+		}
+		if _, ok := lookupNormalizer(opts.NormalizerID); !ok {
+			return wrapf(ErrInvalidOption, "hashset: no normalizer registered under NormalizerID %d", opts.NormalizerID)
+		}
+	}
+	if opts.NormalizerName != "" {
+		if opts.Normalize != nil || opts.NormalizerID != 0 {
+			return wrapf(ErrInvalidOption, "hashset: NormalizerName excludes Normalize and NormalizerID, it selects the Normalize function")
+		}
+		if _, ok := lookupNamedNormalizer(opts.NormalizerName); !ok {
+			return wrapf(ErrInvalidOption, "hashset: no normalizer registered under NormalizerName %q", opts.NormalizerName)
+		}
+	}
+	if opts.SortedBuckets && opts.SecondaryHashing {
+		return wrapf(ErrInvalidOption, "hashset: SortedBuckets and SecondaryHashing are exclusive, sorted buckets are binary searched")
+	}
+
+	if opts.ChainFingerprints && (opts.SecondaryHashing || opts.SortedBuckets || opts.Strategy != SeparateChaining || opts.IncrementalResize > 0) {
+		return wrapf(ErrInvalidOption, "hashset: ChainFingerprints excludes SecondaryHashing, SortedBuckets, IncrementalResize and the strategies other than %v", SeparateChaining)
+	}
+	if opts.IncrementalResize > 0 && opts.SecondaryHashing {
+		return wrapf(ErrInvalidOption, "hashset: IncrementalResize and SecondaryHashing are exclusive, the secondary index is rebuilt on resize")
+	}
+
+	if opts.SlabBuckets && opts.PoolBuckets {
+		return wrapf(ErrInvalidOption, "hashset: SlabBuckets and PoolBuckets are exclusive, slab buckets cannot be pooled")
+	}
+	if opts.Allocator != nil && (opts.PoolBuckets || opts.SlabBuckets) {
+		return wrapf(ErrInvalidOption, "hashset: Allocator excludes PoolBuckets and SlabBuckets, their buckets bypass it")
+	}
+
+	switch opts.Strategy {
+	case SeparateChaining:
+	case SortedChaining:
+		if opts.SecondaryHashing {
+			return wrapf(ErrInvalidOption, "hashset: the %v strategy excludes SecondaryHashing, sorted buckets are binary searched", opts.Strategy)
+		}
+	case OpenAddressing:
+		if opts.SortedBuckets || opts.SecondaryHashing || opts.BucketLimit > 0 {
+			return wrapf(ErrInvalidOption, "hashset: the %v strategy excludes SortedBuckets, SecondaryHashing and BucketLimit, buckets hold one element", opts.Strategy)
+		}
+		if opts.IncrementalResize > 0 {
+			return wrapf(ErrInvalidOption, "hashset: the %v strategy excludes IncrementalResize, probe sequences cross bucket halves", opts.Strategy)
+		}
+	default:
+		return wrapf(ErrInvalidOption, "hashset: unknown strategy %d", opts.Strategy)
+	}
+
+	// Serialized sets are decoded with the hasher registered under their ID
+	if opts.Hasher != nil {
+		if registered, err := lookupHasher(opts.Hasher.ID()); err == nil && !sameHasher(registered, opts.Hasher) {
+			return wrapf(ErrInvalidOption, "hashset: hasher %d conflicts with the hasher registered under the same ID", opts.Hasher.ID())
+		}
+	}
+	return nil
+}
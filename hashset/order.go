@@ -0,0 +1,269 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"container/heap"
+	"container/list"
+	"iter"
+	"math/rand/v2"
+	"slices"
+)
+
+// insertionOrder is a list of elements in the order they were first added.
+// A nil insertionOrder records nothing.
+type insertionOrder struct {
+	elements *list.List               // Elements, oldest first
+	index    map[string]*list.Element // Element lookup for removal
+}
+
+// newInsertionOrder creates an empty insertionOrder.
+func newInsertionOrder() *insertionOrder {
+	return &insertionOrder{
+		elements: list.New(),
+		index:    make(map[string]*list.Element),
+	}
+}
+
+// clone returns an independent copy of the insertion order.
+func (o *insertionOrder) clone() *insertionOrder {
+	if o == nil {
+		return nil
+	}
+
+	c := newInsertionOrder()
+	for e := o.elements.Front(); e != nil; e = e.Next() {
+		c.insert(e.Value.([]byte))
+	}
+	return c
+}
+
+// insert records value as the newest element.
+func (o *insertionOrder) insert(value []byte) {
+	if o == nil {
+		return
+	}
+	o.index[string(value)] = o.elements.PushBack(value)
+}
+
+// remove forgets value.
+func (o *insertionOrder) remove(value []byte) {
+	if o == nil {
+		return
+	}
+
+	if e, ok := o.index[string(value)]; ok {
+		o.elements.Remove(e)
+		delete(o.index, string(value))
+	}
+}
+
+// clear forgets every element.
+func (o *insertionOrder) clear() {
+	if o == nil {
+		return
+	}
+	o.elements.Init()
+	clear(o.index)
+}
+
+// ForEach calls fn for every element in the set until fn returns false.
+// Elements are visited in insertion order if the set records it, otherwise in bucket order.
+func (h *HashSet) ForEach(fn func(value []byte) bool) {
+	if h.order != nil {
+		for e := h.order.elements.Front(); e != nil; e = e.Next() {
+			if !fn(e.Value.([]byte)) {
+				return
+			}
+		}
+		return
+	}
+
+	if a := h.currentArena(); a != nil {
+		for _, item := range a.items {
+			if !fn(item.([]byte)) {
+				return
+			}
+		}
+		return
+	}
+
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			if !fn(item.([]byte)) {
+				return
+			}
+		}
+	}
+}
+
+// ForEachShuffled calls fn for every element in an order shuffled by rng until fn returns false.
+// Every order is equally likely and the same rng state gives the same order for the same set, so
+// replays are reproducible. The element references are buffered, so the set must not be mutated by fn.
+func (h *HashSet) ForEachShuffled(rng *rand.Rand, fn func(value []byte) bool) {
+	values := h.ToSlice()
+	rng.Shuffle(len(values), func(i, j int) {
+		values[i], values[j] = values[j], values[i]
+	})
+	for _, value := range values {
+		if !fn(value) {
+			return
+		}
+	}
+}
+
+// ToSlice returns every element in the set.
+// Elements are in insertion order if the set records it, otherwise in bucket order. It returns nil for a nil set.
+func (h *HashSet) ToSlice() [][]byte {
+	if h == nil {
+		return nil
+	}
+	values := make([][]byte, 0, h.Size)
+	h.ForEach(func(value []byte) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// ToOrderedSlice returns every element in the order it was first added.
+// It returns nil if the set was not created with the InsertionOrder option.
+func (h *HashSet) ToOrderedSlice() [][]byte {
+	if h.order == nil {
+		return nil
+	}
+	return h.ToSlice()
+}
+
+// ToSortedSlice returns every element in the set ordered by bytes.Compare.
+func (h *HashSet) ToSortedSlice() [][]byte {
+	return h.ToSortedSliceFunc(bytes.Compare)
+}
+
+// ToSortedSliceFunc returns every element in the set ordered by cmp.
+// cmp returns a negative number when a sorts before b, a positive number when after, and zero otherwise.
+func (h *HashSet) ToSortedSliceFunc(cmp func(a, b []byte) int) [][]byte {
+	values := h.ToSlice()
+	slices.SortFunc(values, cmp)
+	return values
+}
+
+// ToStringSlice returns every element in the set as a string.
+// Each string is a direct conversion of the element bytes, invalid UTF-8 is kept as is rather than replaced.
+func (h *HashSet) ToStringSlice() []string {
+	if h == nil {
+		return nil
+	}
+	values := make([]string, 0, h.Size)
+	h.ForEach(func(value []byte) bool {
+		values = append(values, string(value))
+		return true
+	})
+	return values
+}
+
+// ToSortedStringSlice returns every element in the set as a string like ToStringSlice, ordered like ToSortedSlice.
+// Strings compare byte by byte, so invalid UTF-8 sorts the same as the bytes would.
+func (h *HashSet) ToSortedStringSlice() []string {
+	values := h.ToStringSlice()
+	slices.Sort(values)
+	return values
+}
+
+// SortedRun returns an iterator over the elements of the set in bytes.Compare order, a sorted run the
+// k-way merge of a compaction can pull from like an SSTable. Elements are ordered lazily with a heap,
+// so stopping early costs O(n + k log n) for k yielded elements. Only the element references are buffered,
+// the elements must not be modified and the set must not be mutated during iteration.
+func (h *HashSet) SortedRun() iter.Seq[[]byte] {
+	return func(yield func(value []byte) bool) {
+		run := sortedRun(h.ToSlice())
+		heap.Init(&run)
+		for len(run) > 0 {
+			if !yield(heap.Pop(&run).([]byte)) {
+				return
+			}
+		}
+	}
+}
+
+// sortedRun is a min-heap of elements ordered by bytes.Compare.
+type sortedRun [][]byte
+
+func (r sortedRun) Len() int           { return len(r) }
+func (r sortedRun) Less(i, j int) bool { return bytes.Compare(r[i], r[j]) < 0 }
+func (r sortedRun) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r *sortedRun) Push(x any)        { *r = append(*r, x.([]byte)) }
+
+func (r *sortedRun) Pop() any {
+	old := *r
+	value := old[len(old)-1]
+	old[len(old)-1] = nil
+	*r = old[:len(old)-1]
+	return value
+}
+
+// ForEachGrouped calls fn for every element with the group partition returns for it, computed on the fly during
+// a single traversal in the order ForEach visits the elements. Nothing is buffered, so groups interleave: fn
+// routes each element to its group, such as a downstream shard, as it arrives. fn must not mutate the set.
+func (h *HashSet) ForEachGrouped(partition func(value []byte) int, fn func(group int, value []byte)) {
+	h.ForEach(func(value []byte) bool {
+		fn(partition(value), value)
+		return true
+	})
+}
+
+// AddWindowed adds value and, if the set then holds more than maxSize elements, evicts the oldest one.
+// It returns the evicted element, or nil when no eviction was needed. At most one element is evicted per call.
+// The set must be created with the InsertionOrder option to know which element is the oldest, ErrInvalidOption
+// is returned otherwise. An error of Add, such as ErrCapacityExceeded, is returned with the window left unchanged.
+// The evicted element leaves the set entirely: a multiset drops every occurrence and no tombstone is recorded.
+func (h *HashSet) AddWindowed(value []byte, maxSize int) ([]byte, error) {
+	if h.order == nil {
+		return nil, wrapf(ErrInvalidOption, "hashset: AddWindowed requires the InsertionOrder option")
+	}
+
+	if _, err := h.Add(value); err != nil {
+		return nil, err
+	}
+	if h.Size <= maxSize {
+		return nil, nil
+	}
+
+	oldest := h.order.elements.Front().Value.([]byte)
+	index, i := h.locate(h.digest(oldest), oldest)
+	h.notify(OpRemove, oldest)
+	h.removeAt(index, i)
+	h.Size--
+	h.splitBuckets(h.opts.IncrementalResize)
+	h.adapt(false)
+	return oldest, nil
+}
@@ -0,0 +1,332 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestHashSet_ToOrderedSlice(t *testing.T) {
+	set := mustHashSet(t, Options{InsertionOrder: true})
+
+	expected := make([][]byte, 0)
+	for i := 0; i < 1000; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		set.Add(value)
+		if i%3 == 0 {
+			set.Remove(value)
+			continue
+		}
+		expected = append(expected, value)
+	}
+
+	set.Add([]byte("test1")) // Already present, keeps its position
+
+	ordered := set.ToOrderedSlice()
+	if len(ordered) != len(expected) {
+		t.Fatalf("Expected %d elements, got %d", len(expected), len(ordered))
+	}
+
+	for i := range expected {
+		if !bytes.Equal(ordered[i], expected[i]) {
+			t.Errorf("Expected element %d to be %s, got %s", i, expected[i], ordered[i])
+		}
+	}
+
+	set.Clear()
+	if len(set.ToOrderedSlice()) != 0 {
+		t.Errorf("Expected no elements after clear")
+	}
+}
+
+func TestHashSet_ToOrderedSliceDisabled(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("test"))
+
+	if set.ToOrderedSlice() != nil {
+		t.Errorf("Expected nil without the InsertionOrder option")
+	}
+
+	if len(set.ToSlice()) != 1 {
+		t.Errorf("Expected ToSlice to return 1 element")
+	}
+}
+
+func TestHashSet_ForEach(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	visited := 0
+	set.ForEach(func(value []byte) bool {
+		visited++
+		return visited < 10
+	})
+
+	if visited != 10 {
+		t.Errorf("Expected ForEach to stop after 10 elements, got %d", visited)
+	}
+}
+
+func TestHashSet_ForEachShuffled(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	shuffled := func(seed uint64) []string {
+		var order []string
+		set.ForEachShuffled(rand.New(rand.NewPCG(seed, 0)), func(value []byte) bool {
+			order = append(order, string(value))
+			return true
+		})
+		return order
+	}
+
+	first := shuffled(1)
+	if len(first) != 100 {
+		t.Errorf("Expected 100 elements, got %d", len(first))
+	}
+	seen := make(map[string]bool)
+	for _, value := range first {
+		seen[value] = true
+	}
+	if len(seen) != 100 {
+		t.Errorf("Expected every element once, got %d distinct", len(seen))
+	}
+	if !reflect.DeepEqual(first, shuffled(1)) {
+		t.Errorf("Expected the same seed to give the same order")
+	}
+	if reflect.DeepEqual(first, shuffled(2)) || reflect.DeepEqual(first, set.ToStringSlice()) {
+		t.Errorf("Expected a different order for another seed and from the bucket order")
+	}
+
+	visited := 0
+	set.ForEachShuffled(rand.New(rand.NewPCG(1, 0)), func(value []byte) bool {
+		visited++
+		return visited < 5
+	})
+	if visited != 5 {
+		t.Errorf("Expected the iteration to stop after 5 elements, got %d", visited)
+	}
+}
+
+func TestHashSet_ToSortedSlice(t *testing.T) {
+	set := NewHashSet()
+	for _, value := range []string{"c", "a", "d", "b"} {
+		set.Add([]byte(value))
+	}
+
+	sorted := set.ToSortedSlice()
+	for i, want := range []string{"a", "b", "c", "d"} {
+		if string(sorted[i]) != want {
+			t.Errorf("Expected element %d to be %s, got %s", i, want, sorted[i])
+		}
+	}
+}
+
+func TestHashSet_ToSortedStringSlice(t *testing.T) {
+	set := NewHashSet()
+	for _, value := range []string{"c", "\xff", "a", "b"} {
+		set.Add([]byte(value))
+	}
+
+	if values := set.ToStringSlice(); len(values) != 4 {
+		t.Errorf("Expected 4 strings, got %d", len(values))
+	}
+	sorted := set.ToSortedStringSlice()
+	for i, want := range []string{"a", "b", "c", "\xff"} {
+		if sorted[i] != want {
+			t.Errorf("Expected element %d to be %q, got %q", i, want, sorted[i])
+		}
+	}
+}
+
+func TestHashSet_ToSortedSliceFunc(t *testing.T) {
+	set := NewHashSet()
+	for _, value := range []string{"x-3", "y-1", "z-2"} {
+		set.Add([]byte(value))
+	}
+
+	// Order by the suffix after the dash
+	sorted := set.ToSortedSliceFunc(func(a, b []byte) int {
+		return bytes.Compare(a[bytes.IndexByte(a, '-'):], b[bytes.IndexByte(b, '-'):])
+	})
+
+	for i, want := range []string{"y-1", "z-2", "x-3"} {
+		if string(sorted[i]) != want {
+			t.Errorf("Expected element %d to be %s, got %s", i, want, sorted[i])
+		}
+	}
+}
+
+func TestHashSet_SortedRun(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("value%d", i)))
+	}
+
+	var run [][]byte
+	for value := range set.SortedRun() {
+		run = append(run, value)
+	}
+	if !reflect.DeepEqual(run, set.ToSortedSlice()) {
+		t.Errorf("Expected the run to match the sorted slice")
+	}
+
+	// Stopping early yields the smallest elements
+	var first [][]byte
+	for value := range set.SortedRun() {
+		first = append(first, value)
+		if len(first) == 3 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(first, run[:3]) {
+		t.Errorf("Expected the first elements %q, got %q", run[:3], first)
+	}
+
+	for range NewHashSet().SortedRun() {
+		t.Errorf("Expected no elements in the run of an empty set")
+	}
+}
+
+func TestHashSet_AddWindowed(t *testing.T) {
+	set := mustHashSet(t, Options{InsertionOrder: true})
+
+	for i := 0; i < 3; i++ {
+		if evicted, _ := set.AddWindowed([]byte(fmt.Sprintf("test%d", i)), 3); evicted != nil {
+			t.Errorf("Expected no eviction while under the window, got %s", evicted)
+		}
+	}
+
+	// A duplicate neither grows the set nor evicts
+	if evicted, _ := set.AddWindowed([]byte("test1"), 3); evicted != nil {
+		t.Errorf("Expected no eviction for a duplicate, got %s", evicted)
+	}
+
+	for i := 3; i < 10; i++ {
+		evicted, err := set.AddWindowed([]byte(fmt.Sprintf("test%d", i)), 3)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if want := fmt.Sprintf("test%d", i-3); string(evicted) != want {
+			t.Errorf("Expected %s to be evicted, got %s", want, evicted)
+		}
+	}
+
+	if set.Size != 3 || !set.Contains([]byte("test9")) || set.Contains([]byte("test6")) {
+		t.Errorf("Expected the window to hold test7 to test9")
+	}
+}
+
+func TestHashSet_AddWindowedRefused(t *testing.T) {
+	set := mustHashSet(t, Options{InsertionOrder: true, MaxDistinct: 2})
+	set.AddWindowed([]byte("test0"), 3)
+	set.AddWindowed([]byte("test1"), 3)
+
+	evicted, err := set.AddWindowed([]byte("test2"), 3)
+	if !errors.Is(err, ErrCapacityExceeded) || evicted != nil {
+		t.Errorf("Expected ErrCapacityExceeded and no eviction, got %s %v", evicted, err)
+	}
+	if got := set.ToSlice(); len(got) != 2 || string(got[0]) != "test0" || string(got[1]) != "test1" {
+		t.Errorf("Expected the window to be unchanged, got %q", got)
+	}
+
+	// A refused add evicts nothing even when the set is over a smaller window
+	evicted, err = set.AddWindowed([]byte("test3"), 1)
+	if err == nil || evicted != nil || set.Size != 2 {
+		t.Errorf("Expected a refused add not to shrink the window, got %s %v with size %d", evicted, err, set.Size)
+	}
+}
+
+func TestHashSet_AddWindowedEvictsEntirely(t *testing.T) {
+	for name, opts := range map[string]Options{
+		"multiset":   {InsertionOrder: true, Multiset: true},
+		"tombstones": {InsertionOrder: true, Tombstones: true},
+	} {
+		set := mustHashSet(t, opts)
+		set.AddWindowed([]byte("test0"), 2)
+		set.AddWindowed([]byte("test0"), 2) // Counted twice by a multiset
+		set.AddWindowed([]byte("test1"), 2)
+
+		evicted, err := set.AddWindowed([]byte("test2"), 2)
+		if err != nil || string(evicted) != "test0" {
+			t.Fatalf("Expected test0 to be evicted from the %s window, got %s %v", name, evicted, err)
+		}
+		if set.Size != 2 || set.Contains([]byte("test0")) || set.Count([]byte("test0")) != 0 {
+			t.Errorf("Expected test0 to leave the %s window entirely, size %d", name, set.Size)
+		}
+		if len(set.Tombstones()) != 0 {
+			t.Errorf("Expected no tombstone for the element evicted from the %s window", name)
+		}
+	}
+}
+
+func TestHashSet_AddWindowedRequiresOrder(t *testing.T) {
+	if _, err := NewHashSet().AddWindowed([]byte("test"), 1); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption without InsertionOrder, got %v", err)
+	}
+}
+
+func TestHashSet_ForEachGrouped(t *testing.T) {
+	set := mustHashSet(t, Options{InsertionOrder: true})
+	for _, value := range []string{"user:1", "order:1", "user:2", "cart:1", "order:2", "user:3"} {
+		set.Add([]byte(value))
+	}
+
+	namespaces := map[string]int{"cart": 0, "order": 1, "user": 2}
+	calls := 0
+	var got []string
+	set.ForEachGrouped(func(value []byte) int {
+		calls++
+		return namespaces[string(bytes.SplitN(value, []byte(":"), 2)[0])]
+	}, func(group int, value []byte) {
+		if calls != len(got)+1 {
+			t.Errorf("Expected %s to be passed on as it is partitioned", value)
+		}
+		got = append(got, fmt.Sprintf("%d %s", group, value))
+	})
+
+	want := []string{"2 user:1", "1 order:1", "2 user:2", "0 cart:1", "1 order:2", "2 user:3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if calls != 6 {
+		t.Errorf("Expected the partition to be computed once per element, got %d calls", calls)
+	}
+}
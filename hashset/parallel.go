@@ -0,0 +1,93 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import "sync"
+
+// partitionBounds returns the bucket range [start, end) of partition p out of n.
+func (h *HashSet) partitionBounds(p, n int) (int, int) {
+	return p * h.Capacity / n, (p + 1) * h.Capacity / n
+}
+
+// ForEachPartition splits the buckets into n contiguous ranges and iterates each in its own goroutine.
+// fn receives the partition number and every element of it, each element is visited exactly once.
+// fn is called concurrently from different partitions and must not mutate the set.
+func (h *HashSet) ForEachPartition(n int, fn func(partition int, value []byte)) {
+	n = min(max(n, 1), h.Capacity)
+
+	wg := &sync.WaitGroup{}
+	for p := 0; p < n; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			start, end := h.partitionBounds(p, n)
+			for _, bucket := range h.Buckets[start:end] {
+				for _, item := range bucket {
+					fn(p, item.([]byte))
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+}
+
+// Reduce maps every element of h and folds the results, splitting the buckets across workers as ForEachPartition
+// does. Each worker folds its range starting from identity, the partial results are then folded in bucket order,
+// so for an associative reducer the result equals a serial fold over the buckets. identity must leave any value
+// unchanged under reducer, as 0 does for a sum, since it is folded in once per worker. mapper and reducer are called
+// concurrently and must not mutate the set. Go methods cannot take type parameters, hence the function form.
+func Reduce[T any](h *HashSet, workers int, mapper func(value []byte) T, reducer func(a, b T) T, identity T) T {
+	workers = min(max(workers, 1), h.Capacity)
+
+	partials := make([]T, workers)
+	wg := &sync.WaitGroup{}
+	for p := 0; p < workers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			acc := identity
+			start, end := h.partitionBounds(p, workers)
+			for _, bucket := range h.Buckets[start:end] {
+				for _, item := range bucket {
+					acc = reducer(acc, mapper(item.([]byte)))
+				}
+			}
+			partials[p] = acc
+		}(p)
+	}
+	wg.Wait()
+
+	result := identity
+	for _, partial := range partials {
+		result = reducer(result, partial)
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestHashSet_ForEachPartition(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	lock := &sync.Mutex{}
+	seen := make(map[string]int)
+	partitions := make(map[int]bool)
+
+	set.ForEachPartition(4, func(partition int, value []byte) {
+		lock.Lock()
+		defer lock.Unlock()
+		seen[string(value)]++
+		partitions[partition] = true
+	})
+
+	if len(seen) != 1000 {
+		t.Errorf("Expected 1000 distinct elements, got %d", len(seen))
+	}
+
+	for value, count := range seen {
+		if count != 1 {
+			t.Errorf("Expected %s to be visited once, got %d", value, count)
+		}
+	}
+
+	if len(partitions) != 4 {
+		t.Errorf("Expected 4 partitions, got %d", len(partitions))
+	}
+}
+
+func TestHashSet_ForEachPartitionMoreThanBuckets(t *testing.T) {
+	set := mustHashSet(t, Options{Capacity: 2})
+	set.Add([]byte("test"))
+
+	visited := 0
+	set.ForEachPartition(64, func(partition int, value []byte) {
+		visited++
+	})
+
+	if visited != 1 {
+		t.Errorf("Expected 1 element to be visited, got %d", visited)
+	}
+}
+
+func TestReduce(t *testing.T) {
+	set := NewHashSet()
+	total := 0
+	for i := 0; i < 1000; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		set.Add(value)
+		total += len(value)
+	}
+
+	length := func(value []byte) int { return len(value) }
+	sum := func(a, b int) int { return a + b }
+	for _, workers := range []int{0, 1, 4, 64} {
+		if got := Reduce(set, workers, length, sum, 0); got != total {
+			t.Errorf("Expected a total length of %d with %d workers, got %d", total, workers, got)
+		}
+	}
+
+	// Concatenation is associative but not commutative, so it checks the partial results keep bucket order
+	serial := ""
+	for _, bucket := range set.Buckets {
+		for _, item := range bucket {
+			serial += string(item.([]byte)) + ","
+		}
+	}
+	join := func(value []byte) string { return string(value) + "," }
+	concat := func(a, b string) string { return a + b }
+	if got := Reduce(set, 8, join, concat, ""); got != serial {
+		t.Errorf("Expected the parallel reduce to match the serial one")
+	}
+
+	if got := Reduce(NewHashSet(), 4, join, concat, ""); got != "" {
+		t.Errorf("Expected the identity for an empty set, got %q", got)
+	}
+}
@@ -0,0 +1,123 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import "sync"
+
+// bucketPool holds emptied bucket slices for sets created with the PoolBuckets option.
+var bucketPool = sync.Pool{}
+
+// Allocator provides the backing slices of the buckets of a set, see the Allocator option.
+// The set only allocates buckets through it and hands every bucket it drops back to Free, so an implementation
+// can account for the memory of the buckets. Element bytes and the bucket array itself are not covered.
+type Allocator interface {
+	Alloc(n int) []interface{} // Returns a slice of capacity n or more, its length is ignored
+	Free(bucket []interface{}) // Takes back a bucket the set no longer references, its elements are cleared
+}
+
+// allocBucket returns an empty bucket with room for n elements, from the Allocator option if set.
+func (h *HashSet) allocBucket(n int) []interface{} {
+	if h.opts.Allocator != nil {
+		return h.opts.Allocator.Alloc(n)[:0]
+	}
+	return make([]interface{}, 0, n)
+}
+
+// growBucket returns bucket with room for one more element. Under the Allocator option a full bucket is
+// copied to a bucket twice as large and freed, unless it is shared with a clone, so append never allocates.
+func (h *HashSet) growBucket(bucket []interface{}, shared bool) []interface{} {
+	if h.opts.Allocator == nil || len(bucket) < cap(bucket) {
+		return bucket
+	}
+
+	grown := append(h.allocBucket(max(2*cap(bucket), 1)), bucket...)
+	if !shared {
+		h.releaseBucket(bucket)
+	}
+	return grown
+}
+
+// recycles reports whether emptied buckets are handed back, to the pool or the Allocator, rather than kept.
+func (h *HashSet) recycles() bool {
+	return h.opts.PoolBuckets || h.opts.Allocator != nil
+}
+
+// newBucket returns an empty bucket for a first insert.
+// It comes from the pool with the PoolBuckets option and from the Allocator option if set,
+// is preallocated with the BucketHint option, and is nil otherwise so append allocates it.
+func (h *HashSet) newBucket() []interface{} {
+	if h.opts.Allocator != nil {
+		return h.allocBucket(max(h.opts.BucketHint, 1))
+	}
+	if h.opts.PoolBuckets {
+		if p, ok := bucketPool.Get().(*[]interface{}); ok {
+			return *p
+		}
+	}
+
+	if h.opts.BucketHint > 0 {
+		return make([]interface{}, 0, h.opts.BucketHint)
+	}
+	return nil
+}
+
+// makeBuckets returns an empty bucket array of the given capacity.
+// With the SlabBuckets option every bucket is a capped slice of one shared backing array.
+func (h *HashSet) makeBuckets(capacity int) [][]interface{} {
+	buckets := make([][]interface{}, capacity)
+	if !h.opts.SlabBuckets {
+		return buckets
+	}
+
+	hint := max(h.opts.BucketHint, 1)
+	slab := make([]interface{}, capacity*hint)
+	for i := range buckets {
+		buckets[i] = slab[i*hint : i*hint : (i+1)*hint] // Capped so an append never overwrites the next bucket
+	}
+	return buckets
+}
+
+// releaseBucket returns a bucket that is no longer referenced by the set to the pool or the Allocator option.
+func (h *HashSet) releaseBucket(bucket []interface{}) {
+	if !h.recycles() || cap(bucket) == 0 {
+		return
+	}
+
+	clear(bucket[:cap(bucket)]) // Drop every element reference, including stale ones past the length
+	bucket = bucket[:0]
+	if h.opts.Allocator != nil {
+		if !h.inArena(bucket) { // Arena buckets view the arena, the Allocator never handed them out
+			h.opts.Allocator.Free(bucket)
+		}
+		return
+	}
+	bucketPool.Put(&bucket)
+}
@@ -0,0 +1,231 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"testing"
+	"unsafe"
+)
+
+func TestHashSet_PoolBuckets(t *testing.T) {
+	set := mustHashSet(t, Options{PoolBuckets: true})
+
+	for round := 0; round < 10; round++ {
+		for i := 0; i < 1000; i++ {
+			set.Add([]byte(fmt.Sprintf("test%d-%d", round, i)))
+		}
+
+		for i := 0; i < 1000; i += 2 {
+			set.Remove([]byte(fmt.Sprintf("test%d-%d", round, i)))
+		}
+
+		for i := 0; i < 1000; i++ {
+			value := []byte(fmt.Sprintf("test%d-%d", round, i))
+			if set.Contains(value) != (i%2 == 1) {
+				t.Fatalf("Unexpected membership for %s", value)
+			}
+		}
+
+		if set.Size != 500 {
+			t.Fatalf("Expected size to be 500, got %d", set.Size)
+		}
+
+		set.Clear()
+		if set.Size != 0 || set.Contains([]byte(fmt.Sprintf("test%d-1", round))) {
+			t.Fatalf("Expected set to be empty after clear")
+		}
+	}
+}
+
+func TestHashSet_PoolBucketsEmptied(t *testing.T) {
+	set := mustHashSet(t, Options{PoolBuckets: true})
+	value := []byte("test")
+
+	set.Add(value)
+	set.Remove(value)
+
+	if set.Buckets[set.hash(value, set.Capacity)] != nil {
+		t.Errorf("Expected emptied bucket to be released")
+	}
+
+	set.Add(value)
+	if !set.Contains(value) {
+		t.Errorf("Expected set to contain %v", value)
+	}
+}
+
+func TestHashSet_SlabBuckets(t *testing.T) {
+	opts := Options{Capacity: 1024, BucketHint: 2, SlabBuckets: true}
+	if allocs := testing.AllocsPerRun(10, func() { newHashSetWithOptions(opts) }); allocs > 4 {
+		t.Errorf("Expected O(1) allocations to create 1024 buckets, got %v", allocs)
+	}
+
+	// Buckets outgrowing their share of the slab must not overwrite their neighbours
+	set := mustHashSet(t, Options{Capacity: 1, BucketHint: 1, SlabBuckets: true})
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	for i := 0; i < 1000; i += 2 {
+		set.Remove([]byte(fmt.Sprintf("test%d", i)))
+	}
+	for i := 0; i < 1000; i++ {
+		if set.Contains([]byte(fmt.Sprintf("test%d", i))) != (i%2 == 1) {
+			t.Errorf("Unexpected membership for test%d", i)
+		}
+	}
+
+	set.Clear()
+	if cap(set.Buckets[0]) != 1 {
+		t.Errorf("Expected cleared buckets to be carved from a slab, got capacity %d", cap(set.Buckets[0]))
+	}
+
+	if _, err := NewHashSetWithOptions(Options{SlabBuckets: true, PoolBuckets: true}); err == nil {
+		t.Errorf("Expected an error combining SlabBuckets and PoolBuckets")
+	}
+}
+
+// countingAllocator records the buckets it hands out so a test can check each one is freed once.
+type countingAllocator struct {
+	live  map[*interface{}]int
+	frees int
+}
+
+func (a *countingAllocator) Alloc(n int) []interface{} {
+	bucket := make([]interface{}, n)
+	a.live[unsafe.SliceData(bucket)] = n
+	return bucket
+}
+
+func (a *countingAllocator) Free(bucket []interface{}) {
+	data := unsafe.SliceData(bucket[:cap(bucket)])
+	if _, ok := a.live[data]; !ok {
+		panic("free of a bucket that is not live")
+	}
+	for _, item := range bucket[:cap(bucket)] {
+		if item != nil {
+			panic("free of a bucket that still references elements")
+		}
+	}
+	delete(a.live, data)
+	a.frees++
+}
+
+func TestHashSet_Allocator(t *testing.T) {
+	alloc := &countingAllocator{live: map[*interface{}]int{}}
+	set := mustHashSet(t, Options{Capacity: 1, Allocator: alloc})
+
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	for i := 0; i < 1000; i += 2 {
+		set.Remove([]byte(fmt.Sprintf("test%d", i)))
+	}
+	for i := 0; i < 1000; i++ {
+		if set.Contains([]byte(fmt.Sprintf("test%d", i))) != (i%2 == 1) {
+			t.Fatalf("Unexpected membership for test%d", i)
+		}
+	}
+
+	if alloc.frees == 0 {
+		t.Errorf("Expected resizes and removals to free buckets")
+	}
+	for index, bucket := range set.Buckets {
+		if cap(bucket) == 0 {
+			continue
+		}
+		if _, ok := alloc.live[unsafe.SliceData(bucket[:cap(bucket)])]; !ok {
+			t.Fatalf("Expected bucket %d to come from the allocator", index)
+		}
+	}
+
+	set.Clear()
+	if len(alloc.live) != 0 {
+		t.Errorf("Expected every bucket to be freed after clear, %d are live", len(alloc.live))
+	}
+
+	if _, err := NewHashSetWithOptions(Options{Allocator: alloc, PoolBuckets: true}); err == nil {
+		t.Errorf("Expected an error combining Allocator and PoolBuckets")
+	}
+}
+
+func TestHashSet_AllocatorArena(t *testing.T) {
+	alloc := &countingAllocator{live: map[*interface{}]int{}}
+	set := mustHashSet(t, Options{Allocator: alloc})
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	set.Arena()
+	if len(alloc.live) != 0 {
+		t.Errorf("Expected the buckets replaced by the arena to be freed, %d are live", len(alloc.live))
+	}
+
+	// Arena buckets are moved to the allocator when they grow and are never passed to Free
+	for i := 100; i < 200; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+	for i := 0; i < 200; i += 2 {
+		set.Remove([]byte(fmt.Sprintf("test%d", i)))
+	}
+	for i := 0; i < 200; i++ {
+		if set.Contains([]byte(fmt.Sprintf("test%d", i))) != (i%2 == 1) {
+			t.Fatalf("Unexpected membership for test%d", i)
+		}
+	}
+
+	set.Arena()
+	set.Remove([]byte("test1"))
+	set.Clear()
+	if len(alloc.live) != 0 {
+		t.Errorf("Expected every bucket to be freed after clear, %d are live", len(alloc.live))
+	}
+}
+
+func BenchmarkHashSet_ClearChurn(b *testing.B) {
+	values := make([][]byte, 1000)
+	for i := range values {
+		values[i] = []byte(fmt.Sprintf("test%d", i))
+	}
+
+	for _, pool := range []bool{false, true} {
+		b.Run(fmt.Sprintf("pool=%v", pool), func(b *testing.B) {
+			set := mustHashSet(b, Options{PoolBuckets: pool})
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, value := range values {
+					set.Add(value)
+				}
+				set.Clear()
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import "time"
+
+// insertRate estimates how fast new elements arrive, for the ResizeHorizon option.
+// The rate is smoothed over windows of a quarter of the horizon. A nil insertRate predicts nothing.
+type insertRate struct {
+	horizon time.Duration    // How far ahead inserts are predicted
+	rate    float64          // Smoothed new elements per second
+	start   time.Time        // Start of the current window
+	count   int              // New elements in the current window
+	early   bool             // Whether the current window already resized early
+	now     func() time.Time // Clock, replaced in tests
+}
+
+// newInsertRate creates an insertRate predicting horizon ahead.
+func newInsertRate(horizon time.Duration) *insertRate {
+	return &insertRate{horizon: horizon, start: time.Now(), now: time.Now}
+}
+
+// window returns the length of a smoothing window.
+func (r *insertRate) window() time.Duration {
+	return max(r.horizon/4, time.Millisecond)
+}
+
+// roll folds the current window into the rate once it is over.
+// A window without inserts halves the rate, so an idle set stops predicting bursts.
+func (r *insertRate) roll() {
+	now := r.now()
+	elapsed := now.Sub(r.start)
+	if elapsed < r.window() {
+		return
+	}
+	r.rate = r.rate/2 + float64(r.count)/elapsed.Seconds()/2
+	r.start = now
+	r.count = 0
+	r.early = false
+}
+
+// add records a new element.
+func (r *insertRate) add() {
+	if r == nil {
+		return
+	}
+	r.roll()
+	r.count++
+}
+
+// burst reports whether the elements expected within the horizon would push size over the load factor
+// threshold of capacity. It reports true at most once per window, so a burst doubles the set once at a time.
+func (r *insertRate) burst(size, capacity int) bool {
+	if r == nil {
+		return false
+	}
+	r.roll()
+	if r.early {
+		return false
+	}
+	expected := size + int(r.rate*r.horizon.Seconds())
+	if float64(expected)/float64(capacity) <= loadFactorThreshold {
+		return false
+	}
+	r.early = true
+	return true
+}
+
+// clone returns an independent copy of the rate sharing the clock.
+func (r *insertRate) clone() *insertRate {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	return &c
+}
+
+// ResizeIfPredicted resizes the set now if the recent insert rate predicts crossing the load factor
+// threshold within the ResizeHorizon option, and reports whether it did. Calling it from idle periods
+// moves the resize out of the next burst. Without the option it does nothing and reports false.
+func (h *HashSet) ResizeIfPredicted() bool {
+	h.checkMutable()
+	if h.opts.BucketLimit > 0 || h.Capacity >= maxCapacity || !h.rate.burst(h.Size, h.Capacity) {
+		return false
+	}
+	h.resize()
+	return true
+}
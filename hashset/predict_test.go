@@ -0,0 +1,89 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHashSet_ResizeHorizon(t *testing.T) {
+	now := time.Unix(0, 0)
+	set := mustHashSet(t, Options{Capacity: 1024, ResizeHorizon: time.Second})
+	set.rate.now = func() time.Time { return now }
+	set.rate.start = now
+
+	// A steady trickle well below the threshold predicts nothing
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("slow%d", i)))
+		now = now.Add(100 * time.Millisecond)
+	}
+	if set.Capacity != 1024 || set.ResizeIfPredicted() {
+		t.Errorf("Expected no early resize at 10 inserts per second, got capacity %d", set.Capacity)
+	}
+
+	// A burst of 2000 inserts per second is predicted to cross the threshold within the horizon
+	// once its first window is over, while the 700 elements alone stay below it
+	for i := 0; i < 600; i++ {
+		set.Add([]byte(fmt.Sprintf("fast%d", i)))
+		now = now.Add(500 * time.Microsecond)
+	}
+	if set.Capacity != 2048 {
+		t.Errorf("Expected an early resize to 2048 buckets, got %d", set.Capacity)
+	}
+	if set.Size != 700 || !set.Contains([]byte("slow0")) || !set.Contains([]byte("fast599")) {
+		t.Errorf("Expected every element to survive the early resize")
+	}
+}
+
+func TestHashSet_ResizeIfPredicted(t *testing.T) {
+	if set := NewHashSet(); set.ResizeIfPredicted() {
+		t.Errorf("Expected no resize without the ResizeHorizon option")
+	}
+
+	now := time.Unix(0, 0)
+	set := mustHashSet(t, Options{Capacity: 1024, ResizeHorizon: time.Second})
+	set.rate.now = func() time.Time { return now }
+	set.rate.start = now
+	set.rate.early = true // Keep the burst from resizing during the inserts
+	for i := 0; i < 500; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	now = now.Add(300 * time.Millisecond) // The burst is over, the set is idle
+	if !set.ResizeIfPredicted() || set.Capacity != 2048 {
+		t.Errorf("Expected an idle resize to 2048 buckets, got %d", set.Capacity)
+	}
+	if set.ResizeIfPredicted() {
+		t.Errorf("Expected at most one early resize per window")
+	}
+}
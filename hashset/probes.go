@@ -0,0 +1,174 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import "bytes"
+
+const probeBuckets = 17 // one bucket per comparison count, the last one is open ended
+
+// ProbeHistogram is a histogram of the element comparisons lookups made before resolving.
+// Bucket i counts the lookups that compared i elements, the last bucket those that compared probeBuckets-1 or more.
+type ProbeHistogram struct {
+	Buckets [probeBuckets]uint64 // Lookup counts per number of comparisons
+	Count   uint64               // Number of lookups
+	Total   uint64               // Sum of the comparisons
+}
+
+// ProbeStats holds the comparison histograms of Contains, split by outcome so hits in a few long chains
+// stand out from misses scanning every bucket.
+type ProbeStats struct {
+	Name   string         // Name of the set, see the Name option
+	Hits   ProbeHistogram // Comparisons of Contains calls that found the element
+	Misses ProbeHistogram // Comparisons of Contains calls that did not
+}
+
+// observe records a lookup that compared n elements.
+func (p *ProbeHistogram) observe(n int) {
+	p.Buckets[min(n, probeBuckets-1)]++
+	p.Count++
+	p.Total += uint64(n)
+}
+
+// Mean returns the average number of comparisons, or 0 if no lookup was recorded.
+func (p *ProbeHistogram) Mean() float64 {
+	if p.Count == 0 {
+		return 0
+	}
+	return float64(p.Total) / float64(p.Count)
+}
+
+// Quantile returns the number of comparisons within which a fraction q of the lookups resolved,
+// probeBuckets-1 standing for that many or more. It returns 0 if no lookup was recorded.
+func (p *ProbeHistogram) Quantile(q float64) int {
+	target := uint64(q * float64(p.Count))
+	seen := uint64(0)
+	for i, n := range p.Buckets {
+		seen += n
+		if seen > target {
+			return i
+		}
+	}
+	return 0
+}
+
+// observe records a Contains call for value that compared n elements.
+func (p *ProbeStats) observe(found bool, n int) {
+	if p == nil {
+		return
+	}
+	if found {
+		p.Hits.observe(n)
+	} else {
+		p.Misses.observe(n)
+	}
+}
+
+// ProbeStats returns a copy of the comparison histograms recorded with the Profiling option.
+// It returns zero histograms if the set was not created with the Profiling option.
+func (h *HashSet) ProbeStats() ProbeStats {
+	if h.probes == nil {
+		return ProbeStats{Name: h.opts.Name}
+	}
+	stats := *h.probes
+	stats.Name = h.opts.Name
+	return stats
+}
+
+// comparisons returns the number of elements a lookup of value compares before resolving.
+// It repeats the lookup counting its steps, so only profiled sets pay for the count.
+func (h *HashSet) comparisons(value []byte) int {
+	if !h.lengths.mayContain(len(value)) {
+		return 0 // Ruled out by its length
+	}
+	digest := h.digest(value)
+	if !h.bloom.mayContain(digest) {
+		return 0 // Ruled out without looking at the buckets
+	}
+	index := h.home(digest)
+
+	switch {
+	case h.opts.Strategy == OpenAddressing:
+		for probes := 0; probes < h.Capacity; probes++ {
+			bucket := h.Buckets[(index+probes)%h.Capacity]
+			if len(bucket) == 0 {
+				return probes
+			}
+			if bytes.Equal(bucket[0].([]byte), value) {
+				return probes + 1
+			}
+		}
+		return h.Capacity
+
+	case h.opts.SortedBuckets:
+		bucket := h.Buckets[index]
+		n := 0
+		for lo, hi := 0, len(bucket); lo < hi; n++ {
+			mid := int(uint(lo+hi) >> 1)
+			switch c := bytes.Compare(bucket[mid].([]byte), value); {
+			case c == 0:
+				return n + 1
+			case c < 0:
+				lo = mid + 1
+			default:
+				hi = mid
+			}
+		}
+		return n
+	}
+
+	if positions, ok := h.secondaryPositions(index, value); ok {
+		for probes, i := range positions {
+			if bytes.Equal(h.Buckets[index][i].([]byte), value) {
+				return probes + 1
+			}
+		}
+		return len(positions)
+	}
+	if tags, ok := h.tags[index]; ok {
+		n := 0
+		for i, tag := range tags {
+			if tag != chainTag(digest) {
+				continue
+			}
+			n++
+			if bytes.Equal(h.Buckets[index][i].([]byte), value) {
+				return n
+			}
+		}
+		return n
+	}
+	for i, item := range h.Buckets[index] {
+		if bytes.Equal(item.([]byte), value) {
+			return i + 1
+		}
+	}
+	return len(h.Buckets[index])
+}
@@ -0,0 +1,89 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_ProbeStats(t *testing.T) {
+	if stats := NewHashSet().ProbeStats(); stats.Hits.Count != 0 || stats.Misses.Count != 0 {
+		t.Errorf("Expected no probe stats without the Profiling option")
+	}
+
+	set := mustHashSet(t, Options{Name: "probes", Capacity: 1, BucketLimit: 4, Profiling: true})
+	for _, value := range []string{"a", "b", "c", "d"} {
+		set.Add([]byte(value)) // A single bucket holding the elements in insertion order
+	}
+	set.Contains([]byte("a"))
+	set.Contains([]byte("d"))
+	set.Contains([]byte("d"))
+	set.Contains([]byte("missing"))
+
+	stats := set.ProbeStats()
+	if stats.Name != "probes" {
+		t.Errorf("Expected the name of the set, got %q", stats.Name)
+	}
+	if stats.Hits.Count != 3 || stats.Hits.Buckets[1] != 1 || stats.Hits.Buckets[4] != 2 {
+		t.Errorf("Expected hits after 1 and 4 comparisons, got %+v", stats.Hits)
+	}
+	if stats.Hits.Mean() != 3 || stats.Hits.Quantile(0.5) != 4 {
+		t.Errorf("Expected a mean of 3 and a median of 4 comparisons, got %v and %d", stats.Hits.Mean(), stats.Hits.Quantile(0.5))
+	}
+	if stats.Misses.Count != 1 {
+		t.Errorf("Expected one miss, got %d", stats.Misses.Count)
+	}
+	if n := stats.Misses.Total; n != 0 && n != 4 {
+		t.Errorf("Expected the miss to be ruled out by the bloom or to scan the bucket, got %d comparisons", n)
+	}
+}
+
+func TestHashSet_ProbeStatsStrategies(t *testing.T) {
+	for _, opts := range []Options{
+		{Profiling: true, SortedBuckets: true},
+		{Profiling: true, Strategy: OpenAddressing},
+		{Profiling: true, SecondaryHashing: true},
+	} {
+		set := mustHashSet(t, opts)
+		for i := 0; i < 100; i++ {
+			set.Add([]byte(fmt.Sprintf("test%d", i)))
+		}
+		for i := 0; i < 100; i++ {
+			set.Contains([]byte(fmt.Sprintf("test%d", i)))
+		}
+
+		stats := set.ProbeStats()
+		if stats.Hits.Count != 100 || stats.Hits.Buckets[0] != 0 {
+			t.Errorf("Expected every hit to compare at least one element with %v, got %+v", opts.Strategy, stats.Hits)
+		}
+	}
+}
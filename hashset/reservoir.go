@@ -0,0 +1,85 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"math/rand/v2"
+	"slices"
+)
+
+// reservoir keeps a uniform random sample of the values offered to it, using Algorithm R.
+type reservoir struct {
+	sample  [][]byte // Sampled values
+	size    int      // Maximum sample size
+	offered uint64   // Number of values offered
+}
+
+// newReservoir creates an empty reservoir holding up to size values.
+func newReservoir(size int) *reservoir {
+	return &reservoir{
+		sample: make([][]byte, 0, max(size, 0)),
+		size:   max(size, 0),
+	}
+}
+
+// clone returns an independent copy of the sample.
+func (r *reservoir) clone() *reservoir {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	c.sample = slices.Clone(r.sample)
+	return &c
+}
+
+// offer samples value with a probability of size / offered.
+func (r *reservoir) offer(value []byte) {
+	r.offered++
+	if len(r.sample) < r.size {
+		r.sample = append(r.sample, value)
+		return
+	}
+
+	if j := rand.Uint64N(r.offered); j < uint64(r.size) {
+		r.sample[j] = value // Replace a random sampled value
+	}
+}
+
+// Reservoir returns a uniform random sample of the values Add refused under the MaxDistinct option,
+// and the number of refused values, duplicates included. With n refusals and a sample of k values,
+// every refused value had the same k/n chance of being sampled.
+// It returns nil and 0 if the set was not created with the MaxDistinct option.
+func (h *HashSet) Reservoir() ([][]byte, uint64) {
+	if h.reservoir == nil {
+		return nil, 0
+	}
+	return append([][]byte(nil), h.reservoir.sample...), h.reservoir.offered
+}
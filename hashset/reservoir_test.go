@@ -0,0 +1,95 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_MaxDistinct(t *testing.T) {
+	set := mustHashSet(t, Options{MaxDistinct: 100, ReservoirSample: 10})
+
+	for i := 0; i < 1000; i++ {
+		_, err := set.Add([]byte(fmt.Sprintf("test%d", i)))
+		if (i >= 100) != errors.Is(err, ErrCapacityExceeded) {
+			t.Fatalf("Expected add %d to be refused only past the limit, got %v", i, err)
+		}
+	}
+
+	if set.Size != 100 {
+		t.Errorf("Expected set size to be capped at 100, got %d", set.Size)
+	}
+
+	sample, refused := set.Reservoir()
+	if len(sample) != 10 || refused != 900 {
+		t.Errorf("Expected a sample of 10 out of 900 refused, got %d out of %d", len(sample), refused)
+	}
+
+	for _, value := range sample {
+		if set.Contains(value) {
+			t.Errorf("Expected sampled %s to be a refused value", value)
+		}
+	}
+
+	// Existing members are still accepted as duplicates
+	if ok, err := set.Add([]byte("test0")); ok || err != nil {
+		t.Errorf("Expected an existing member to be reported as present, got %v %v", ok, err)
+	}
+}
+
+func TestHashSet_ReservoirUniform(t *testing.T) {
+	// Every refused value must have the same chance to be sampled
+	hits := make([]int, 10)
+	for round := 0; round < 2000; round++ {
+		set := mustHashSet(t, Options{MaxDistinct: 1, ReservoirSample: 1})
+		set.Add([]byte("kept"))
+		for i := range hits {
+			set.Add([]byte{byte(i)})
+		}
+
+		sample, _ := set.Reservoir()
+		hits[sample[0][0]]++
+	}
+
+	for i, n := range hits {
+		if n < 100 || n > 300 { // Expected 200 each
+			t.Errorf("Expected value %d to be sampled about 200 times, got %d", i, n)
+		}
+	}
+}
+
+func TestHashSet_ReservoirDisabled(t *testing.T) {
+	if sample, refused := NewHashSet().Reservoir(); sample != nil || refused != 0 {
+		t.Errorf("Expected no reservoir without MaxDistinct")
+	}
+}
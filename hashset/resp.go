@@ -0,0 +1,92 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+)
+
+const respBatchSize = 1000 // members per SADD command
+
+// ExportRESP writes every element of the set to w as RESP encoded SADD commands adding it to the Redis set key.
+// Members are batched, up to 1000 per command, and the output can be piped directly into a Redis connection.
+func (h *HashSet) ExportRESP(key string, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	batch := make([][]byte, 0, min(h.Size, respBatchSize))
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			batch = append(batch, item.([]byte))
+			if len(batch) == respBatchSize {
+				if err := writeSADD(bw, key, batch); err != nil {
+					return err
+				}
+				batch = batch[:0]
+			}
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := writeSADD(bw, key, batch); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// writeSADD writes a single SADD command of members as a RESP array of bulk strings.
+func writeSADD(w *bufio.Writer, key string, members [][]byte) error {
+	writeHeader := func(prefix byte, n int) {
+		w.WriteByte(prefix)
+		w.WriteString(strconv.Itoa(n))
+		w.WriteString("\r\n")
+	}
+
+	writeBulk := func(b []byte) {
+		writeHeader('$', len(b))
+		w.Write(b)
+		w.WriteString("\r\n")
+	}
+
+	writeHeader('*', len(members)+2)
+	writeBulk([]byte("SADD"))
+	writeBulk([]byte(key))
+	for _, member := range members {
+		writeBulk(member)
+	}
+
+	// A bufio.Writer keeps the first error and returns it from every later write
+	_, err := w.Write(nil)
+	return err
+}
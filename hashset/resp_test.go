@@ -0,0 +1,79 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_ExportRESP(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("a\r\nb"))
+
+	var buf bytes.Buffer
+	if err := set.ExportRESP("dedup", &buf); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	want := "*3\r\n$4\r\nSADD\r\n$5\r\ndedup\r\n$4\r\na\r\nb\r\n"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestHashSet_ExportRESPBatches(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 2500; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	var buf bytes.Buffer
+	if err := set.ExportRESP("dedup", &buf); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	if n := bytes.Count(buf.Bytes(), []byte("$4\r\nSADD\r\n")); n != 3 {
+		t.Errorf("Expected 3 SADD commands, got %d", n)
+	}
+
+	for _, header := range []string{"*1002\r\n", "*502\r\n"} {
+		if !bytes.Contains(buf.Bytes(), []byte(header)) {
+			t.Errorf("Expected a command with header %q", header)
+		}
+	}
+
+	buf.Reset()
+	if err := NewHashSet().ExportRESP("dedup", &buf); err != nil || buf.Len() != 0 {
+		t.Errorf("Expected an empty set to write nothing, got %q %v", buf.String(), err)
+	}
+}
@@ -0,0 +1,69 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+// joinInto concatenates parts into scratch, reusing its backing array when it is large enough.
+func joinInto(scratch []byte, parts [][]byte) []byte {
+	key := scratch[:0]
+	for _, part := range parts {
+		key = append(key, part...)
+	}
+	return key
+}
+
+// ContainsInto checks if the concatenation of parts is in the set, building it in scratch.
+// A loop passing the same scratch buffer, with capacity for the longest key, looks composite keys up
+// without allocating. Parts are concatenated as is, so "ab"+"c" and "a"+"bc" are the same key.
+func (h *HashSet) ContainsInto(scratch []byte, parts ...[]byte) bool {
+	return h.Contains(joinInto(scratch, parts))
+}
+
+// AddInto inserts the concatenation of parts, building it in scratch, see ContainsInto.
+// A key already present is found without allocating, only a new element is copied out of scratch.
+func (h *HashSet) AddInto(scratch []byte, parts ...[]byte) (bool, error) {
+	h.checkMutable()
+
+	key := joinInto(scratch, parts)
+	if h.counts == nil && h.times == nil && h.has(h.key(key)) {
+		return false, nil // Nothing to count or refresh
+	}
+	return h.Add(append([]byte(nil), key...))
+}
+
+// RemoveInto deletes the concatenation of parts, building it in scratch, see ContainsInto.
+func (h *HashSet) RemoveInto(scratch []byte, parts ...[]byte) {
+	h.checkMutable()
+
+	key := h.key(joinInto(scratch, parts))
+	if index, i := h.locate(h.digest(key), key); i >= 0 {
+		h.Remove(h.Buckets[index][i].([]byte)) // Removed by its stored instance, scratch is not retained
+	}
+}
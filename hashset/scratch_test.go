@@ -0,0 +1,67 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import "testing"
+
+func TestHashSet_ContainsInto(t *testing.T) {
+	set := NewHashSet()
+	scratch := make([]byte, 0, 64)
+
+	if added, err := set.AddInto(scratch, []byte("user:"), []byte("42")); !added || err != nil {
+		t.Fatalf("Expected the composite key to be added, got %v, %v", added, err)
+	}
+	if added, _ := set.AddInto(scratch, []byte("user:4"), []byte("2")); added {
+		t.Errorf("Expected the same concatenation to be present")
+	}
+	if !set.Contains([]byte("user:42")) {
+		t.Errorf("Expected the concatenation to be stored")
+	}
+
+	// The stored element does not alias scratch
+	scratch = append(scratch[:0], "overwritten"...)
+	if !set.ContainsInto(scratch, []byte("user:"), []byte("42")) || set.ContainsInto(scratch, []byte("user:"), []byte("43")) {
+		t.Errorf("Unexpected ContainsInto results")
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		set.ContainsInto(scratch, []byte("user:"), []byte("42"))
+		set.AddInto(scratch, []byte("user:"), []byte("42"))
+	})
+	if allocs != 0 {
+		t.Errorf("Expected lookups of present keys not to allocate, got %v allocations", allocs)
+	}
+
+	set.RemoveInto(scratch, []byte("user"), []byte(":42"))
+	if set.Contains([]byte("user:42")) || set.Size != 0 {
+		t.Errorf("Expected the composite key to be removed")
+	}
+}
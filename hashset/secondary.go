@@ -0,0 +1,114 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+const secondaryThreshold = 8             // chain length at which a bucket gets a secondary index
+const secondarySlots = 8                 // number of secondary slots per indexed bucket
+const secondarySeed = 0x9e3779b97f4a7c15 // seed mixed into the set seed for the secondary hash
+
+// secondaryHash computes the secondary slot for a given value.
+func (h *HashSet) secondaryHash(value []byte) int {
+	return int(hash64(value, h.Seed^secondarySeed) % secondarySlots)
+}
+
+// secondaryPositions returns the positions within the bucket at index that share the secondary hash of value.
+// The second return value is false if the bucket is not indexed and must be scanned in full.
+func (h *HashSet) secondaryPositions(index int, value []byte) ([]int, bool) {
+	if h.secondary == nil {
+		return nil, false
+	}
+
+	slots, ok := h.secondary[index]
+	if !ok {
+		return nil, false
+	}
+
+	return slots[h.secondaryHash(value)], true
+}
+
+// secondaryInsert indexes the element just appended to the bucket at index.
+func (h *HashSet) secondaryInsert(index int, value []byte) {
+	if !h.opts.SecondaryHashing || h.opts.SortedBuckets { // Sorted buckets are binary searched instead
+		return
+	}
+
+	slots, ok := h.secondary[index]
+	if !ok {
+		if len(h.Buckets[index]) > secondaryThreshold {
+			h.secondaryIndex(index) // The chain just became long
+		}
+		return
+	}
+
+	slot := h.secondaryHash(value)
+	slots[slot] = append(slots[slot], len(h.Buckets[index])-1)
+}
+
+// secondaryRemove re-indexes the bucket at index after an element was removed from it.
+func (h *HashSet) secondaryRemove(index int) {
+	if _, ok := h.secondary[index]; !ok {
+		return
+	}
+
+	delete(h.secondary, index)
+	if len(h.Buckets[index]) > secondaryThreshold {
+		h.secondaryIndex(index) // Positions shifted, so index the chain again
+	}
+}
+
+// secondaryIndex builds the secondary index of the bucket at index.
+func (h *HashSet) secondaryIndex(index int) {
+	if h.secondary == nil {
+		h.secondary = make(map[int][][]int)
+	}
+
+	slots := make([][]int, secondarySlots)
+	for i, item := range h.Buckets[index] {
+		slot := h.secondaryHash(item.([]byte))
+		slots[slot] = append(slots[slot], i)
+	}
+
+	h.secondary[index] = slots
+}
+
+// secondaryRebuild rebuilds the secondary index of every long bucket.
+func (h *HashSet) secondaryRebuild() {
+	h.secondary = nil
+	if !h.opts.SecondaryHashing || h.opts.SortedBuckets { // Sorted buckets are binary searched instead
+		return
+	}
+
+	for index, bucket := range h.Buckets {
+		if len(bucket) > secondaryThreshold {
+			h.secondaryIndex(index)
+		}
+	}
+}
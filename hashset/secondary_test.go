@@ -0,0 +1,88 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_SecondaryHashing(t *testing.T) {
+	// A single bucket forces every element into one long chain
+	set := mustHashSet(t, Options{Capacity: 1, SecondaryHashing: true})
+
+	for i := 0; i < 32; i++ {
+		set.insert(0, []byte(fmt.Sprintf("test%d", i)))
+		set.Size++
+	}
+
+	if _, ok := set.secondary[0]; !ok {
+		t.Fatalf("Expected long chain to be indexed")
+	}
+
+	for i := 0; i < 32; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		if !set.Contains(value) {
+			t.Errorf("Expected set to contain %v", value)
+		}
+	}
+
+	// Removing shifts positions and must keep the index consistent
+	for i := 0; i < 32; i += 2 {
+		set.Remove([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	for i := 0; i < 32; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		if set.Contains(value) != (i%2 == 1) {
+			t.Errorf("Unexpected membership for %v", value)
+		}
+	}
+
+	if set.Size != 16 {
+		t.Errorf("Expected size to be 16, got %d", set.Size)
+	}
+}
+
+func TestHashSet_SecondaryHashingResize(t *testing.T) {
+	set := mustHashSet(t, Options{SecondaryHashing: true})
+
+	for i := 0; i < 10_000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	for i := 0; i < 10_000; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		if !set.Contains(value) {
+			t.Errorf("Expected set to contain %v", value)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import "fmt"
+
+const selfTestSamples = 16 // members checked in place by SelfTest
+
+// selfTestSentinel is the value SelfTest adds to and removes from a scratch set.
+var selfTestSentinel = []byte("\x00k4-hashset-self-test\x00")
+
+// SelfTest runs a cheap check that the set can answer lookups, leaving it untouched.
+// It checks the bucket array matches the capacity, that a sample of members is found where
+// the hash places them, and that a scratch set with the same seed and hasher adds, finds and removes a sentinel.
+func (h *HashSet) SelfTest() error {
+	if h.Capacity <= 0 || h.Capacity&(h.Capacity-1) != 0 || len(h.Buckets) != h.Capacity {
+		return fmt.Errorf("hashset self-test: %d buckets for capacity %d", len(h.Buckets), h.Capacity)
+	}
+
+	if h.Size < 0 {
+		return fmt.Errorf("hashset self-test: negative size %d", h.Size)
+	}
+
+	if h.hasher == nil || h.hasher.ID() != h.Hasher {
+		return fmt.Errorf("hashset self-test: hasher does not match recorded hasher %d", h.Hasher)
+	}
+
+	// Sample members spread over the buckets
+	stride := max(h.Capacity/selfTestSamples, 1)
+	for index := 0; index < h.Capacity; index += stride {
+		for _, item := range h.Buckets[index] {
+			value, ok := item.([]byte)
+			if !ok {
+				return fmt.Errorf("hashset self-test: unexpected element type %T in bucket %d", item, index)
+			}
+			if h.opts.Strategy != OpenAddressing && h.home(h.digest(value)) != index || !h.has(value) {
+				return fmt.Errorf("hashset self-test: member of bucket %d is not found by its hash", index)
+			}
+			break // One member per sampled bucket
+		}
+	}
+
+	// Exercise the write path on a scratch set hashing like this one
+	scratch := newHashSet(initialCapacity, h.Seed)
+	scratch.Hasher, scratch.hasher = h.Hasher, h.hasher
+
+	scratch.Add(selfTestSentinel)
+	if !scratch.Contains(selfTestSentinel) || scratch.Size != 1 {
+		return fmt.Errorf("hashset self-test: sentinel not found after add")
+	}
+
+	scratch.Remove(selfTestSentinel)
+	if scratch.Contains(selfTestSentinel) || scratch.Size != 0 {
+		return fmt.Errorf("hashset self-test: sentinel found after remove")
+	}
+
+	return nil
+}
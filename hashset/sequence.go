@@ -0,0 +1,189 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+)
+
+// lifetime is the range of sequence numbers a removed element lived in, added at or after added and
+// removed at removed.
+type lifetime struct {
+	added   uint64 // Sequence number the element was added at
+	removed uint64 // Sequence number the element was removed at
+}
+
+// memberSequences records the sequence number of every change to the elements, for the Sequences option.
+// A nil memberSequences records nothing.
+type memberSequences struct {
+	next    uint64              // Sequence number of the next change
+	added   map[string]uint64   // Sequence number each element was added at
+	removed map[string]lifetime // Latest lifetime of each removed element
+}
+
+// newMemberSequences creates an empty memberSequences.
+func newMemberSequences() *memberSequences {
+	return &memberSequences{
+		next:    1,
+		added:   make(map[string]uint64),
+		removed: make(map[string]lifetime),
+	}
+}
+
+// clone returns an independent copy of the sequences.
+func (s *memberSequences) clone() *memberSequences {
+	if s == nil {
+		return nil
+	}
+	return &memberSequences{next: s.next, added: maps.Clone(s.added), removed: maps.Clone(s.removed)}
+}
+
+// stamp returns the sequence number of a change and advances to the next one.
+func (s *memberSequences) stamp() uint64 {
+	seq := s.next
+	s.next++
+	return seq
+}
+
+// add records value as added by the next change.
+func (s *memberSequences) add(value []byte) {
+	if s == nil {
+		return
+	}
+	s.added[string(value)] = s.stamp()
+}
+
+// remove records value as removed by the next change, keeping its lifetime for the views before it.
+func (s *memberSequences) remove(value []byte) {
+	if s == nil {
+		return
+	}
+	s.removed[string(value)] = lifetime{added: s.added[string(value)], removed: s.stamp()}
+	delete(s.added, string(value))
+}
+
+// at makes seq the sequence number of the next change.
+func (s *memberSequences) at(seq uint64) {
+	if s == nil {
+		return
+	}
+	s.next = seq
+}
+
+// after moves past seq once the change at seq is done or did not happen.
+func (s *memberSequences) after(seq uint64) {
+	if s == nil {
+		return
+	}
+	s.next = max(s.next, seq+1)
+}
+
+// clear drops the sequences of every element. The next sequence number is kept so views never go back.
+func (s *memberSequences) clear() {
+	if s == nil {
+		return
+	}
+	clear(s.added)
+	clear(s.removed)
+}
+
+// Sequence returns the sequence number the next change is stamped with under the Sequences option,
+// or 0 if the set was not created with it.
+func (h *HashSet) Sequence() uint64 {
+	if h.seqs == nil {
+		return 0
+	}
+	return h.seqs.next
+}
+
+// AddSeq inserts value like Add and stamps the change with seq under the Sequences option,
+// so the set follows the sequence numbers of the LSM tree. The changes after it are stamped from seq+1.
+// Sequence numbers are meant to increase, a lower seq is recorded as given and only affects the views of value.
+func (h *HashSet) AddSeq(value []byte, seq uint64) (bool, error) {
+	h.checkMutable()
+	h.seqs.at(seq)
+	defer h.seqs.after(seq)
+	return h.Add(value)
+}
+
+// RemoveSeq deletes value like Remove and stamps the change with seq under the Sequences option, see AddSeq.
+func (h *HashSet) RemoveSeq(value []byte, seq uint64) {
+	h.checkMutable()
+	h.seqs.at(seq)
+	defer h.seqs.after(seq)
+	h.Remove(value)
+}
+
+// ForEachAsOf visits, ordered by bytes.Compare, the elements the set held once the change stamped seq was made:
+// those added at or before seq and not removed by then, including the ones removed since. It stops when fn
+// returns false. An element only keeps its latest removal, after a remove, add and remove again the views
+// before the first removal no longer see it. Elements of a decoded set carry sequence number 0.
+// It visits nothing if the set was not created with the Sequences option.
+func (h *HashSet) ForEachAsOf(seq uint64, fn func(value []byte) bool) {
+	if h.seqs == nil {
+		return
+	}
+
+	var visible [][]byte
+	h.ForEach(func(value []byte) bool {
+		if h.seqs.added[string(value)] <= seq {
+			visible = append(visible, value)
+		} else if life, ok := h.seqs.removed[string(value)]; ok && life.added <= seq && seq < life.removed {
+			visible = append(visible, value) // Removed and added again after seq
+		}
+		return true
+	})
+	for key, life := range h.seqs.removed {
+		if _, ok := h.seqs.added[key]; !ok && life.added <= seq && seq < life.removed {
+			visible = append(visible, []byte(key))
+		}
+	}
+
+	slices.SortFunc(visible, bytes.Compare)
+	for _, value := range visible {
+		if !fn(value) {
+			return
+		}
+	}
+}
+
+// PurgeSequences drops the lifetimes of the elements removed at or before seq, once no view older than seq is read.
+func (h *HashSet) PurgeSequences(seq uint64) {
+	h.checkMutable()
+	if h.seqs == nil {
+		return
+	}
+	maps.DeleteFunc(h.seqs.removed, func(_ string, life lifetime) bool {
+		return life.removed <= seq
+	})
+}
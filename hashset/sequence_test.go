@@ -0,0 +1,115 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"slices"
+	"testing"
+)
+
+// asOf collects the elements ForEachAsOf visits at seq.
+func asOf(set *HashSet, seq uint64) []string {
+	var values []string
+	set.ForEachAsOf(seq, func(value []byte) bool {
+		values = append(values, string(value))
+		return true
+	})
+	return values
+}
+
+func TestHashSet_ForEachAsOf(t *testing.T) {
+	set := mustHashSet(t, Options{Sequences: true})
+	set.Add([]byte("a"))       // 1
+	set.Add([]byte("b"))       // 2
+	set.Remove([]byte("a"))    // 3
+	set.Add([]byte("c"))       // 4
+	set.Add([]byte("a"))       // 5
+	set.Remove([]byte("b"))    // 6
+	set.Remove([]byte("none")) // Not a change
+
+	if seq := set.Sequence(); seq != 7 {
+		t.Errorf("Expected the next sequence number to be 7, got %d", seq)
+	}
+
+	views := map[uint64][]string{
+		0: nil,
+		1: {"a"},
+		2: {"a", "b"},
+		3: {"b"},
+		4: {"b", "c"},
+		5: {"a", "b", "c"},
+		6: {"a", "c"},
+		9: {"a", "c"},
+	}
+	for seq, want := range views {
+		if got := asOf(set, seq); !slices.Equal(got, want) {
+			t.Errorf("Expected %v as of %d, got %v", want, seq, got)
+		}
+	}
+
+	set.PurgeSequences(6)
+	if got := asOf(set, 4); !slices.Equal(got, []string{"c"}) {
+		t.Errorf("Expected the purged removals to be dropped, got %v", got)
+	}
+}
+
+func TestHashSet_AddSeq(t *testing.T) {
+	set := mustHashSet(t, Options{Sequences: true})
+	set.AddSeq([]byte("a"), 100)
+	set.AddSeq([]byte("a"), 110) // Already present, not a change
+	set.Add([]byte("b"))
+	set.RemoveSeq([]byte("a"), 200)
+
+	if seq := set.Sequence(); seq != 201 {
+		t.Errorf("Expected the next sequence number to be 201, got %d", seq)
+	}
+	if got := asOf(set, 99); got != nil {
+		t.Errorf("Expected nothing before the first change, got %v", got)
+	}
+	if got := asOf(set, 150); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Expected [a b] as of 150, got %v", got)
+	}
+	if got := asOf(set, 200); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("Expected [b] as of 200, got %v", got)
+	}
+
+	clone := set.Clone()
+	clone.Add([]byte("c"))
+	if got := asOf(set, 300); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("Expected a clone to keep its own sequence numbers, got %v", got)
+	}
+
+	plain := NewHashSet()
+	plain.Add([]byte("a"))
+	if got := asOf(plain, 10); got != nil {
+		t.Errorf("Expected a set without Sequences to visit nothing, got %v", got)
+	}
+}
@@ -0,0 +1,195 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// writeUvarint writes v to w as an unsigned varint.
+func writeUvarint(w io.Writer, v uint64) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	_, err := w.Write(buf[:n])
+	return err
+}
+
+// writeBytes writes a length prefixed byte slice to w.
+func writeBytes(w io.Writer, b []byte) error {
+	if err := writeUvarint(w, uint64(len(b))); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// byteReader is a reader that can also read single bytes, as needed to decode varints.
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// checksumReader computes the crc32 (IEEE) of every byte read through it.
+type checksumReader struct {
+	r   *bufio.Reader // Buffered source
+	crc hash.Hash32   // Checksum of the bytes read so far
+}
+
+// newChecksumReader creates a checksumReader reading from r.
+func newChecksumReader(r io.Reader) *checksumReader {
+	return &checksumReader{
+		r:   bufio.NewReader(r),
+		crc: crc32.NewIEEE(),
+	}
+}
+
+// Read reads into p and adds the bytes read to the checksum.
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.crc.Write(p[:n])
+	return n, err
+}
+
+// ReadByte reads a single byte and adds it to the checksum.
+func (c *checksumReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.crc.Write([]byte{b})
+	}
+	return b, err
+}
+
+// verify reads a little endian crc32 and checks it against the checksum of the bytes read so far.
+func (c *checksumReader) verify() error {
+	sum := c.crc.Sum32()
+
+	var stored [4]byte
+	if _, err := io.ReadFull(c.r, stored[:]); err != nil {
+		return err
+	}
+
+	if binary.LittleEndian.Uint32(stored[:]) != sum {
+		return wrapf(ErrChecksumMismatch, "corrupt hashset: checksum mismatch")
+	}
+	return nil
+}
+
+// readBytes reads a length prefixed byte slice from r.
+func readBytes(r byteReader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read through a limit rather than allocating n bytes up front, n may be corrupt
+	b, err := io.ReadAll(io.LimitReader(r, int64(min(n, math.MaxInt64))))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(b)) != n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return b, nil
+}
+
+// SerializeRange encodes the members of the buckets in [start, end) into w.
+// The capacity and the range bounds are written ahead of the members.
+func (h *HashSet) SerializeRange(start, end int, w io.Writer) error {
+	if start < 0 || end > h.Capacity || start > end {
+		return wrapf(ErrInvalidOption, "invalid bucket range [%d,%d) for capacity %d", start, end, h.Capacity)
+	}
+
+	// Count the members in the range
+	count := 0
+	for _, bucket := range h.Buckets[start:end] {
+		count += len(bucket)
+	}
+
+	bw := bufio.NewWriter(w)
+
+	// Write the header
+	for _, v := range []int{h.Capacity, start, end, count} {
+		if err := writeUvarint(bw, uint64(v)); err != nil {
+			return err
+		}
+	}
+
+	// Write the members
+	for _, bucket := range h.Buckets[start:end] {
+		for _, item := range bucket {
+			if err := writeBytes(bw, item.([]byte)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// DeserializeRange decodes a range written by SerializeRange and merges its members into the set.
+func (h *HashSet) DeserializeRange(r io.Reader) error {
+	h.checkMutable()
+
+	br := bufio.NewReader(r)
+
+	// Read the header
+	header := make([]uint64, 4)
+	for i := range header {
+		v, err := binary.ReadUvarint(br)
+		if err != nil {
+			return err
+		}
+		header[i] = v
+	}
+
+	capacity, start, end, count := header[0], header[1], header[2], header[3]
+	if start > end || end > capacity {
+		return wrapf(ErrInvalidOption, "invalid bucket range [%d,%d) for capacity %d", start, end, capacity)
+	}
+
+	// Read the members and add them to the set
+	for i := uint64(0); i < count; i++ {
+		value, err := readBytes(br)
+		if err != nil {
+			return err
+		}
+		if _, err := h.Add(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_SerializeRange(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	// Split the set into two halves
+	mid := set.Capacity / 2
+	var first, second bytes.Buffer
+
+	if err := set.SerializeRange(0, mid, &first); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := set.SerializeRange(mid, set.Capacity, &second); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewHashSet()
+	if err := restored.DeserializeRange(&first); err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.Size >= set.Size {
+		t.Errorf("Expected partial set to be smaller than %d, got %d", set.Size, restored.Size)
+	}
+
+	if err := restored.DeserializeRange(&second); err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.Size != set.Size {
+		t.Errorf("Expected restored size to be %d, got %d", set.Size, restored.Size)
+	}
+
+	for i := 0; i < 1000; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		if !restored.Contains(value) {
+			t.Errorf("Expected restored set to contain %v", value)
+		}
+	}
+}
+
+func TestHashSet_SerializeRangeInvalid(t *testing.T) {
+	set := NewHashSet()
+	var buf bytes.Buffer
+
+	if err := set.SerializeRange(0, set.Capacity+1, &buf); err == nil {
+		t.Errorf("Expected error for range past capacity")
+	}
+
+	if err := set.SerializeRange(4, 2, &buf); err == nil {
+		t.Errorf("Expected error for inverted range")
+	}
+}
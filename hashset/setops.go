@@ -0,0 +1,117 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+// IntersectAll returns a new set of the elements present in every one of sets.
+// The smallest set is iterated once and each element is checked against the others,
+// stopping at the first set that lacks it. It returns an empty set if sets is empty.
+func IntersectAll(sets ...*HashSet) *HashSet {
+	if len(sets) == 0 {
+		return NewHashSet()
+	}
+
+	// Iterate the smallest set, the result cannot be larger
+	smallest := sets[0]
+	for _, set := range sets[1:] {
+		if set.Size < smallest.Size {
+			smallest = set
+		}
+	}
+
+	result := NewHashSet()
+	result.reserve(smallest.Size)
+
+	for _, bucket := range smallest.Buckets {
+		for _, item := range bucket {
+			value := item.([]byte)
+			if inAll(value, sets, smallest) {
+				result.Add(value)
+			}
+		}
+	}
+
+	return result
+}
+
+// inAll checks if value is in every one of sets other than skip.
+func inAll(value []byte, sets []*HashSet, skip *HashSet) bool {
+	for _, set := range sets {
+		if set != skip && !set.has(set.key(value)) {
+			return false // Short-circuit on the first miss
+		}
+	}
+	return true
+}
+
+// UnionAll returns a new set of the elements present in any of sets.
+// The result is sized up front for the sum of the input sizes, so adding never resizes.
+func UnionAll(sets ...*HashSet) *HashSet {
+	total := 0
+	for _, set := range sets {
+		total += set.Size
+	}
+
+	result := NewHashSet()
+	result.reserve(total) // Upper bound of the union size
+
+	for _, set := range sets {
+		for _, bucket := range set.Buckets {
+			for _, item := range bucket {
+				result.Add(item.([]byte))
+			}
+		}
+	}
+
+	return result
+}
+
+// Split distributes the elements of the set across n new independent sets, n is at least 1.
+// An element goes to the set hash % n, using the same hash as ShardedHashSet, so the split is
+// deterministic and the sets are roughly equal in size. The sets share the seed and hasher of h.
+func (h *HashSet) Split(n int) []*HashSet {
+	n = max(n, 1)
+
+	sets := make([]*HashSet, n)
+	for i := range sets {
+		sets[i] = newHashSet(initialCapacity, h.Seed)
+		sets[i].Hasher, sets[i].hasher = h.Hasher, h.hasher
+		sets[i].reserve(h.Size / n)
+	}
+
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			value := item.([]byte)
+			sets[hash64(value, shardSeed)%uint64(n)].Add(value)
+		}
+	}
+
+	return sets
+}
@@ -0,0 +1,149 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestIntersectAll(t *testing.T) {
+	sets := make([]*HashSet, 5)
+	for i := range sets {
+		sets[i] = NewHashSet()
+		for j := 0; j < 100+i*50; j++ {
+			sets[i].Add([]byte(fmt.Sprintf("test%d", j)))
+		}
+		sets[i].Add([]byte(fmt.Sprintf("only%d", i)))
+	}
+
+	result := IntersectAll(sets...)
+	if result.Size != 100 {
+		t.Errorf("Expected intersection size to be 100, got %d", result.Size)
+	}
+
+	for j := 0; j < 100; j++ {
+		if !result.Contains([]byte(fmt.Sprintf("test%d", j))) {
+			t.Errorf("Expected intersection to contain test%d", j)
+		}
+	}
+
+	if result.Contains([]byte("only0")) {
+		t.Errorf("Expected intersection to not contain only0")
+	}
+}
+
+func TestIntersectAllEmpty(t *testing.T) {
+	if result := IntersectAll(); result.Size != 0 {
+		t.Errorf("Expected empty intersection, got size %d", result.Size)
+	}
+
+	set := NewHashSet()
+	set.Add([]byte("test"))
+	if result := IntersectAll(set, NewHashSet()); result.Size != 0 {
+		t.Errorf("Expected intersection with an empty set to be empty, got size %d", result.Size)
+	}
+
+	if result := IntersectAll(set); !result.Contains([]byte("test")) || result.Size != 1 {
+		t.Errorf("Expected intersection of one set to be a copy of it")
+	}
+}
+
+func TestUnionAll(t *testing.T) {
+	sets := make([]*HashSet, 5)
+	for i := range sets {
+		sets[i] = NewHashSet()
+		for j := 0; j < 100; j++ {
+			sets[i].Add([]byte(fmt.Sprintf("test%d", j+i*50))) // Overlaps the next set by half
+		}
+	}
+
+	result := UnionAll(sets...)
+	if result.Size != 300 {
+		t.Errorf("Expected union size to be 300, got %d", result.Size)
+	}
+
+	for j := 0; j < 300; j++ {
+		if !result.Contains([]byte(fmt.Sprintf("test%d", j))) {
+			t.Errorf("Expected union to contain test%d", j)
+		}
+	}
+
+	// Pre-sized for the sum of the sizes, no resize took place
+	if result.Capacity != capacityFor(500) {
+		t.Errorf("Expected union capacity to be %d, got %d", capacityFor(500), result.Capacity)
+	}
+
+	if result := UnionAll(); result.Size != 0 {
+		t.Errorf("Expected empty union, got size %d", result.Size)
+	}
+}
+
+func TestHashSet_Split(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 10000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	sets := set.Split(4)
+	if len(sets) != 4 {
+		t.Fatalf("Expected 4 sets, got %d", len(sets))
+	}
+
+	total := 0
+	for i, part := range sets {
+		// Roughly equal within 10%
+		if part.Size < 2250 || part.Size > 2750 {
+			t.Errorf("Expected set %d to hold about 2500 elements, got %d", i, part.Size)
+		}
+		total += part.Size
+	}
+
+	if total != set.Size {
+		t.Errorf("Expected the sets to hold %d elements together, got %d", set.Size, total)
+	}
+
+	if UnionAll(sets...).Fingerprint() != set.Fingerprint() {
+		t.Errorf("Expected the union of the sets to equal the original")
+	}
+
+	// Deterministic
+	again := set.Split(4)
+	for i := range sets {
+		if sets[i].Fingerprint() != again[i].Fingerprint() {
+			t.Errorf("Expected set %d to be the same on every split", i)
+		}
+	}
+
+	if parts := NewHashSet().Split(0); len(parts) != 1 || parts[0].Size != 0 {
+		t.Errorf("Expected splitting into 0 sets to return one empty set")
+	}
+}
@@ -0,0 +1,204 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// Sharded binary format
+//
+//	magic   [4]byte "K4SH"
+//	version uint8
+//	shards  uvarint
+//	payload shards x (uvarint length, HashSet binary format)
+const shardedMagic = "K4SH"
+const shardedVersion = 1
+
+const shardSeed = 0x2545f4914f6cdd1d // seed used to pick a shard, independent of the shard seeds
+
+// ShardedHashSet is a hash set split into independently locked shards.
+// It is safe for concurrent use.
+type ShardedHashSet struct {
+	shards []*HashSet      // Shards holding the elements
+	locks  []*sync.RWMutex // Lock of each shard
+}
+
+// NewShardedHashSet creates a new instance of ShardedHashSet with the given number of shards.
+func NewShardedHashSet(shards int) *ShardedHashSet {
+	shards = max(shards, 1)
+
+	s := &ShardedHashSet{
+		shards: make([]*HashSet, shards),
+		locks:  make([]*sync.RWMutex, shards),
+	}
+
+	for i := range s.shards {
+		s.shards[i] = NewHashSet()
+		s.locks[i] = &sync.RWMutex{}
+	}
+
+	return s
+}
+
+// shard returns the shard index of value.
+func (s *ShardedHashSet) shard(value []byte) int {
+	return int(hash64(value, shardSeed) % uint64(len(s.shards)))
+}
+
+// ShardCount returns the number of shards.
+func (s *ShardedHashSet) ShardCount() int {
+	return len(s.shards)
+}
+
+// Add inserts a new element into the set.
+func (s *ShardedHashSet) Add(value []byte) {
+	i := s.shard(value)
+	s.locks[i].Lock()
+	defer s.locks[i].Unlock()
+	s.shards[i].Add(value)
+}
+
+// Remove deletes an element from the set.
+func (s *ShardedHashSet) Remove(value []byte) {
+	i := s.shard(value)
+	s.locks[i].Lock()
+	defer s.locks[i].Unlock()
+	s.shards[i].Remove(value)
+}
+
+// Contains checks if an element is in the set.
+func (s *ShardedHashSet) Contains(value []byte) bool {
+	i := s.shard(value)
+	s.locks[i].RLock()
+	defer s.locks[i].RUnlock()
+	return s.shards[i].Contains(value)
+}
+
+// Len returns the number of elements in the set.
+func (s *ShardedHashSet) Len() int {
+	size := 0
+	for i, shard := range s.shards {
+		s.locks[i].RLock()
+		size += shard.Size
+		s.locks[i].RUnlock()
+	}
+	return size
+}
+
+// Serialize encodes the ShardedHashSet into a byte slice recording the shard count and every shard.
+func (s *ShardedHashSet) Serialize() ([]byte, error) {
+	buf := make([]byte, 0)
+	buf = append(buf, shardedMagic...)
+	buf = append(buf, shardedVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(s.shards)))
+
+	for i, shard := range s.shards {
+		s.locks[i].RLock()
+		data, err := shard.MarshalBinary()
+		s.locks[i].RUnlock()
+		if err != nil {
+			return nil, err
+		}
+
+		buf = binary.AppendUvarint(buf, uint64(len(data)))
+		buf = append(buf, data...)
+	}
+
+	return buf, nil
+}
+
+// DeserializeSharded decodes a byte slice written by ShardedHashSet.Serialize.
+// If shards is zero or matches the encoded shard count the layout is restored as is,
+// otherwise every element is rehashed across the requested number of shards.
+func DeserializeSharded(data []byte, shards int) (*ShardedHashSet, error) {
+	if len(data) < len(shardedMagic)+1 || !bytes.Equal(data[:len(shardedMagic)], []byte(shardedMagic)) {
+		return nil, wrapf(ErrCorrupt, "corrupt sharded hashset: invalid magic")
+	}
+
+	if version := data[len(shardedMagic)]; version != shardedVersion {
+		return nil, wrapf(ErrUnsupportedVersion, "unsupported sharded hashset version %d", version)
+	}
+
+	rest := data[len(shardedMagic)+1:]
+	count, rest, err := readUvarint(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every shard takes at least its length prefix
+	if count == 0 || count > uint64(len(rest)) {
+		return nil, wrapf(ErrCorrupt, "corrupt sharded hashset: invalid shard count %d", count)
+	}
+
+	decoded := make([]*HashSet, count)
+	for i := range decoded {
+		var n uint64
+		n, rest, err = readUvarint(rest)
+		if err != nil {
+			return nil, err
+		}
+
+		if n > uint64(len(rest)) {
+			return nil, wrapf(ErrCorrupt, "corrupt sharded hashset: shard %d length %d exceeds payload", i, n)
+		}
+
+		decoded[i] = &HashSet{}
+		if err := decoded[i].UnmarshalBinary(rest[:n]); err != nil {
+			return nil, fmt.Errorf("shard %d: %w", i, err)
+		}
+		rest = rest[n:]
+	}
+
+	if len(rest) != 0 {
+		return nil, wrapf(ErrCorrupt, "corrupt sharded hashset: %d trailing bytes", len(rest))
+	}
+
+	s := NewShardedHashSet(int(count))
+	if shards == 0 || shards == int(count) {
+		copy(s.shards, decoded)
+		return s, nil
+	}
+
+	// Rehash every element across the new layout
+	s = NewShardedHashSet(shards)
+	for _, shard := range decoded {
+		shard.ForEach(func(value []byte) bool {
+			s.Add(value)
+			return true
+		})
+	}
+
+	return s, nil
+}
@@ -0,0 +1,109 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestShardedHashSet(t *testing.T) {
+	set := NewShardedHashSet(4)
+
+	wg := &sync.WaitGroup{}
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 250; i++ {
+				set.Add([]byte(fmt.Sprintf("test%d", w*250+i)))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if set.Len() != 1000 {
+		t.Errorf("Expected size to be 1000, got %d", set.Len())
+	}
+
+	set.Remove([]byte("test0"))
+	if set.Contains([]byte("test0")) {
+		t.Errorf("Expected set to not contain test0")
+	}
+
+	if !set.Contains([]byte("test1")) {
+		t.Errorf("Expected set to contain test1")
+	}
+}
+
+func TestShardedHashSet_SerializeDeserialize(t *testing.T) {
+	set := NewShardedHashSet(4)
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, shards := range []int{0, 4, 7} {
+		restored, err := DeserializeSharded(data, shards)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := shards
+		if shards == 0 {
+			expected = 4
+		}
+
+		if restored.ShardCount() != expected {
+			t.Errorf("Expected %d shards, got %d", expected, restored.ShardCount())
+		}
+
+		if restored.Len() != 1000 {
+			t.Errorf("Expected size to be 1000, got %d", restored.Len())
+		}
+
+		for i := 0; i < 1000; i++ {
+			value := []byte(fmt.Sprintf("test%d", i))
+			if !restored.Contains(value) {
+				t.Errorf("Expected restored set to contain %v", value)
+			}
+		}
+	}
+
+	if _, err := DeserializeSharded(data[:len(data)-1], 0); err == nil {
+		t.Errorf("Expected error for truncated payload")
+	}
+}
@@ -0,0 +1,105 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// checkSortedBuckets fails the test if a bucket of set is not ordered by bytes.Compare.
+func checkSortedBuckets(t *testing.T, set *HashSet) {
+	t.Helper()
+	for index, bucket := range set.Buckets {
+		if !slices.IsSortedFunc(bucket, compareItems) {
+			t.Errorf("Expected bucket %d to be sorted", index)
+		}
+	}
+}
+
+func TestHashSet_SortedBuckets(t *testing.T) {
+	set := mustHashSet(t, Options{SortedBuckets: true})
+	for i := 999; i >= 0; i-- {
+		set.Add([]byte(fmt.Sprintf("value%d", i)))
+	}
+	checkSortedBuckets(t, set)
+
+	for i := 0; i < 1000; i += 2 {
+		set.Remove([]byte(fmt.Sprintf("value%d", i)))
+	}
+	checkSortedBuckets(t, set)
+
+	// A rehash to a capacity that is not a doubling merges old buckets
+	if err := set.RehashToLoadFactor(0.3); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkSortedBuckets(t, set)
+
+	for i := 0; i < 1000; i++ {
+		if set.Contains([]byte(fmt.Sprintf("value%d", i))) != (i%2 == 1) {
+			t.Errorf("Unexpected membership for value%d", i)
+		}
+	}
+	if set.Size != 500 {
+		t.Errorf("Expected size to be 500, got %d", set.Size)
+	}
+}
+
+func TestHashSet_SortedBucketsBinarySearch(t *testing.T) {
+	// A single bucket that never resizes holds a long chain
+	set := mustHashSet(t, Options{Capacity: 1, BucketLimit: 1024, SortedBuckets: true})
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("value%d", i)))
+	}
+	if len(set.Buckets[0]) != 1000 {
+		t.Fatalf("Expected a single chain of 1000 elements, got %d", len(set.Buckets[0]))
+	}
+
+	// Binary search needs at most ceil(log2(1001)) = 10 probes
+	for i := 0; i < 1000; i++ {
+		if found, exhausted := set.ContainsWithin([]byte(fmt.Sprintf("value%d", i)), 10); !found || exhausted {
+			t.Errorf("Expected value%d to be found within 10 probes", i)
+		}
+	}
+	if found, exhausted := set.ContainsWithin([]byte("missing"), 10); found || exhausted {
+		t.Errorf("Expected missing to be ruled out within 10 probes")
+	}
+
+	values := make([][]byte, 0, len(set.Buckets[0]))
+	for _, item := range set.Buckets[0] {
+		values = append(values, item.([]byte))
+	}
+	if !slices.IsSortedFunc(values, bytes.Compare) {
+		t.Errorf("Expected the chain to be sorted")
+	}
+}
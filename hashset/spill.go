@@ -0,0 +1,257 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+const spillPartitions = 64           // partitions of a SpillingHashSet, the unit spilled to disk
+const spillSeed = 0x7f4a7c159e3779b9 // seed used to pick a partition, independent of the partition seeds
+
+// ErrSpillClosed is returned by a SpillingHashSet after Close.
+var ErrSpillClosed = errors.New("hashset: spilling set is closed")
+
+// SpillingHashSet is a grow-only hash set for key spaces larger than memory.
+// Its elements are split into partitions by hash. Once the resident partitions take more than the memory
+// budget, the least recently used ones are written to a backing file in the frozen format and dropped from memory.
+// Lookups of a spilled partition binary search its segments in the file, so they are much slower than
+// resident lookups, and each spill of a partition adds a segment the lookup reads.
+//
+// The backing file is scratch space, it is removed by Close and cannot be reopened.
+// A SpillingHashSet is not safe for concurrent use.
+type SpillingHashSet struct {
+	partitions [spillPartitions]spillPartition // Partitions of the elements
+	file       *os.File                        // Backing file of the spilled segments
+	path       string                          // Path of the backing file
+	end        int64                           // End of the written segments
+	budget     int                             // Memory budget of the resident elements in bytes
+	memory     int                             // Memory held by the resident elements in bytes
+	tick       uint64                          // Operation counter, the clock of the partition use
+	closed     bool                            // Whether Close was called
+}
+
+// spillPartition holds the elements of one partition of a SpillingHashSet.
+type spillPartition struct {
+	resident *HashSet      // Elements added since the partition was last spilled
+	segments []*FrozenFile // Spilled elements, in the backing file
+	used     uint64        // Tick of the last use
+}
+
+// NewSpillingHashSet creates a SpillingHashSet keeping about budget bytes of elements in memory,
+// spilling the rest to a backing file created at path. An existing file at path is truncated.
+func NewSpillingHashSet(path string, budget int) (*SpillingHashSet, error) {
+	if budget <= 0 {
+		return nil, wrapf(ErrInvalidOption, "hashset: spill budget must be positive, got %d", budget)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SpillingHashSet{file: file, path: path, budget: budget}
+	for i := range s.partitions {
+		s.partitions[i].resident = NewHashSet()
+		s.memory += s.partitions[i].resident.MemoryUsage()
+	}
+	return s, nil
+}
+
+// partition returns the partition of value, marking it used.
+func (s *SpillingHashSet) partition(value []byte) *spillPartition {
+	p := &s.partitions[hash64(value, spillSeed)%spillPartitions]
+	s.tick++
+	p.used = s.tick
+	return p
+}
+
+// Budget returns the memory budget of the resident elements in bytes.
+func (s *SpillingHashSet) Budget() int {
+	return s.budget
+}
+
+// MemoryUsage estimates the memory held by the resident elements in bytes.
+func (s *SpillingHashSet) MemoryUsage() int {
+	return s.memory
+}
+
+// Add inserts a new element into the set, reporting whether it was not already present.
+// It may spill partitions to the backing file to stay within the budget.
+func (s *SpillingHashSet) Add(value []byte) (bool, error) {
+	if s.closed {
+		return false, ErrSpillClosed
+	}
+
+	p := s.partition(value)
+	if found, err := p.contains(value); found || err != nil {
+		return false, err
+	}
+
+	before := p.resident.MemoryUsage()
+	if _, err := p.resident.Add(value); err != nil {
+		return false, err
+	}
+	s.memory += p.resident.MemoryUsage() - before
+
+	for s.memory > s.budget {
+		cold := s.coldest()
+		if cold == nil {
+			break
+		}
+		if err := s.spill(cold); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// Contains checks if an element is in the set, reading the backing file if its partition was spilled.
+func (s *SpillingHashSet) Contains(value []byte) (bool, error) {
+	if s.closed {
+		return false, ErrSpillClosed
+	}
+	return s.partition(value).contains(value)
+}
+
+// contains checks the resident elements and then the spilled segments of the partition.
+func (p *spillPartition) contains(value []byte) (bool, error) {
+	if p.resident.Contains(value) {
+		return true, nil
+	}
+
+	for _, segment := range p.segments {
+		found, err := segment.Contains(value)
+		if found || err != nil {
+			return found, err
+		}
+	}
+	return false, nil
+}
+
+// Len returns the number of elements in the set, resident or spilled.
+func (s *SpillingHashSet) Len() int {
+	size := 0
+	for i := range s.partitions {
+		p := &s.partitions[i]
+		size += p.resident.Size
+		for _, segment := range p.segments {
+			size += segment.Len()
+		}
+	}
+	return size
+}
+
+// Spilled returns the number of elements in the backing file.
+func (s *SpillingHashSet) Spilled() int {
+	size := 0
+	for i := range s.partitions {
+		for _, segment := range s.partitions[i].segments {
+			size += segment.Len()
+		}
+	}
+	return size
+}
+
+// coldest returns the least recently used partition holding resident elements, nil if there is none.
+func (s *SpillingHashSet) coldest() *spillPartition {
+	var cold *spillPartition
+	for i := range s.partitions {
+		p := &s.partitions[i]
+		if p.resident.Size > 0 && (cold == nil || p.used < cold.used) {
+			cold = p
+		}
+	}
+	return cold
+}
+
+// spill writes the resident elements of the partition to the backing file as a new segment and drops them from memory.
+func (s *SpillingHashSet) spill(p *spillPartition) error {
+	w := io.NewOffsetWriter(s.file, s.end)
+	if err := p.resident.WriteFrozen(w); err != nil {
+		return err
+	}
+
+	length, err := w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	segment, err := newFrozenFile(io.NewSectionReader(s.file, s.end, length), length)
+	if err != nil {
+		return err
+	}
+	s.end += length
+	p.segments = append(p.segments, segment)
+
+	s.memory -= p.resident.MemoryUsage()
+	p.resident = NewHashSet()
+	s.memory += p.resident.MemoryUsage()
+	return nil
+}
+
+// Flush spills every resident element to the backing file and syncs it to disk, releasing the memory of the elements.
+func (s *SpillingHashSet) Flush() error {
+	if s.closed {
+		return ErrSpillClosed
+	}
+
+	for i := range s.partitions {
+		if p := &s.partitions[i]; p.resident.Size > 0 {
+			if err := s.spill(p); err != nil {
+				return err
+			}
+		}
+	}
+	return s.file.Sync()
+}
+
+// Close closes and removes the backing file. The set cannot be used afterwards, its methods return ErrSpillClosed.
+// Close is idempotent, calls after the first return nil.
+func (s *SpillingHashSet) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	for i := range s.partitions {
+		s.partitions[i] = spillPartition{}
+	}
+	s.memory = 0
+
+	err := s.file.Close()
+	if removeErr := os.Remove(s.path); err == nil {
+		err = removeErr
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpillingHashSet_Spill(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill")
+	s, err := NewSpillingHashSet(path, 128<<10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10000; i++ {
+		added, err := s.Add([]byte(fmt.Sprintf("key%d", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !added {
+			t.Errorf("Expected key%d to be new", i)
+		}
+	}
+
+	// Spilled elements are still deduplicated
+	if added, err := s.Add([]byte("key0")); err != nil || added {
+		t.Errorf("Expected key0 to be present, got %v, %v", added, err)
+	}
+
+	if s.Len() != 10000 {
+		t.Errorf("Expected length 10000, got %d", s.Len())
+	}
+	if s.Spilled() == 0 {
+		t.Errorf("Expected elements to be spilled")
+	}
+	if s.MemoryUsage() > s.Budget() {
+		t.Errorf("Expected memory usage %d within the budget %d", s.MemoryUsage(), s.Budget())
+	}
+
+	for i := 0; i < 10000; i++ {
+		if found, err := s.Contains([]byte(fmt.Sprintf("key%d", i))); err != nil || !found {
+			t.Errorf("Expected key%d to be found, got %v, %v", i, found, err)
+		}
+	}
+	if found, err := s.Contains([]byte("missing")); err != nil || found {
+		t.Errorf("Expected missing to be absent, got %v, %v", found, err)
+	}
+
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Spilled() != 10000 {
+		t.Errorf("Expected every element to be spilled after Flush, got %d", s.Spilled())
+	}
+	if found, err := s.Contains([]byte("key42")); err != nil || !found {
+		t.Errorf("Expected key42 to be found after Flush, got %v, %v", found, err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Expected a second Close to return nil, got %v", err)
+	}
+	if _, err := s.Contains([]byte("key0")); !errors.Is(err, ErrSpillClosed) {
+		t.Errorf("Expected ErrSpillClosed, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the backing file to be removed, got %v", err)
+	}
+
+	if _, err := NewSpillingHashSet(path, 0); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption for a zero budget, got %v", err)
+	}
+}
@@ -0,0 +1,259 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// Split format, of every part
+//
+//	magic    [4]byte "K4SP"
+//	version  uint8
+//	hasher   uint8   hasher of the build, which placed the members
+//	seed     uint64  little endian
+//	part     uint32  little endian, position of the part from 0
+//	parts    uint32  little endian, number of parts
+//	capacity uint64  little endian capacity of the set
+//	size     uint64  little endian number of members across the parts
+//	count    uint32  little endian number of members in the part
+//	members  count x (uvarint length, bytes) in bucket order
+//	checksum uint32  little endian crc32 (IEEE) of everything before it
+const splitMagic = "K4SP"
+const splitVersion = 1
+const splitHeaderLen = len(splitMagic) + 2 + 8 + 4 + 4 + 8 + 8 + 4
+
+// SerializeSplit writes the set across as many parts of at most maxBytes bytes as its members need, for
+// files or segments with a size limit. Part i is written to the writer open returns for i, which is closed
+// once the part is written. Every part records its position and the number of parts, see DeserializeSplit.
+// Like MarshalBinary only the members, seed and capacity are recorded. It fails before writing anything if a
+// member does not fit in a part of maxBytes bytes.
+func (h *HashSet) SerializeSplit(maxBytes int, open func(part int) (io.WriteCloser, error)) error {
+	if err := h.checkIDOnlyHasher("split"); err != nil {
+		return err
+	}
+
+	budget := maxBytes - splitHeaderLen - binaryChecksumLen
+	if budget <= 0 {
+		return wrapf(ErrInvalidOption, "hashset: parts of %d bytes cannot hold the %d bytes of their header", maxBytes, splitHeaderLen+binaryChecksumLen)
+	}
+
+	// Plan the parts, counts[i] members go to part i
+	counts := []int{0}
+	used := 0
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			value := item.([]byte)
+			n := uvarintLen(uint64(len(value))) + len(value)
+			if n > budget {
+				return wrapf(ErrInvalidOption, "hashset: a member of %d bytes does not fit in parts of %d bytes", len(value), maxBytes)
+			}
+			if used+n > budget {
+				counts = append(counts, 0)
+				used = 0
+			}
+			counts[len(counts)-1]++
+			used += n
+		}
+	}
+
+	// Write the parts, the members follow in bucket order
+	buf := make([]byte, 0, min(maxBytes, h.SerializedSize()+splitHeaderLen))
+	part := 0
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			if len(buf) == 0 {
+				buf = h.appendSplitHeader(buf, part, len(counts), counts[part])
+			}
+			value := item.([]byte)
+			buf = binary.AppendUvarint(buf, uint64(len(value)))
+			buf = append(buf, value...)
+
+			if counts[part]--; counts[part] == 0 {
+				if err := writePart(open, part, buf); err != nil {
+					return err
+				}
+				buf = buf[:0]
+				part++
+			}
+		}
+	}
+
+	if part == 0 { // An empty set is one empty part
+		return writePart(open, 0, h.appendSplitHeader(buf, 0, 1, 0))
+	}
+	return nil
+}
+
+// appendSplitHeader appends the header of a part of the split format to buf.
+func (h *HashSet) appendSplitHeader(buf []byte, part, parts, count int) []byte {
+	buf = append(buf, splitMagic...)
+	buf = append(buf, splitVersion, h.Hasher)
+	buf = binary.LittleEndian.AppendUint64(buf, h.Seed)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(part))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(parts))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(h.Capacity))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(h.Size))
+	return binary.LittleEndian.AppendUint32(buf, uint32(count))
+}
+
+// writePart appends the checksum to the part in buf and writes it to the writer open returns for it.
+func writePart(open func(part int) (io.WriteCloser, error), part int, buf []byte) error {
+	w, err := open(part)
+	if err != nil {
+		return err
+	}
+
+	buf = binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+	if _, err := w.Write(buf); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// splitHeader is the header of a part of the split format.
+type splitHeader struct {
+	hasher   uint8
+	seed     uint64
+	part     int
+	parts    int
+	capacity uint64
+	size     uint64
+	count    int
+}
+
+// DeserializeSplit reassembles a set written by SerializeSplit, reading part i from the reader open returns
+// for i, which is closed once the part is read. Part 0 records how many parts follow. Parts out of place,
+// from another set or failing their checksum are rejected.
+func DeserializeSplit(open func(part int) (io.ReadCloser, error)) (*HashSet, error) {
+	var h *HashSet
+	var first splitHeader
+	for part := 0; part == 0 || part < first.parts; part++ {
+		data, err := readPart(open, part)
+		if err != nil {
+			return nil, err
+		}
+
+		header, members, err := parseSplitPart(data)
+		if err != nil {
+			return nil, err
+		}
+		if part == 0 {
+			first = header
+			hasher, err := lookupHasher(header.hasher)
+			if err != nil {
+				return nil, err
+			}
+			h = newHashSet(decodedCapacity(int(header.capacity), header.count), header.seed) // Bounded by a part actually read
+			h.Hasher, h.hasher = header.hasher, hasher
+		}
+		if header.part != part || header.parts != first.parts || header.seed != first.seed || header.hasher != first.hasher || header.size != first.size {
+			return nil, wrapf(ErrCorrupt, "corrupt split hashset: part %d of %d does not belong at position %d of %d", header.part, header.parts, part, first.parts)
+		}
+
+		for i := 0; i < header.count; i++ {
+			var n uint64
+			if n, members, err = readUvarint(members); err != nil {
+				return nil, err
+			}
+			if n > uint64(len(members)) {
+				return nil, wrapf(ErrCorrupt, "corrupt split hashset: member length %d exceeds part %d", n, part)
+			}
+			h.Add(members[:n:n])
+			members = members[n:]
+		}
+		if len(members) != 0 {
+			return nil, wrapf(ErrCorrupt, "corrupt split hashset: %d trailing bytes in part %d", len(members), part)
+		}
+	}
+
+	if uint64(h.Size) != first.size {
+		return nil, wrapf(ErrCorrupt, "corrupt split hashset: size %d does not match %d members", first.size, h.Size)
+	}
+	return h, nil
+}
+
+// readPart reads part from the reader open returns for it.
+func readPart(open func(part int) (io.ReadCloser, error), part int) ([]byte, error) {
+	r, err := open(part)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// parseSplitPart checks a part of the split format and returns its header and encoded members.
+func parseSplitPart(data []byte) (splitHeader, []byte, error) {
+	if len(data) < splitHeaderLen+binaryChecksumLen {
+		return splitHeader{}, nil, wrapf(ErrCorrupt, "corrupt split hashset: part too short")
+	}
+	if !bytes.Equal(data[:len(splitMagic)], []byte(splitMagic)) {
+		return splitHeader{}, nil, wrapf(ErrCorrupt, "corrupt split hashset: invalid magic")
+	}
+	if version := data[len(splitMagic)]; version != splitVersion {
+		return splitHeader{}, nil, wrapf(ErrUnsupportedVersion, "unsupported split hashset version %d", version)
+	}
+
+	// Verify the checksum before trusting any of the content
+	body := data[:len(data)-binaryChecksumLen]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(body):]) {
+		return splitHeader{}, nil, wrapf(ErrChecksumMismatch, "corrupt split hashset: checksum mismatch")
+	}
+
+	fields := body[len(splitMagic)+2:]
+	header := splitHeader{
+		hasher:   body[len(splitMagic)+1],
+		seed:     binary.LittleEndian.Uint64(fields),
+		part:     int(binary.LittleEndian.Uint32(fields[8:])),
+		parts:    int(binary.LittleEndian.Uint32(fields[12:])),
+		capacity: binary.LittleEndian.Uint64(fields[16:]),
+		size:     binary.LittleEndian.Uint64(fields[24:]),
+		count:    int(binary.LittleEndian.Uint32(fields[32:])),
+	}
+
+	if header.capacity == 0 || header.capacity > maxCapacity || header.capacity&(header.capacity-1) != 0 {
+		return splitHeader{}, nil, wrapf(ErrCorrupt, "corrupt split hashset: invalid capacity %d", header.capacity)
+	}
+	if header.parts == 0 || header.part >= header.parts {
+		return splitHeader{}, nil, wrapf(ErrCorrupt, "corrupt split hashset: part %d of %d", header.part, header.parts)
+	}
+
+	// Every member takes at least its length prefix
+	if header.count > len(data) {
+		return splitHeader{}, nil, wrapf(ErrCorrupt, "corrupt split hashset: %d members exceed the part", header.count)
+	}
+	return header, body[splitHeaderLen:], nil
+}
@@ -0,0 +1,131 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// bufferCloser is a bytes.Buffer with a no-op Close.
+type bufferCloser struct {
+	*bytes.Buffer
+}
+
+func (bufferCloser) Close() error { return nil }
+
+// splitParts serializes set in parts of at most maxBytes bytes held in memory.
+func splitParts(t *testing.T, set *HashSet, maxBytes int) [][]byte {
+	t.Helper()
+	var parts []*bytes.Buffer
+	err := set.SerializeSplit(maxBytes, func(part int) (io.WriteCloser, error) {
+		if part != len(parts) {
+			t.Fatalf("Expected part %d, got %d", len(parts), part)
+		}
+		parts = append(parts, &bytes.Buffer{})
+		return bufferCloser{parts[part]}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([][]byte, len(parts))
+	for i, part := range parts {
+		data[i] = part.Bytes()
+	}
+	return data
+}
+
+// openParts opens the parts returned by splitParts.
+func openParts(parts [][]byte) func(part int) (io.ReadCloser, error) {
+	return func(part int) (io.ReadCloser, error) {
+		if part >= len(parts) {
+			return nil, fmt.Errorf("no part %d", part)
+		}
+		return io.NopCloser(bytes.NewReader(parts[part])), nil
+	}
+}
+
+func TestHashSet_SerializeSplit(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	parts := splitParts(t, set, 1024)
+	if len(parts) < 8 {
+		t.Errorf("Expected the set to take several parts, got %d", len(parts))
+	}
+	for i, part := range parts {
+		if len(part) > 1024 {
+			t.Errorf("Expected part %d to hold at most 1024 bytes, got %d", i, len(part))
+		}
+	}
+
+	decoded, err := DeserializeSplit(openParts(parts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Size != 1000 || decoded.Capacity != set.Capacity || decoded.Seed != set.Seed {
+		t.Errorf("Expected the decoded set to match, got %d members in %d buckets", decoded.Size, decoded.Capacity)
+	}
+	for i := 0; i < 1000; i++ {
+		if !decoded.Contains([]byte(fmt.Sprintf("test%d", i))) {
+			t.Errorf("Expected test%d to be decoded", i)
+		}
+	}
+
+	// Missing, swapped and corrupt parts are rejected
+	if _, err := DeserializeSplit(openParts(parts[:len(parts)-1])); err == nil {
+		t.Errorf("Expected an error for a missing part")
+	}
+	swapped := append([][]byte{parts[1], parts[0]}, parts[2:]...)
+	if _, err := DeserializeSplit(openParts(swapped)); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt for swapped parts, got %v", err)
+	}
+	parts[2][splitHeaderLen] ^= 1
+	if _, err := DeserializeSplit(openParts(parts)); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch, got %v", err)
+	}
+
+	empty := splitParts(t, NewHashSet(), 64)
+	if decoded, err := DeserializeSplit(openParts(empty)); err != nil || len(empty) != 1 || decoded.Size != 0 {
+		t.Errorf("Expected an empty set in one part, got %d parts, %v", len(empty), err)
+	}
+
+	set.Add(make([]byte, 2048))
+	if err := set.SerializeSplit(1024, nil); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption for a member larger than a part, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"math"
+)
+
+// Stats is a snapshot of the shape of a set, see HashSet.Stats.
+type Stats struct {
+	Name          string   // Name of the set, see the Name option
+	Size          int      // Number of elements
+	Capacity      int      // Number of buckets
+	LoadFactor    float64  // Elements per bucket
+	LongestBucket int      // Elements in the longest bucket
+	MemoryUsage   int      // Estimated memory in bytes, see MemoryUsage
+	Generation    uint64   // Mutation counter, see Generation
+	Strategy      Strategy // Collision resolution strategy
+}
+
+// Name returns the name the set was created with, see the Name option.
+func (h *HashSet) Name() string {
+	return h.opts.Name
+}
+
+// Stats returns a snapshot of the shape of the set. Finding the longest bucket is O(capacity).
+func (h *HashSet) Stats() Stats {
+	longest := 0
+	for _, bucket := range h.Buckets {
+		longest = max(longest, len(bucket))
+	}
+
+	return Stats{
+		Name:          h.opts.Name,
+		Size:          h.Size,
+		Capacity:      h.Capacity,
+		LoadFactor:    float64(h.Size) / float64(h.Capacity),
+		LongestBucket: longest,
+		MemoryUsage:   h.MemoryUsage(),
+		Generation:    h.generation,
+		Strategy:      h.Strategy,
+	}
+}
+
+// DistributionScore returns the normalized entropy of the bucket occupancy, in [0,1].
+// It is 1 when the elements are spread as evenly as the capacity allows and falls towards 0 as they crowd into
+// fewer buckets, a score dropping over time points to a hash or seed that suits the keys badly.
+// A good hash scores somewhat below 1 as random placement collides, the more so the higher the load factor.
+// It is O(capacity) and read-only, sets with fewer than two elements score 1.
+func (h *HashSet) DistributionScore() float64 {
+	if h.Size < 2 || h.Capacity < 2 {
+		return 1
+	}
+	n := float64(h.Size)
+	spread := math.Log(min(n, float64(h.Capacity)))
+
+	// H = log n - sum(c log c) / n over the bucket counts c
+	sum := 0.0
+	for _, chain := range h.chains() {
+		if c := float64(len(chain)); c > 1 {
+			sum += c * math.Log(c)
+		}
+	}
+	return max(0, min(1, (math.Log(n)-sum/n)/spread))
+}
+
+// String describes the set by its name, size and capacity, without listing the elements.
+func (h *HashSet) String() string {
+	if h.opts.Name == "" {
+		return fmt.Sprintf("hashset: %d elements in %d buckets", h.Size, h.Capacity)
+	}
+	return fmt.Sprintf("hashset %s: %d elements in %d buckets", h.opts.Name, h.Size, h.Capacity)
+}
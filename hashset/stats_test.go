@@ -0,0 +1,104 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHashSet_Name(t *testing.T) {
+	set := mustHashSet(t, Options{Name: "users", Profiling: true})
+	set.Add([]byte("a"))
+	set.Add([]byte("b"))
+
+	if set.Name() != "users" {
+		t.Errorf("Expected name to be users, got %s", set.Name())
+	}
+	if got := set.String(); got != "hashset users: 2 elements in 32 buckets" {
+		t.Errorf("Unexpected string %q", got)
+	}
+	if got := NewHashSet().String(); got != "hashset: 0 elements in 32 buckets" {
+		t.Errorf("Unexpected string %q", got)
+	}
+	if set.LatencyStats().Name != "users" {
+		t.Errorf("Expected the latency stats to carry the name")
+	}
+
+	stats := set.Stats()
+	if stats.Name != "users" || stats.Size != 2 || stats.Capacity != 32 || stats.LongestBucket < 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	// The name is serialized with the other options
+	data, err := set.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Deserialize(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Name() != "users" || decoded.Size != 2 {
+		t.Errorf("Expected a decoded set of 2 elements named users")
+	}
+}
+
+func TestHashSet_DistributionScore(t *testing.T) {
+	if score := NewHashSet().DistributionScore(); score != 1 {
+		t.Errorf("Expected an empty set to score 1, got %f", score)
+	}
+
+	set := NewHashSet()
+	skewed := mustHashSet(t, Options{Hasher: moduloHasher{}})
+	for i := 0; i < 1000; i++ {
+		value := []byte(fmt.Sprintf("test%d", i))
+		set.Add(value)
+		skewed.Add(value)
+	}
+
+	score := set.DistributionScore()
+	if score < 0.8 || score > 1 {
+		t.Errorf("Expected a uniform set to score close to 1, got %f", score)
+	}
+	if skewedScore := skewed.DistributionScore(); skewedScore >= score/2 {
+		t.Errorf("Expected a skewed set to score well below %f, got %f", score, skewedScore)
+	}
+}
+
+// moduloHasher is a poor hasher for tests, placing every element in one of 16 buckets.
+type moduloHasher struct{}
+
+func (moduloHasher) ID() uint8 { return 201 }
+
+func (moduloHasher) Hash64(data []byte, seed uint64) uint64 {
+	return FNVHasher.Hash64(data, seed) % 16
+}
@@ -0,0 +1,155 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Strategy is the way a set resolves elements hashing to the same bucket, see the Strategy option.
+// Every strategy has the same API, Serialize records the strategy so the decoded set finds its elements.
+// The member formats, such as MarshalBinary, re-add the elements and take the strategy of the decoding set.
+type Strategy uint8
+
+const (
+	// SeparateChaining appends the elements of a bucket to its chain and scans it on lookups.
+	SeparateChaining Strategy = iota
+
+	// SortedChaining keeps every chain ordered and binary searches it, as the SortedBuckets option.
+	SortedChaining
+
+	// OpenAddressing stores at most one element per bucket, an element whose bucket is taken goes to the
+	// next free one. Lookups probe the buckets in turn instead of following a chain, and removal shifts
+	// the following elements back so no probe sequence is broken. It excludes the BucketLimit and
+	// SecondaryHashing options, and a set at the maximum capacity refuses elements once every bucket is taken.
+	OpenAddressing
+)
+
+// String returns the name of the strategy.
+func (s Strategy) String() string {
+	switch s {
+	case SeparateChaining:
+		return "separate chaining"
+	case SortedChaining:
+		return "sorted chaining"
+	case OpenAddressing:
+		return "open addressing"
+	default:
+		return fmt.Sprintf("Strategy(%d)", uint8(s))
+	}
+}
+
+// locate returns the bucket holding value and its position within it, or the bucket value is added to and -1.
+func (h *HashSet) locate(digest uint64, value []byte) (index int, i int) {
+	index = h.home(digest)
+	if h.opts.Strategy == OpenAddressing {
+		index, i, _ = h.probeWithin(index, value, -1)
+		return index, i
+	}
+	return index, h.find(index, digest, value)
+}
+
+// probeWithin is findWithin for open addressing, probing at most maxProbes buckets from index,
+// or every bucket up to the first free one if maxProbes is negative.
+// It returns the bucket holding value and 0, or the free bucket ending the probe and -1.
+func (h *HashSet) probeWithin(index int, value []byte, maxProbes int) (int, int, bool) {
+	for probes := 0; probes < h.Capacity; probes++ {
+		if probes == maxProbes {
+			return index, -1, true
+		}
+
+		bucket := h.Buckets[index]
+		if len(bucket) == 0 {
+			return index, -1, false // A free bucket ends every probe sequence
+		}
+		if bytes.Equal(bucket[0].([]byte), value) {
+			return index, 0, false
+		}
+		index = (index + 1) % h.Capacity
+	}
+	return index, -1, false
+}
+
+// vacant returns the first free bucket probing from the bucket at index under open addressing, or index itself.
+func (h *HashSet) vacant(index int) int {
+	if h.opts.Strategy == OpenAddressing {
+		for len(h.Buckets[index]) != 0 {
+			index = (index + 1) % h.Capacity
+		}
+	}
+	return index
+}
+
+// backshift closes the gap left by removing the element of the bucket at index under open addressing.
+// Every following element that probed past the gap moves back into it, leaving the gap where it came from.
+func (h *HashSet) backshift(index int) {
+	if h.opts.Strategy != OpenAddressing {
+		return
+	}
+
+	for next := (index + 1) % h.Capacity; len(h.Buckets[next]) != 0; next = (next + 1) % h.Capacity {
+		home := h.hash(h.Buckets[next][0].([]byte), h.Capacity)
+
+		// The element stays if its home lies cyclically in (index, next]
+		if (next-home+h.Capacity)%h.Capacity < (next-index+h.Capacity)%h.Capacity {
+			continue
+		}
+		h.Buckets[index], h.Buckets[next] = h.Buckets[next], h.Buckets[index]
+		if h.shared != nil {
+			h.shared[index], h.shared[next] = h.shared[next], h.shared[index] // Sharing moves with the bucket
+		}
+		index = next
+	}
+}
+
+// full reports whether an open addressing set has no free bucket left for a new element.
+func (h *HashSet) full() bool {
+	return h.opts.Strategy == OpenAddressing && h.Size >= h.Capacity
+}
+
+// chains returns the elements grouped by the bucket their hash places them in.
+// It is the bucket array itself unless elements moved to other buckets under open addressing
+// or are waiting for an incremental resize to split their bucket.
+func (h *HashSet) chains() [][]interface{} {
+	if h.opts.Strategy != OpenAddressing {
+		return h.settledBuckets()
+	}
+
+	chains := make([][]interface{}, h.Capacity)
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			index := h.hash(item.([]byte), h.Capacity)
+			chains[index] = append(chains[index], item)
+		}
+	}
+	return chains
+}
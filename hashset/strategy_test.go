@@ -0,0 +1,173 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+var strategies = []Strategy{SeparateChaining, SortedChaining, OpenAddressing}
+
+func TestHashSet_Strategies(t *testing.T) {
+	for _, strategy := range strategies {
+		t.Run(strategy.String(), func(t *testing.T) {
+			set := mustHashSet(t, Options{Strategy: strategy})
+			for i := 0; i < 1000; i++ {
+				set.Add([]byte(fmt.Sprintf("value%d", i)))
+			}
+
+			// Remove every third element, leaving gaps in the probe sequences
+			for i := 0; i < 1000; i += 3 {
+				set.Remove([]byte(fmt.Sprintf("value%d", i)))
+			}
+			set.IterRemove(func(value []byte) bool {
+				return len(value) == len("value1")
+			})
+
+			for i := 0; i < 1000; i++ {
+				want := i%3 != 0 && i >= 10
+				if set.Contains([]byte(fmt.Sprintf("value%d", i))) != want {
+					t.Errorf("Expected membership of value%d to be %v", i, want)
+				}
+			}
+			if set.Size != 660 {
+				t.Errorf("Expected size to be 660, got %d", set.Size)
+			}
+			if err := set.SelfTest(); err != nil {
+				t.Errorf("Unexpected self-test error: %v", err)
+			}
+
+			data, err := set.Serialize()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			decoded, err := Deserialize(data)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if decoded.Strategy != strategy {
+				t.Errorf("Expected the decoded strategy to be %v, got %v", strategy, decoded.Strategy)
+			}
+			set.ForEach(func(value []byte) bool {
+				if !decoded.Contains(value) {
+					t.Errorf("Expected the decoded set to contain %s", value)
+				}
+				return true
+			})
+
+			var buf bytes.Buffer
+			if err := set.SerializeChecked(&buf); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			checked, failed, err := DeserializeChecked(&buf)
+			if err != nil || len(failed) != 0 || checked.Size != set.Size {
+				t.Errorf("Expected the checked format to recover every member, got %v, %v", failed, err)
+			}
+
+			decoded.Add([]byte("value0"))
+			decoded.Remove([]byte("value2"))
+			if !decoded.Contains([]byte("value0")) || decoded.Contains([]byte("value2")) {
+				t.Errorf("Expected the decoded set to stay usable")
+			}
+		})
+	}
+}
+
+func TestHashSet_OpenAddressingProbing(t *testing.T) {
+	set := mustHashSet(t, Options{Capacity: 1024, Strategy: OpenAddressing})
+	for i := 0; i < 700; i++ {
+		set.Add([]byte(fmt.Sprintf("value%d", i)))
+	}
+	if set.Capacity != 1024 {
+		t.Fatalf("Expected no resize, got capacity %d", set.Capacity)
+	}
+
+	for i, bucket := range set.Buckets {
+		if len(bucket) > 1 {
+			t.Fatalf("Expected at most one element in bucket %d, got %d", i, len(bucket))
+		}
+	}
+
+	// Every element is reachable from its home bucket after removals shift others back
+	for i := 0; i < 700; i += 2 {
+		set.Remove([]byte(fmt.Sprintf("value%d", i)))
+	}
+	for i := 1; i < 700; i += 2 {
+		value := []byte(fmt.Sprintf("value%d", i))
+		if found, exhausted := set.ContainsWithin(value, set.Capacity); !found || exhausted {
+			t.Errorf("Expected value%d to be found by probing", i)
+		}
+	}
+
+	if found, exhausted := set.ContainsWithin([]byte("missing"), 0); found || !exhausted {
+		t.Errorf("Expected an exhausted probe budget")
+	}
+}
+
+func TestHashSet_StrategyOptions(t *testing.T) {
+	set := mustHashSet(t, Options{SortedBuckets: true})
+	if set.Strategy != SortedChaining {
+		t.Errorf("Expected SortedBuckets to select %v, got %v", SortedChaining, set.Strategy)
+	}
+
+	for _, opts := range []Options{
+		{Strategy: OpenAddressing, BucketLimit: 2},
+		{Strategy: OpenAddressing, SecondaryHashing: true},
+		{Strategy: SortedChaining, SecondaryHashing: true},
+		{Strategy: OpenAddressing + 1},
+	} {
+		if _, err := NewHashSetWithOptions(opts); err == nil {
+			t.Errorf("Expected an error for %+v", opts)
+		}
+	}
+}
+
+func BenchmarkHashSet_Strategy(b *testing.B) {
+	values := make([][]byte, 10000)
+	for i := range values {
+		values[i] = []byte(fmt.Sprintf("value%d", i))
+	}
+
+	for _, strategy := range strategies {
+		b.Run(strategy.String(), func(b *testing.B) {
+			set := mustHashSet(b, Options{Strategy: strategy})
+			for _, value := range values {
+				set.Add(value)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				set.Contains(values[i%len(values)])
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// DumpText writes every element of the set to w as one base64 encoded line.
+// The output can be grepped, diffed and edited by hand, then loaded with LoadText.
+func (h *HashSet) DumpText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	for _, bucket := range h.Buckets {
+		for _, item := range bucket {
+			if _, err := bw.WriteString(base64.StdEncoding.EncodeToString(item.([]byte))); err != nil {
+				return err
+			}
+			if err := bw.WriteByte('\n'); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// LoadText reads a set written by DumpText, one base64 encoded element per line.
+// Every line is an element, an empty line is the empty element.
+func LoadText(r io.Reader) (*HashSet, error) {
+	h := NewHashSet()
+	br := bufio.NewReader(r)
+
+	for lineNum := 1; ; lineNum++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		if len(line) == 0 && err == io.EOF {
+			break // No trailing line
+		}
+
+		line = bytes.TrimSuffix(line, []byte("\n"))
+		line = bytes.TrimSuffix(line, []byte("\r"))
+
+		value := make([]byte, base64.StdEncoding.DecodedLen(len(line)))
+		n, decodeErr := base64.StdEncoding.Decode(value, line)
+		if decodeErr != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, decodeErr)
+		}
+		h.Add(value[:n])
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	return h, nil
+}
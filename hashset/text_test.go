@@ -0,0 +1,91 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHashSet_DumpLoadText(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("test\n%d", i))) // Newlines must survive the line format
+	}
+	set.Add([]byte{})
+
+	var buf bytes.Buffer
+	if err := set.DumpText(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := strings.Count(buf.String(), "\n"); lines != set.Size {
+		t.Errorf("Expected %d lines, got %d", set.Size, lines)
+	}
+
+	loaded, err := LoadText(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Size != set.Size {
+		t.Errorf("Expected loaded size to be %d, got %d", set.Size, loaded.Size)
+	}
+
+	for i := 0; i < 100; i++ {
+		value := []byte(fmt.Sprintf("test\n%d", i))
+		if !loaded.Contains(value) {
+			t.Errorf("Expected loaded set to contain %v", value)
+		}
+	}
+
+	if !loaded.Contains([]byte{}) {
+		t.Errorf("Expected loaded set to contain the empty value")
+	}
+}
+
+func TestLoadText_HandEdited(t *testing.T) {
+	// Windows line endings and a missing trailing newline
+	loaded, err := LoadText(strings.NewReader("dGVzdDE=\r\ndGVzdDI="))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !loaded.Contains([]byte("test1")) || !loaded.Contains([]byte("test2")) {
+		t.Errorf("Expected loaded set to contain test1 and test2")
+	}
+
+	if _, err := LoadText(strings.NewReader("not base64!\n")); err == nil {
+		t.Errorf("Expected error for invalid base64")
+	}
+}
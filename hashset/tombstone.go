@@ -0,0 +1,104 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+)
+
+// tombstoneSet records the keys deleted by Remove, for the Tombstones option.
+// A nil tombstoneSet records nothing.
+type tombstoneSet struct {
+	keys map[string]struct{} // Deleted keys in stored form
+}
+
+// newTombstoneSet creates an empty tombstoneSet.
+func newTombstoneSet() *tombstoneSet {
+	return &tombstoneSet{
+		keys: make(map[string]struct{}),
+	}
+}
+
+// clone returns an independent copy of the tombstones.
+func (t *tombstoneSet) clone() *tombstoneSet {
+	if t == nil {
+		return nil
+	}
+	return &tombstoneSet{keys: maps.Clone(t.keys)}
+}
+
+// add records a tombstone for value.
+func (t *tombstoneSet) add(value []byte) {
+	if t == nil {
+		return
+	}
+	t.keys[string(value)] = struct{}{}
+}
+
+// remove drops the tombstone of value, it is called when value is added again.
+func (t *tombstoneSet) remove(value []byte) {
+	if t == nil {
+		return
+	}
+	delete(t.keys, string(value))
+}
+
+// clear drops every tombstone.
+func (t *tombstoneSet) clear() {
+	if t == nil {
+		return
+	}
+	clear(t.keys)
+}
+
+// Tombstones returns the keys deleted by Remove since the last PurgeTombstones, ordered by bytes.Compare.
+// Keys are in stored form, see the FingerprintThreshold option. A key added again loses its tombstone.
+// It returns nil if the set was not created with the Tombstones option.
+func (h *HashSet) Tombstones() [][]byte {
+	if h.deleted == nil {
+		return nil
+	}
+
+	keys := make([][]byte, 0, len(h.deleted.keys))
+	for key := range h.deleted.keys {
+		keys = append(keys, []byte(key))
+	}
+	slices.SortFunc(keys, bytes.Compare)
+	return keys
+}
+
+// PurgeTombstones drops every tombstone, once a compaction has merged them into the lower levels.
+func (h *HashSet) PurgeTombstones() {
+	h.checkMutable()
+	h.deleted.clear()
+}
@@ -0,0 +1,77 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHashSet_Tombstones(t *testing.T) {
+	set := mustHashSet(t, Options{Tombstones: true})
+	set.Add([]byte("a"))
+	set.Add([]byte("b"))
+
+	set.Remove([]byte("b"))
+	set.Remove([]byte("elsewhere")) // Deleted in another level
+	if set.Contains([]byte("b")) {
+		t.Errorf("Expected a tombstoned key to be absent")
+	}
+
+	want := [][]byte{[]byte("b"), []byte("elsewhere")}
+	if got := set.Tombstones(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected tombstones %q, got %q", want, got)
+	}
+
+	// Adding a key again supersedes its delete
+	set.Add([]byte("b"))
+	want = [][]byte{[]byte("elsewhere")}
+	if got := set.Tombstones(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected tombstones %q after re-adding b, got %q", want, got)
+	}
+
+	set.PurgeTombstones()
+	if got := set.Tombstones(); len(got) != 0 {
+		t.Errorf("Expected no tombstones after a purge, got %q", got)
+	}
+	if !set.Contains([]byte("a")) || !set.Contains([]byte("b")) {
+		t.Errorf("Expected purging tombstones to keep the members")
+	}
+}
+
+func TestHashSet_TombstonesDisabled(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("a"))
+	set.Remove([]byte("a"))
+	if set.Tombstones() != nil {
+		t.Errorf("Expected no tombstones without the Tombstones option")
+	}
+}
@@ -0,0 +1,122 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"maps"
+	"time"
+)
+
+// insertTimes records when each element was added or last refreshed, for the TTL option.
+// A nil insertTimes records nothing and nothing expires.
+type insertTimes struct {
+	ttl   time.Duration        // Lifetime of an element
+	added map[string]time.Time // Time each element was added or refreshed
+	now   func() time.Time     // Clock, replaced in tests
+}
+
+// newInsertTimes creates an empty insertTimes for elements living ttl.
+func newInsertTimes(ttl time.Duration) *insertTimes {
+	return &insertTimes{
+		ttl:   ttl,
+		added: make(map[string]time.Time),
+		now:   time.Now,
+	}
+}
+
+// clone returns an independent copy of the insert times sharing the clock.
+func (t *insertTimes) clone() *insertTimes {
+	if t == nil {
+		return nil
+	}
+	return &insertTimes{ttl: t.ttl, added: maps.Clone(t.added), now: t.now}
+}
+
+// touch records value as added now.
+func (t *insertTimes) touch(value []byte) {
+	if t == nil {
+		return
+	}
+	t.added[string(value)] = t.now()
+}
+
+// age returns how long ago value was added or refreshed.
+func (t *insertTimes) age(value []byte) time.Duration {
+	if t == nil {
+		return 0
+	}
+	return t.now().Sub(t.added[string(value)])
+}
+
+// expired checks if value outlived the TTL.
+func (t *insertTimes) expired(value []byte) bool {
+	return t != nil && t.age(value) >= t.ttl
+}
+
+// remove forgets value.
+func (t *insertTimes) remove(value []byte) {
+	if t == nil {
+		return
+	}
+	delete(t.added, string(value))
+}
+
+// clear forgets every element.
+func (t *insertTimes) clear() {
+	if t == nil {
+		return
+	}
+	clear(t.added)
+}
+
+// ContainsAge checks if an element is in the set and returns how long ago it was added or last refreshed
+// by adding it again. Elements older than the TTL option are reported as absent.
+// Without the TTL option the age is always 0.
+func (h *HashSet) ContainsAge(value []byte) (bool, time.Duration) {
+	value = h.key(value) // Compute the stored form
+	if !h.has(value) || h.times.expired(value) {
+		return false, 0
+	}
+	h.access.hit(value)
+	return true, h.times.age(value)
+}
+
+// Expire removes the elements older than the TTL option and returns how many were removed.
+// Expired elements are reported absent by Contains but keep their memory until Expire removes them.
+func (h *HashSet) Expire() int {
+	if h.times == nil {
+		return 0
+	}
+
+	before := h.Size
+	h.IterRemove(h.times.expired)
+	return before - h.Size
+}
@@ -0,0 +1,99 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock returns a set with the TTL option and a clock advanced by hand.
+func fakeClock(ttl time.Duration) (*HashSet, *time.Time) {
+	now := time.Unix(0, 0)
+	set := newHashSetWithOptions(Options{TTL: ttl})
+	set.times.now = func() time.Time { return now }
+	return set, &now
+}
+
+func TestHashSet_ContainsAge(t *testing.T) {
+	set, now := fakeClock(time.Minute)
+	set.Add([]byte("test"))
+
+	*now = now.Add(10 * time.Second)
+	if found, age := set.ContainsAge([]byte("test")); !found || age != 10*time.Second {
+		t.Errorf("Expected test to be found aged 10s, got %v %v", found, age)
+	}
+
+	// Adding again refreshes the element
+	set.Add([]byte("test"))
+	*now = now.Add(5 * time.Second)
+	if _, age := set.ContainsAge([]byte("test")); age != 5*time.Second {
+		t.Errorf("Expected the refreshed age to be 5s, got %v", age)
+	}
+
+	if found, _ := set.ContainsAge([]byte("missing")); found {
+		t.Errorf("Expected missing to not be found")
+	}
+}
+
+func TestHashSet_TTLExpire(t *testing.T) {
+	set, now := fakeClock(time.Minute)
+	set.Add([]byte("old"))
+
+	*now = now.Add(30 * time.Second)
+	set.Add([]byte("new"))
+
+	*now = now.Add(45 * time.Second)
+	if set.Contains([]byte("old")) {
+		t.Errorf("Expected old to be expired")
+	}
+
+	if !set.Contains([]byte("new")) {
+		t.Errorf("Expected new to be live")
+	}
+
+	if removed := set.Expire(); removed != 1 || set.Size != 1 {
+		t.Errorf("Expected Expire to remove 1 element, got %d with %d left", removed, set.Size)
+	}
+}
+
+func TestHashSet_ContainsAgeWithoutTTL(t *testing.T) {
+	set := NewHashSet()
+	set.Add([]byte("test"))
+
+	if found, age := set.ContainsAge([]byte("test")); !found || age != 0 {
+		t.Errorf("Expected test to be found with age 0, got %v %v", found, age)
+	}
+
+	if set.Expire() != 0 {
+		t.Errorf("Expected nothing to expire without TTL")
+	}
+}
@@ -0,0 +1,79 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"encoding/binary"
+	"sync"
+)
+
+// uint64Buffers holds the scratch buffers the integer methods encode into, so lookups do not allocate.
+// Buffers are only hashed and compared, never stored.
+var uint64Buffers = sync.Pool{
+	New: func() interface{} { return new([8]byte) },
+}
+
+// AddUint64 inserts the 8 byte big endian encoding of v, the element Add would insert for it.
+// Values already present are found without allocating, only a new element allocates its 8 bytes.
+func (h *HashSet) AddUint64(v uint64) (bool, error) {
+	h.checkMutable()
+
+	buf := uint64Buffers.Get().(*[8]byte)
+	binary.BigEndian.PutUint64(buf[:], v)
+	present := h.counts == nil && h.times == nil && h.has(buf[:])
+	uint64Buffers.Put(buf)
+	if present {
+		return false, nil // Nothing to count or refresh
+	}
+	return h.Add(binary.BigEndian.AppendUint64(make([]byte, 0, 8), v))
+}
+
+// ContainsUint64 checks if the 8 byte big endian encoding of v is in the set without allocating.
+func (h *HashSet) ContainsUint64(v uint64) bool {
+	buf := uint64Buffers.Get().(*[8]byte)
+	defer uint64Buffers.Put(buf)
+
+	binary.BigEndian.PutUint64(buf[:], v)
+	return h.Contains(buf[:])
+}
+
+// RemoveUint64 deletes the 8 byte big endian encoding of v from the set without allocating.
+func (h *HashSet) RemoveUint64(v uint64) {
+	h.checkMutable()
+
+	buf := uint64Buffers.Get().(*[8]byte)
+	defer uint64Buffers.Put(buf)
+
+	binary.BigEndian.PutUint64(buf[:], v)
+	if index, i := h.locate(h.digest(buf[:]), buf[:]); i >= 0 {
+		h.Remove(h.Buckets[index][i].([]byte)) // Removed by its stored instance, the buffer is not retained
+	}
+}
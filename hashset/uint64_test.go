@@ -0,0 +1,86 @@
+// Package hashset tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestHashSet_Uint64(t *testing.T) {
+	set := NewHashSet()
+	for v := uint64(0); v < 1000; v++ {
+		if ok, err := set.AddUint64(v * 7919); !ok || err != nil {
+			t.Fatalf("Expected %d to be added, got %v, %v", v*7919, ok, err)
+		}
+	}
+	if ok, _ := set.AddUint64(7919); ok {
+		t.Errorf("Expected a present value not to be added again")
+	}
+
+	// The integer methods are interchangeable with the byte methods on the big endian encoding
+	if !set.Contains(binary.BigEndian.AppendUint64(nil, 7919)) {
+		t.Errorf("Expected Contains to find the encoding of an integer added by AddUint64")
+	}
+	set.Add(binary.BigEndian.AppendUint64(nil, 42))
+	if !set.ContainsUint64(42) {
+		t.Errorf("Expected ContainsUint64 to find an encoding added by Add")
+	}
+
+	set.RemoveUint64(42)
+	set.RemoveUint64(7919)
+	if set.ContainsUint64(42) || set.ContainsUint64(7919) {
+		t.Errorf("Expected removed integers not to be found")
+	}
+	if !set.ContainsUint64(2 * 7919) {
+		t.Errorf("Expected other integers to remain")
+	}
+	if set.Size != 999 {
+		t.Errorf("Expected size to be 999, got %d", set.Size)
+	}
+}
+
+func TestHashSet_Uint64NoAlloc(t *testing.T) {
+	set := NewHashSet()
+	for v := uint64(0); v < 100; v++ {
+		set.AddUint64(v)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		set.ContainsUint64(50)
+		set.ContainsUint64(500)
+		set.AddUint64(50)
+		set.RemoveUint64(500)
+	})
+	if allocs != 0 && !raceEnabled { // The race detector drops pooled buffers at random
+		t.Errorf("Expected lookups of integers not to allocate, got %v allocations", allocs)
+	}
+}
@@ -0,0 +1,220 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+)
+
+// Uint64Set binary format
+//
+//	magic    [4]byte "K4U8"
+//	version  uint8
+//	hasher   uint8   hasher of the build, which placed the members
+//	seed     uint64  little endian
+//	count    uint64  little endian
+//	members  count x uint64 little endian, in bucket order
+//	checksum uint32  little endian crc32 (IEEE) of everything before it
+const uint64SetMagic = "K4U8"
+const uint64SetVersion = 1
+const uint64SetHeaderLen = len(uint64SetMagic) + 2 + 8 + 8 // magic, version, hasher, seed and count
+
+// Uint64Set is a hash set of uint64 values stored as is, without the byte slice and interface of every
+// HashSet element, taking 8 bytes per member plus the bucket headers.
+// It shares the hashing and growth of HashSet, a value hashes like its 8 byte big endian encoding does.
+type Uint64Set struct {
+	Buckets  [][]uint64 // Buckets of members
+	Size     int        // Number of members
+	Capacity int        // Number of buckets, always a power of two
+	Seed     uint64     // Seed used to hash members
+	Hasher   uint8      // Hasher used to hash members, fixed at compile time
+}
+
+// NewUint64Set creates a new instance of Uint64Set.
+func NewUint64Set() *Uint64Set {
+	return newUint64Set(initialCapacity, defaultSeed)
+}
+
+// newUint64Set creates a new instance of Uint64Set with the given capacity and seed.
+func newUint64Set(capacity int, seed uint64) *Uint64Set {
+	return &Uint64Set{
+		Buckets:  make([][]uint64, capacity),
+		Size:     0,
+		Capacity: capacity,
+		Seed:     seed,
+		Hasher:   hasherID,
+	}
+}
+
+// index computes the bucket of v for the given capacity.
+func (s *Uint64Set) index(v uint64, capacity int) int {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	return hashIndex(buf[:], s.Seed, capacity)
+}
+
+// find returns the position of v in the bucket at index, or -1 if it is absent.
+func (s *Uint64Set) find(index int, v uint64) int {
+	for i, member := range s.Buckets[index] {
+		if member == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add inserts v into the set, reporting whether it was not already present.
+func (s *Uint64Set) Add(v uint64) bool {
+	index := s.index(v, s.Capacity) // Compute the index
+	if s.find(index, v) >= 0 {
+		return false // Member already exists
+	}
+
+	s.Buckets[index] = append(s.Buckets[index], v)
+	s.Size++
+
+	// Check if we need to resize the set
+	if float64(s.Size)/float64(s.Capacity) > loadFactorThreshold {
+		s.resize()
+	}
+	return true
+}
+
+// Contains checks if v is in the set.
+func (s *Uint64Set) Contains(v uint64) bool {
+	return s.find(s.index(v, s.Capacity), v) >= 0
+}
+
+// Remove deletes v from the set.
+func (s *Uint64Set) Remove(v uint64) {
+	index := s.index(v, s.Capacity) // Compute the index
+	if i := s.find(index, v); i >= 0 {
+		s.Buckets[index] = append(s.Buckets[index][:i], s.Buckets[index][i+1:]...) // Remove the member
+		s.Size--
+	}
+}
+
+// Len returns the number of members in the set.
+func (s *Uint64Set) Len() int {
+	return s.Size
+}
+
+// ForEach calls fn for every member in bucket order until fn returns false.
+func (s *Uint64Set) ForEach(fn func(v uint64) bool) {
+	for _, bucket := range s.Buckets {
+		for _, member := range bucket {
+			if !fn(member) {
+				return
+			}
+		}
+	}
+}
+
+// resize increases the capacity of the set.
+func (s *Uint64Set) resize() {
+	newCapacity, ok := growCapacity(s.Capacity) // new capacity
+	if !ok {
+		return // At the maximum capacity, let the chains grow
+	}
+
+	newBuckets := make([][]uint64, newCapacity) // new buckets
+
+	for _, bucket := range s.Buckets {
+		for _, member := range bucket {
+			newIndex := s.index(member, newCapacity) // Compute the new index
+			newBuckets[newIndex] = append(newBuckets[newIndex], member)
+		}
+	}
+
+	s.Buckets = newBuckets   // Update the buckets
+	s.Capacity = newCapacity // Update the capacity
+}
+
+// Serialize encodes the set into the packed Uint64Set binary format.
+func (s *Uint64Set) Serialize() ([]byte, error) {
+	buf := make([]byte, 0, uint64SetHeaderLen+8*s.Size+4)
+	buf = append(buf, uint64SetMagic...)
+	buf = append(buf, uint64SetVersion, s.Hasher)
+	buf = binary.LittleEndian.AppendUint64(buf, s.Seed)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(s.Size))
+
+	for _, bucket := range s.Buckets {
+		for _, member := range bucket {
+			buf = binary.LittleEndian.AppendUint64(buf, member)
+		}
+	}
+
+	return binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf)), nil
+}
+
+// DeserializeUint64Set decodes a set encoded by Uint64Set.Serialize.
+// The members are placed in a set sized to hold them without a resize.
+func DeserializeUint64Set(data []byte) (*Uint64Set, error) {
+	if len(data) < uint64SetHeaderLen+4 {
+		return nil, wrapf(ErrCorrupt, "corrupt uint64 set: payload too short")
+	}
+
+	if string(data[:len(uint64SetMagic)]) != uint64SetMagic {
+		return nil, wrapf(ErrCorrupt, "corrupt uint64 set: invalid magic")
+	}
+
+	if version := data[len(uint64SetMagic)]; version != uint64SetVersion {
+		return nil, wrapf(ErrUnsupportedVersion, "unsupported uint64 set version %d", version)
+	}
+
+	// Verify the checksum before trusting any of the content
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(body):]) {
+		return nil, wrapf(ErrChecksumMismatch, "corrupt uint64 set: checksum mismatch")
+	}
+
+	if err := checkHasher(body[len(uint64SetMagic)+1]); err != nil {
+		return nil, err
+	}
+
+	seed := binary.LittleEndian.Uint64(body[len(uint64SetMagic)+2:])
+	count := binary.LittleEndian.Uint64(body[len(uint64SetMagic)+10:])
+	members := body[uint64SetHeaderLen:]
+	if count != uint64(len(members))/8 || len(members)%8 != 0 {
+		return nil, wrapf(ErrCorrupt, "corrupt uint64 set: %d members in %d bytes", count, len(members))
+	}
+
+	s := newUint64Set(max(capacityFor(int(count)), initialCapacity), seed)
+	for i := 0; i < len(members); i += 8 {
+		s.Add(binary.LittleEndian.Uint64(members[i:]))
+	}
+
+	if s.Size != int(count) {
+		return nil, wrapf(ErrCorrupt, "corrupt uint64 set: %d duplicate members", int(count)-s.Size)
+	}
+	return s, nil
+}
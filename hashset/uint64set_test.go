@@ -0,0 +1,110 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestUint64Set(t *testing.T) {
+	s := NewUint64Set()
+	for v := uint64(0); v < 1000; v++ {
+		if !s.Add(v * 7) {
+			t.Errorf("Expected %d to be new", v*7)
+		}
+	}
+	if s.Add(7) {
+		t.Errorf("Expected 7 to be present")
+	}
+	if s.Len() != 1000 || s.Capacity < 1000 {
+		t.Errorf("Expected 1000 members in a grown set, got %d in %d buckets", s.Len(), s.Capacity)
+	}
+
+	for v := uint64(0); v < 7000; v++ {
+		if s.Contains(v) != (v%7 == 0) {
+			t.Errorf("Expected Contains(%d) to be %v", v, v%7 == 0)
+		}
+	}
+
+	s.Remove(14)
+	s.Remove(15) // Absent
+	if s.Contains(14) || s.Len() != 999 {
+		t.Errorf("Expected 14 to be removed, got length %d", s.Len())
+	}
+
+	// A value lands where a HashSet of the same seed places its big endian encoding
+	h := NewHashSetWithCapacity(s.Capacity)
+	h.AddUint64(21)
+	for index, bucket := range h.Buckets {
+		if len(bucket) > 0 && !slices.Contains(s.Buckets[index], 21) {
+			t.Errorf("Expected 21 in bucket %d", index)
+		}
+	}
+}
+
+func TestUint64Set_Serialize(t *testing.T) {
+	s := NewUint64Set()
+	for v := uint64(0); v < 500; v++ {
+		s.Add(v << 32)
+	}
+
+	data, err := s.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64SetHeaderLen + 8*500 + 4; len(data) != want {
+		t.Errorf("Expected a packed payload of %d bytes, got %d", want, len(data))
+	}
+
+	decoded, err := DeserializeUint64Set(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Len() != 500 {
+		t.Errorf("Expected 500 members, got %d", decoded.Len())
+	}
+	s.ForEach(func(v uint64) bool {
+		if !decoded.Contains(v) {
+			t.Errorf("Expected %d to be decoded", v)
+		}
+		return true
+	})
+
+	data[uint64SetHeaderLen] ^= 1
+	if _, err := DeserializeUint64Set(data); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch, got %v", err)
+	}
+	if _, err := DeserializeUint64Set([]byte("K4U8")); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt for a short payload, got %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/gob"
+	"io"
+	"slices"
+
+	"github.com/guycipher/k4/pager"
+)
+
+// SetInfo summarizes a set in the binary format, see VerifySerialized.
+type SetInfo struct {
+	Version  uint8  // Version of the binary format
+	Hasher   uint8  // ID of the hasher that placed the members
+	Seed     uint64 // Seed of the hasher
+	Capacity int    // Capacity recorded by the encoding set
+	Size     int    // Number of members
+}
+
+// VerifySerialized checks a set written by MarshalBinary without building it. Members are streamed one at
+// a time, so verifying costs memory for a single member. It checks the checksum, the header, that the
+// capacity is a power of two and that the payload holds exactly the recorded number of members.
+func VerifySerialized(r io.Reader) (SetInfo, error) {
+	br := newChecksumReader(r)
+	info, err := streamBinary(br, func(value []byte) {})
+	if err != nil {
+		return info, err
+	}
+
+	if info.Capacity == 0 || info.Capacity&(info.Capacity-1) != 0 {
+		return info, wrapf(ErrCorrupt, "corrupt hashset: invalid capacity %d", info.Capacity)
+	}
+
+	if _, err := br.r.ReadByte(); err != io.EOF {
+		return info, wrapf(ErrCorrupt, "corrupt hashset: trailing bytes after the checksum")
+	}
+	return info, nil
+}
+
+// ApproxCountSerialized returns the number of members of a serialized set read from r without building it,
+// for reporting the cardinality of many set files cheaply. The formats recording a size or count in their
+// header, those of MarshalBinary, SerializeMembers, SerializeFrontCoded, WriteFrozen, WriteBlocks, SerializeSplit,
+// FrozenSet.WriteTo and Uint64Set.Serialize, are read no further than the header; a part of SerializeSplit
+// reports the size of the whole set. The sharded format sums the headers of its shards. The checked format
+// has no size, so its bucket counts are streamed and summed, and the gob format of Serialize is decoded without
+// keeping the buckets. Checksums are not verified, see VerifySerialized.
+func ApproxCountSerialized(r io.Reader) (uint64, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil {
+		return 0, wrapf(ErrCorrupt, "corrupt hashset: %w", err)
+	}
+
+	// The block format starts with a pager header
+	if page, err := br.Peek(pager.HEADER_SIZE + len(blocksMagic)); err == nil && string(page[pager.HEADER_SIZE:]) == blocksMagic {
+		br.Discard(pager.HEADER_SIZE)
+		return countFixed(br, 1+8+8, blocksVersion) // Hasher, threshold and block count
+	}
+
+	switch string(magic) {
+	case binaryMagic:
+		version, err := countHeader(br, 0, 1, binaryVersion) // Version 1 has no hasher byte
+		if err != nil {
+			return 0, err
+		}
+		skip := 8 // Seed
+		if version == binaryVersion {
+			skip++ // Hasher
+		}
+		if _, err := br.Discard(skip); err != nil {
+			return 0, wrapf(ErrCorrupt, "corrupt hashset: %w", err)
+		}
+		if _, err := readCountUvarint(br); err != nil { // Capacity
+			return 0, err
+		}
+		return readCountUvarint(br)
+	case membersMagic:
+		if _, err := countHeader(br, 0, membersVersion); err != nil {
+			return 0, err
+		}
+		return readCountUvarint(br)
+	case frontCodedMagic:
+		if _, err := countHeader(br, 0, frontCodedVersion); err != nil {
+			return 0, err
+		}
+		return readCountUvarint(br)
+	case frozenMagic:
+		return countFixed(br, 1+8, frozenVersion) // Hasher and threshold
+	case splitMagic:
+		return countFixed(br, 1+8+4+4+8, splitVersion) // Hasher, seed, part, parts and capacity
+	case mphMagic:
+		return countFixed(br, 1+8+8, mphVersion) // Hasher, threshold and seed
+	case uint64SetMagic:
+		return countFixed(br, 1+8, uint64SetVersion) // Hasher and seed
+	case shardedMagic:
+		return countSharded(br)
+	case checkedMagic:
+		return countChecked(br)
+	case diffMagic:
+		return 0, wrapf(ErrCorrupt, "corrupt hashset: a diff holds no set")
+	}
+
+	var g struct{ Size int } // The buckets are read and dropped
+	if err := gob.NewDecoder(br).Decode(&g); err != nil || g.Size < 0 {
+		return 0, wrapf(ErrCorrupt, "corrupt hashset: unrecognized encoding")
+	}
+	return uint64(g.Size), nil
+}
+
+// countHeader reads the magic and version of a payload and skips the next skip bytes.
+// It returns the version, or an error if it is not one of versions.
+func countHeader(br *bufio.Reader, skip int, versions ...uint8) (uint8, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return 0, wrapf(ErrCorrupt, "corrupt hashset: %w", err)
+	}
+
+	version := header[4]
+	if !slices.Contains(versions, version) {
+		return 0, wrapf(ErrUnsupportedVersion, "unsupported %s version %d", header[:4], version)
+	}
+	if _, err := br.Discard(skip); err != nil {
+		return 0, wrapf(ErrCorrupt, "corrupt hashset: %w", err)
+	}
+	return version, nil
+}
+
+// countFixed reads the little endian uint64 count following skip bytes of header in the formats with fixed fields.
+func countFixed(br *bufio.Reader, skip int, version uint8) (uint64, error) {
+	if _, err := countHeader(br, skip, version); err != nil {
+		return 0, err
+	}
+
+	var count [8]byte
+	if _, err := io.ReadFull(br, count[:]); err != nil {
+		return 0, wrapf(ErrCorrupt, "corrupt hashset: %w", err)
+	}
+	return binary.LittleEndian.Uint64(count[:]), nil
+}
+
+// countSharded sums the sizes recorded by the shards of the sharded format.
+func countSharded(br *bufio.Reader) (uint64, error) {
+	if _, err := countHeader(br, 0, shardedVersion); err != nil {
+		return 0, err
+	}
+	shards, err := readCountUvarint(br)
+	if err != nil {
+		return 0, err
+	}
+
+	var total uint64
+	for i := uint64(0); i < shards; i++ {
+		length, err := readCountUvarint(br)
+		if err != nil {
+			return 0, err
+		}
+		shard := &io.LimitedReader{R: br, N: int64(length)}
+		size, err := ApproxCountSerialized(shard)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := io.Copy(io.Discard, shard); err != nil { // The rest of the shard
+			return 0, wrapf(ErrCorrupt, "corrupt hashset: %w", err)
+		}
+		if shard.N > 0 {
+			return 0, wrapf(ErrCorrupt, "corrupt hashset: shard %d truncated", i)
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// countChecked streams the buckets of the checked format, summing their member counts.
+func countChecked(br *bufio.Reader) (uint64, error) {
+	if _, err := countHeader(br, 1+8, checkedVersion); err != nil { // Hasher and seed
+		return 0, err
+	}
+	capacity, err := readCountUvarint(br)
+	if err != nil {
+		return 0, err
+	}
+	if capacity > maxCapacity {
+		return 0, wrapf(ErrCorrupt, "corrupt hashset: invalid capacity %d", capacity)
+	}
+	if _, err := br.Discard(4); err != nil { // Header checksum
+		return 0, wrapf(ErrCorrupt, "corrupt hashset: %w", err)
+	}
+
+	var total uint64
+	for i := uint64(0); i < capacity; i++ {
+		length, err := readCountUvarint(br)
+		if err != nil {
+			return 0, err
+		}
+		count, err := readCountUvarint(br)
+		if err != nil {
+			return 0, err
+		}
+		if length > maxCapacity || length < uint64(uvarintLen(count)) {
+			return 0, wrapf(ErrCorrupt, "corrupt hashset: invalid bucket %d", i)
+		}
+		rest := int64(length) - int64(uvarintLen(count)) + 4 // Members and body checksum
+		if _, err := io.CopyN(io.Discard, br, rest); err != nil {
+			return 0, wrapf(ErrCorrupt, "corrupt hashset: %w", err)
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// readCountUvarint reads an unsigned varint from br, reporting a truncated or malformed one as corrupt.
+func readCountUvarint(br *bufio.Reader) (uint64, error) {
+	v, err := binary.ReadUvarint(br)
+	if err != nil {
+		return 0, wrapf(ErrCorrupt, "corrupt hashset: %w", err)
+	}
+	return v, nil
+}
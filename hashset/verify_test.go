@@ -0,0 +1,154 @@
+// Package hashset
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package hashset
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestVerifySerialized(t *testing.T) {
+	set := NewHashSetKeyed([]byte("tenant"))
+	for i := 0; i < 1000; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	data, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := VerifySerialized(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := SetInfo{Version: binaryVersion, Hasher: set.Hasher, Seed: set.Seed, Capacity: set.Capacity, Size: 1000}
+	if info != want {
+		t.Errorf("Expected info %+v, got %+v", want, info)
+	}
+}
+
+func TestVerifySerializedCorrupt(t *testing.T) {
+	set := NewHashSet()
+	for i := 0; i < 100; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+	}
+
+	data, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flipped := bytes.Clone(data)
+	flipped[len(flipped)/2] ^= 0xff
+
+	for name, payload := range map[string][]byte{
+		"truncated": data[:len(data)-1],
+		"flipped":   flipped,
+		"trailing":  append(bytes.Clone(data), 0),
+		"magic":     append([]byte("XXXX"), data[4:]...),
+	} {
+		if _, err := VerifySerialized(bytes.NewReader(payload)); err == nil {
+			t.Errorf("Expected an error for the %s payload", name)
+		}
+	}
+}
+
+func TestApproxCountSerialized(t *testing.T) {
+	set := NewHashSet()
+	sharded := NewShardedHashSet(4)
+	numbers := NewUint64Set()
+	for i := 0; i < 300; i++ {
+		set.Add([]byte(fmt.Sprintf("test%d", i)))
+		sharded.Add([]byte(fmt.Sprintf("test%d", i)))
+		numbers.Add(uint64(i))
+	}
+
+	stream := func(write func(w *bytes.Buffer) error) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := write(&buf); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return buf.Bytes()
+	}
+	encoded := func(data []byte, err error) []byte {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return data
+	}
+
+	payloads := map[string][]byte{
+		"Serialize":           encoded(set.Serialize()),
+		"MarshalBinary":       encoded(set.MarshalBinary()),
+		"SerializeMembers":    encoded(set.SerializeMembers()),
+		"ShardedSerialize":    encoded(sharded.Serialize()),
+		"Uint64SetSerialize":  encoded(numbers.Serialize()),
+		"SerializeSplit":      splitParts(t, set, 1<<10)[1],
+		"SerializeFrontCoded": stream(func(w *bytes.Buffer) error { return set.SerializeFrontCoded(w) }),
+		"SerializeChecked":    stream(func(w *bytes.Buffer) error { return set.SerializeChecked(w) }),
+		"WriteFrozen":         stream(func(w *bytes.Buffer) error { return set.WriteFrozen(w) }),
+		"WriteBlocks":         stream(func(w *bytes.Buffer) error { return set.WriteBlocks(w, 512) }),
+		"FrozenSetWriteTo": stream(func(w *bytes.Buffer) error {
+			frozen, err := set.BuildMPH()
+			if err != nil {
+				return err
+			}
+			_, err = frozen.WriteTo(w)
+			return err
+		}),
+	}
+	for name, data := range payloads {
+		if n, err := ApproxCountSerialized(bytes.NewReader(data)); err != nil || n != 300 {
+			t.Errorf("Expected 300 members from %s, got %d, %v", name, n, err)
+		}
+	}
+
+	if n, err := ApproxCountSerialized(bytes.NewReader(encoded(NewHashSet().MarshalBinary()))); err != nil || n != 0 {
+		t.Errorf("Expected an empty set to count 0, got %d, %v", n, err)
+	}
+
+	for _, data := range [][]byte{nil, []byte("garbage data"), payloads["MarshalBinary"][:5]} {
+		if _, err := ApproxCountSerialized(bytes.NewReader(data)); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("Expected ErrCorrupt for %q, got %v", data, err)
+		}
+	}
+
+	future := bytes.Clone(payloads["SerializeMembers"])
+	future[4] = 99
+	if _, err := ApproxCountSerialized(bytes.NewReader(future)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion for an unknown version, got %v", err)
+	}
+}
@@ -59,10 +59,7 @@ func Hash64(key []byte, seed uint64) uint64 {
 
 	// Process the input in 8-byte chunks
 	for i := 0; i < len(key)/8; i++ {
-		k = binary.LittleEndian.Uint64(key[i*8:])
-		h ^= scramble64(k)
-		h = (h << 27) | (h >> 37) // Rotate left by 27 bits
-		h = h*5 + 0x52dce729
+		h = block64(h, binary.LittleEndian.Uint64(key[i*8:]))
 	}
 
 	// Process the remaining bytes
@@ -73,15 +70,67 @@ func Hash64(key []byte, seed uint64) uint64 {
 	}
 	h ^= scramble64(k)
 
-	// Finalize the hash
-	h ^= uint64(len(key))
+	return finalize64(h, len(key)) // Return the final hash
+}
+
+// Hash64Multi computes the Hash64 of the concatenation of parts with seed 0 without concatenating them.
+func Hash64Multi(parts ...[]byte) uint64 {
+	return Hash64MultiSeed(0, parts...)
+}
+
+// Hash64MultiSeed computes the Hash64 of the concatenation of parts and the given seed without concatenating them.
+// Chunks spanning two parts are assembled in a fixed buffer, so hashing allocates nothing.
+func Hash64MultiSeed(seed uint64, parts ...[]byte) uint64 {
+	h := seed // initialize hash with seed
+	var buf [8]byte
+	buffered, length := 0, 0
+
+	for _, part := range parts {
+		length += len(part)
+
+		// Complete the chunk started by the previous parts
+		if buffered > 0 {
+			n := copy(buf[buffered:], part)
+			buffered += n
+			part = part[n:]
+			if buffered < len(buf) {
+				continue
+			}
+			h = block64(h, binary.LittleEndian.Uint64(buf[:]))
+			buffered = 0
+		}
+
+		// Process the input in 8-byte chunks
+		for len(part) >= 8 {
+			h = block64(h, binary.LittleEndian.Uint64(part))
+			part = part[8:]
+		}
+		buffered = copy(buf[:], part)
+	}
+
+	// Process the remaining bytes
+	clear(buf[buffered:])
+	h ^= scramble64(binary.LittleEndian.Uint64(buf[:]))
+
+	return finalize64(h, length) // Return the final hash
+}
+
+// block64 mixes an 8-byte chunk k into the 64-bit hash h
+func block64(h, k uint64) uint64 {
+	h ^= scramble64(k)
+	h = (h << 27) | (h >> 37) // Rotate left by 27 bits
+	return h*5 + 0x52dce729
+}
+
+// finalize64 mixes the length into the 64-bit hash h and avalanches it
+func finalize64(h uint64, length int) uint64 {
+	h ^= uint64(length)
 	h ^= h >> 33
 	h *= m64
 	h ^= h >> 33
 	h *= seed64
 	h ^= h >> 33
-
-	return h // Return the final hash
+	return h
 }
 
 // scramble32 performs the scrambling operation for 32-bit hash
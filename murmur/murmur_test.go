@@ -33,6 +33,7 @@ package murmur
 
 import (
 	"github.com/guycipher/k4/fuzz"
+	"math/bits"
 	"testing"
 	"time"
 )
@@ -77,6 +78,34 @@ func TestHash64Many(t *testing.T) {
 
 }
 
+func TestHash64Multi(t *testing.T) {
+	key := []byte("namespace/0123456789abcdef/suffix-of-many-bytes")
+	for seed := uint64(0); seed < 3; seed++ {
+		// Every split of the key in up to three parts hashes like the key
+		for i := 0; i <= len(key); i++ {
+			for j := i; j <= len(key); j++ {
+				want := Hash64(key[:j], seed)
+				if got := Hash64MultiSeed(seed, key[:i], key[i:j]); got != want {
+					t.Fatalf("Expected %x for the split at %d of %q, got %x", want, i, key[:j], got)
+				}
+				if got := Hash64MultiSeed(seed, key[:i], nil, key[i:j], key[j:]); got != Hash64(key, seed) {
+					t.Fatalf("Expected %x for the splits at %d and %d, got %x", Hash64(key, seed), i, j, got)
+				}
+			}
+		}
+	}
+
+	if Hash64Multi(key[:9], key[9:]) != Hash64(key, 0) {
+		t.Errorf("Expected Hash64Multi to hash with seed 0")
+	}
+	if Hash64MultiSeed(4) != Hash64(nil, 4) || Hash64Multi() != Hash64(nil, 0) {
+		t.Errorf("Expected no parts to hash like the empty key")
+	}
+	if allocs := testing.AllocsPerRun(100, func() { Hash64Multi(key[:5], key[5:17], key[17:]) }); allocs != 0 {
+		t.Errorf("Expected no allocation, got %v", allocs)
+	}
+}
+
 func TestHash32(t *testing.T) {
 
 	tests := []struct {
@@ -117,6 +146,72 @@ func TestHash32Many(t *testing.T) {
 
 }
 
+// shortKey encodes i into a little endian key of the given length
+func shortKey(i, length int) []byte {
+	key := make([]byte, length)
+	for j := range key {
+		key[j] = byte(i >> (8 * j))
+	}
+	return key
+}
+
+func TestHash64ShortKeyDistribution(t *testing.T) {
+	tests := []struct {
+		length  int
+		keys    int
+		buckets int
+	}{
+		{1, 256, 16},
+		{2, 1 << 16, 64},
+		{2, 1 << 16, 1024},
+		{3, 1 << 20, 1024},
+		{4, 1 << 20, 4096},
+	}
+
+	for _, tt := range tests {
+		counts := make([]int, tt.buckets)
+		for i := 0; i < tt.keys; i++ {
+			counts[Hash64(shortKey(i, tt.length), 4)%uint64(tt.buckets)]++
+		}
+
+		// Chi-squared statistic normalized by the degrees of freedom, 1.0 for a uniform distribution
+		expected := float64(tt.keys) / float64(tt.buckets)
+		chi := 0.0
+		for _, c := range counts {
+			d := float64(c) - expected
+			chi += d * d / expected
+		}
+		chi /= float64(tt.buckets - 1)
+
+		if chi > 1.5 {
+			t.Errorf("Expected uniform distribution of %d byte keys over %d buckets, got normalized chi-squared %f", tt.length, tt.buckets, chi)
+		}
+	}
+}
+
+func TestHash64ShortKeyAvalanche(t *testing.T) {
+	for length := 1; length <= 4; length++ {
+		flipped, trials := 0, 0
+		for i := 0; i < 1024; i++ {
+			key := shortKey(i*2654435761, length)
+			h := Hash64(key, 4)
+
+			// Flipping any single input bit should flip about half the output bits
+			for bit := 0; bit < length*8; bit++ {
+				key[bit/8] ^= 1 << (bit % 8)
+				flipped += bits.OnesCount64(h ^ Hash64(key, 4))
+				key[bit/8] ^= 1 << (bit % 8)
+				trials++
+			}
+		}
+
+		avg := float64(flipped) / float64(trials)
+		if avg < 30 || avg > 34 {
+			t.Errorf("Expected about 32 flipped bits for %d byte keys, got %f", length, avg)
+		}
+	}
+}
+
 func BenchmarkHash64(b *testing.B) {
 	key := []byte("benchmarking 64-bit murmur3 hash function")
 	seed := uint64(0)
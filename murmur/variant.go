@@ -0,0 +1,285 @@
+// Package murmur
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package murmur
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Variant selects one of the reference MurmurHash3 functions, for interop with other implementations.
+// Hash64 and Hash32 remain the hashes used by K4 itself.
+type Variant uint8
+
+const (
+	X86_32  Variant = iota + 1 // MurmurHash3_x86_32
+	X86_128                    // MurmurHash3_x86_128
+	X64_128                    // MurmurHash3_x64_128
+)
+
+// Constants for the 128-bit hashes
+const (
+	c1x86 = 0x239b961b
+	c2x86 = 0xab0e9789
+	c3x86 = 0x38b34ae5
+	c4x86 = 0xa1e38b93
+
+	c1x64 = 0x87c37b91114253d5
+	c2x64 = 0x4cf5ad432745937f
+)
+
+// String returns the reference name of the variant.
+func (v Variant) String() string {
+	switch v {
+	case X86_32:
+		return "x86_32"
+	case X86_128:
+		return "x86_128"
+	case X64_128:
+		return "x64_128"
+	}
+	return "unknown"
+}
+
+// Sum64 hashes key with the variant and returns the first 64 bits of the digest.
+// The 32-bit variant is zero extended, so a value reduced modulo a shard count matches the reference hash.
+// It panics for an unknown variant.
+func (v Variant) Sum64(key []byte, seed uint32) uint64 {
+	switch v {
+	case X86_32:
+		return uint64(Hash32(key, seed))
+	case X86_128:
+		h1, _ := Hash128x86(key, seed)
+		return h1
+	case X64_128:
+		h1, _ := Hash128x64(key, seed)
+		return h1
+	}
+	panic("murmur: unknown variant")
+}
+
+// fmix32 is the finalization mix of the 32-bit hashes
+func fmix32(h uint32) uint32 {
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
+
+// fmix64 is the finalization mix of the 64-bit hashes
+func fmix64(k uint64) uint64 {
+	k ^= k >> 33
+	k *= m64
+	k ^= k >> 33
+	k *= seed64
+	k ^= k >> 33
+	return k
+}
+
+// Hash128x86 computes the reference MurmurHash3_x86_128 hash for the given key and seed.
+// The four 32-bit words of the digest are returned in little-endian order, so the 16 digest bytes are
+// the little-endian encodings of the first and then the second result.
+func Hash128x86(key []byte, seed uint32) (uint64, uint64) {
+	h1, h2, h3, h4 := seed, seed, seed, seed
+
+	// Process the input in 16-byte blocks
+	nblocks := len(key) / 16
+	for i := 0; i < nblocks; i++ {
+		block := key[i*16:]
+		k1 := binary.LittleEndian.Uint32(block)
+		k2 := binary.LittleEndian.Uint32(block[4:])
+		k3 := binary.LittleEndian.Uint32(block[8:])
+		k4 := binary.LittleEndian.Uint32(block[12:])
+
+		k1 *= c1x86
+		k1 = bits.RotateLeft32(k1, 15)
+		k1 *= c2x86
+		h1 ^= k1
+		h1 = bits.RotateLeft32(h1, 19)
+		h1 += h2
+		h1 = h1*5 + 0x561ccd1b
+
+		k2 *= c2x86
+		k2 = bits.RotateLeft32(k2, 16)
+		k2 *= c3x86
+		h2 ^= k2
+		h2 = bits.RotateLeft32(h2, 17)
+		h2 += h3
+		h2 = h2*5 + 0x0bcaa747
+
+		k3 *= c3x86
+		k3 = bits.RotateLeft32(k3, 17)
+		k3 *= c4x86
+		h3 ^= k3
+		h3 = bits.RotateLeft32(h3, 15)
+		h3 += h4
+		h3 = h3*5 + 0x96cd1c35
+
+		k4 *= c4x86
+		k4 = bits.RotateLeft32(k4, 18)
+		k4 *= c1x86
+		h4 ^= k4
+		h4 = bits.RotateLeft32(h4, 13)
+		h4 += h1
+		h4 = h4*5 + 0x32ac3b17
+	}
+
+	// Process the remaining bytes, up to four per lane
+	tail := key[nblocks*16:]
+	var k [4]uint32
+	for i := len(tail) - 1; i >= 0; i-- {
+		k[i/4] = k[i/4]<<8 | uint32(tail[i])
+	}
+	if len(tail) > 12 {
+		k[3] *= c4x86
+		k[3] = bits.RotateLeft32(k[3], 18)
+		k[3] *= c1x86
+		h4 ^= k[3]
+	}
+	if len(tail) > 8 {
+		k[2] *= c3x86
+		k[2] = bits.RotateLeft32(k[2], 17)
+		k[2] *= c4x86
+		h3 ^= k[2]
+	}
+	if len(tail) > 4 {
+		k[1] *= c2x86
+		k[1] = bits.RotateLeft32(k[1], 16)
+		k[1] *= c3x86
+		h2 ^= k[1]
+	}
+	if len(tail) > 0 {
+		k[0] *= c1x86
+		k[0] = bits.RotateLeft32(k[0], 15)
+		k[0] *= c2x86
+		h1 ^= k[0]
+	}
+
+	// Finalize the hash
+	length := uint32(len(key))
+	h1 ^= length
+	h2 ^= length
+	h3 ^= length
+	h4 ^= length
+
+	h1 += h2 + h3 + h4
+	h2 += h1
+	h3 += h1
+	h4 += h1
+
+	h1 = fmix32(h1)
+	h2 = fmix32(h2)
+	h3 = fmix32(h3)
+	h4 = fmix32(h4)
+
+	h1 += h2 + h3 + h4
+	h2 += h1
+	h3 += h1
+	h4 += h1
+
+	return uint64(h2)<<32 | uint64(h1), uint64(h4)<<32 | uint64(h3)
+}
+
+// Hash128x64 computes the reference MurmurHash3_x64_128 hash for the given key and seed.
+// The 16 digest bytes are the little-endian encodings of the first and then the second result.
+func Hash128x64(key []byte, seed uint32) (uint64, uint64) {
+	h1, h2 := uint64(seed), uint64(seed)
+
+	// Process the input in 16-byte blocks
+	nblocks := len(key) / 16
+	for i := 0; i < nblocks; i++ {
+		k1 := binary.LittleEndian.Uint64(key[i*16:])
+		k2 := binary.LittleEndian.Uint64(key[i*16+8:])
+
+		k1 *= c1x64
+		k1 = bits.RotateLeft64(k1, 31)
+		k1 *= c2x64
+		h1 ^= k1
+		h1 = bits.RotateLeft64(h1, 27)
+		h1 += h2
+		h1 = h1*5 + 0x52dce729
+
+		k2 *= c2x64
+		k2 = bits.RotateLeft64(k2, 33)
+		k2 *= c1x64
+		h2 ^= k2
+		h2 = bits.RotateLeft64(h2, 31)
+		h2 += h1
+		h2 = h2*5 + 0x38495ab5
+	}
+
+	// Process the remaining bytes, up to eight per lane
+	tail := key[nblocks*16:]
+	var k [2]uint64
+	for i := len(tail) - 1; i >= 0; i-- {
+		k[i/8] = k[i/8]<<8 | uint64(tail[i])
+	}
+	if len(tail) > 8 {
+		k[1] *= c2x64
+		k[1] = bits.RotateLeft64(k[1], 33)
+		k[1] *= c1x64
+		h2 ^= k[1]
+	}
+	if len(tail) > 0 {
+		k[0] *= c1x64
+		k[0] = bits.RotateLeft64(k[0], 31)
+		k[0] *= c2x64
+		h1 ^= k[0]
+	}
+
+	// Finalize the hash
+	h1 ^= uint64(len(key))
+	h2 ^= uint64(len(key))
+
+	h1 += h2
+	h2 += h1
+
+	h1 = fmix64(h1)
+	h2 = fmix64(h2)
+
+	h1 += h2
+	h2 += h1
+
+	return h1, h2
+}
+
+// ContentKey derives the content address of data: its MurmurHash3_x64_128 digest with seed 0,
+// in the reference byte order of Hash128x64. Keys are stable across versions, see the test vectors.
+// It is not a cryptographic hash and must not address content chosen by an adversary.
+func ContentKey(data []byte) [16]byte {
+	var key [16]byte
+	h1, h2 := Hash128x64(data, 0)
+	binary.LittleEndian.PutUint64(key[:8], h1)
+	binary.LittleEndian.PutUint64(key[8:], h2)
+	return key
+}
@@ -0,0 +1,139 @@
+// Package murmur tests
+// BSD 3-Clause License
+//
+// Copyright (c) 2024, Alex Gaetano Padula
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//  1. Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//  2. Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+//  3. Neither the name of the copyright holder nor the names of its
+//     contributors may be used to endorse or promote products derived from
+//     this software without specific prior written permission.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package murmur
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+// Reference digests of MurmurHash3 with seed 0, as produced by the SMHasher implementation
+func TestVariantVectors(t *testing.T) {
+	tests := []struct {
+		key     string
+		x86_32  uint32
+		x86_128 [2]uint64
+		x64_128 [2]uint64
+	}{
+		{"", 0x00000000, [2]uint64{0, 0}, [2]uint64{0, 0}},
+		{"hello", 0x248bfa47, [2]uint64{0xdb91def72b2444a0, 0x9adb31b69adb31b6}, [2]uint64{0xcbd8a7b341bd9b02, 0x5b1e906a48ae1d19}},
+		{"hello, world", 0x149bbb7f, [2]uint64{0xb9b98a1e8b21605c, 0xeb5957c793273a83}, [2]uint64{0x342fac623a5ebc8e, 0x4cdcbc079642414d}},
+		{"The quick brown fox jumps over the lazy dog.", 0xd5c48bfc, [2]uint64{0x7dd6ed5e6cbb6099, 0x9b627b552bbf0fbb}, [2]uint64{0xcd99481f9ee902c9, 0x695da1a38987b6e7}},
+	}
+
+	for _, tt := range tests {
+		key := []byte(tt.key)
+		if h := Hash32(key, 0); h != tt.x86_32 {
+			t.Errorf("Expected x86_32 of %q to be %08x, got %08x", tt.key, tt.x86_32, h)
+		}
+		if h1, h2 := Hash128x86(key, 0); [2]uint64{h1, h2} != tt.x86_128 {
+			t.Errorf("Expected x86_128 of %q to be %016x%016x, got %016x%016x", tt.key, tt.x86_128[0], tt.x86_128[1], h1, h2)
+		}
+		if h1, h2 := Hash128x64(key, 0); [2]uint64{h1, h2} != tt.x64_128 {
+			t.Errorf("Expected x64_128 of %q to be %016x%016x, got %016x%016x", tt.key, tt.x64_128[0], tt.x64_128[1], h1, h2)
+		}
+	}
+}
+
+// TestVariantVerification runs the SMHasher verification test: the keys {}, {0}, {0, 1}, ... {0, ..., 254}
+// are hashed with seeds 256 down to 1, and the hash of the concatenated digests must match the published value.
+func TestVariantVerification(t *testing.T) {
+	tests := []struct {
+		variant Variant
+		want    uint32
+	}{
+		{X86_32, 0xb0f57ee3},
+		{X86_128, 0xb3ece62a},
+		{X64_128, 0x6384ba69},
+	}
+
+	for _, tt := range tests {
+		key := make([]byte, 256)
+		var digests []byte
+		for i := 0; i < 256; i++ {
+			key[i] = byte(i)
+			digests = appendDigest(digests, tt.variant, key[:i], uint32(256-i))
+		}
+
+		got := binary.LittleEndian.Uint32(appendDigest(nil, tt.variant, digests, 0))
+		if got != tt.want {
+			t.Errorf("Expected %v verification value %08x, got %08x", tt.variant, tt.want, got)
+		}
+	}
+}
+
+// appendDigest appends the digest bytes of key hashed with the variant to b.
+func appendDigest(b []byte, v Variant, key []byte, seed uint32) []byte {
+	switch v {
+	case X86_32:
+		return binary.LittleEndian.AppendUint32(b, Hash32(key, seed))
+	case X86_128:
+		h1, h2 := Hash128x86(key, seed)
+		return binary.LittleEndian.AppendUint64(binary.LittleEndian.AppendUint64(b, h1), h2)
+	default:
+		h1, h2 := Hash128x64(key, seed)
+		return binary.LittleEndian.AppendUint64(binary.LittleEndian.AppendUint64(b, h1), h2)
+	}
+}
+
+func TestVariantSum64(t *testing.T) {
+	key := []byte("hello")
+	if h := X86_32.Sum64(key, 4); h != uint64(Hash32(key, 4)) {
+		t.Errorf("Expected x86_32 Sum64 to zero extend Hash32, got %016x", h)
+	}
+	if h, _ := Hash128x86(key, 4); X86_128.Sum64(key, 4) != h {
+		t.Errorf("Expected x86_128 Sum64 to return the first half of the digest")
+	}
+	if h, _ := Hash128x64(key, 4); X64_128.Sum64(key, 4) != h {
+		t.Errorf("Expected x64_128 Sum64 to return the first half of the digest")
+	}
+}
+
+// Content keys must never change, stored data is addressed by them
+func TestContentKey(t *testing.T) {
+	tests := []struct {
+		data string
+		key  string
+	}{
+		{"", "00000000000000000000000000000000"},
+		{"hello", "029bbd41b3a7d8cb191dae486a901e5b"},
+		{"hello, world", "8ebc5e3a62ac2f344d41429607bcdc4c"},
+		{"The quick brown fox jumps over the lazy dog.", "c902e99e1f4899cde7b68789a3a15d69"},
+	}
+
+	for _, tt := range tests {
+		key := ContentKey([]byte(tt.data))
+		if got := hex.EncodeToString(key[:]); got != tt.key {
+			t.Errorf("ContentKey(%q) = %s, expected %s", tt.data, got, tt.key)
+		}
+	}
+}